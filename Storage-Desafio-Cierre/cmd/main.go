@@ -3,13 +3,32 @@ package main
 import (
 	"app/internal/application"
 	"fmt"
+	"os"
 
 	"github.com/go-sql-driver/mysql"
+	"github.com/izabelly/go-web/pkg/auth"
+	"github.com/izabelly/go-web/pkg/cors"
+	"github.com/izabelly/go-web/pkg/tlsserver"
 )
 
 func main() {
 	// env
-	// ...
+	// - jwt: optional, enabled when a secret or a jwks file is set
+	var jwt *auth.JWTConfig
+	if os.Getenv("JWT_SECRET") != "" || os.Getenv("JWT_JWKS_FILE") != "" {
+		jwt = &auth.JWTConfig{
+			Secret:   []byte(os.Getenv("JWT_SECRET")),
+			JWKSFile: os.Getenv("JWT_JWKS_FILE"),
+			Issuer:   os.Getenv("JWT_ISSUER"),
+			Audience: os.Getenv("JWT_AUDIENCE"),
+		}
+	}
+
+	// - cors: comma separated origins of the browser apps
+	corsCfg := cors.Config{
+		AllowedOrigins:   cors.ParseList(os.Getenv("CORS_ALLOWED_ORIGINS")),
+		AllowCredentials: os.Getenv("CORS_ALLOW_CREDENTIALS") == "true",
+	}
 
 	// app
 	// - config
@@ -22,6 +41,11 @@ func main() {
 			DBName: "fantasy_products",
 		},
 		Addr: "127.0.0.1:8080",
+		JWT:  jwt,
+		CORS: corsCfg,
+		// - tls: TLS_CERT_FILE, TLS_KEY_FILE, TLS_CLIENT_CA_FILE, TLS_CLIENT_AUTH, TLS_MIN_VERSION
+		TLS:          tlsserver.FromEnv(),
+		ClientScopes: auth.ParseScopeMap(os.Getenv("TLS_CLIENT_SCOPES")),
 	}
 	app := application.NewApplicationDefault(cfg)
 	// - set up
@@ -44,4 +44,18 @@ CREATE TABLE `sales` (
     KEY `idx_sales_product_id` (`product_id`),
     CONSTRAINT `fk_sales_invoice_id` FOREIGN KEY (`invoice_id`) REFERENCES `invoices` (`id`) ON DELETE CASCADE ON UPDATE CASCADE,
     CONSTRAINT `fk_sales_product_id` FOREIGN KEY (`product_id`) REFERENCES `products` (`id`) ON DELETE CASCADE ON UPDATE CASCADE
-);
\ No newline at end of file
+);
+-- Table structure for table `audit_log` (append-only: the application never updates or deletes rows)
+CREATE TABLE `audit_log` (
+    `id` bigint NOT NULL AUTO_INCREMENT,
+    `timestamp` datetime(6) NOT NULL,
+    `actor` varchar(100) NOT NULL,
+    `action` varchar(20) NOT NULL,
+    `entity` varchar(45) NOT NULL,
+    `entity_id` varchar(45) NOT NULL,
+    `request_id` varchar(64) DEFAULT NULL,
+    `diff` json DEFAULT NULL,
+    PRIMARY KEY (`id`),
+    KEY `idx_audit_log_entity` (`entity`, `entity_id`),
+    KEY `idx_audit_log_timestamp` (`timestamp`)
+);
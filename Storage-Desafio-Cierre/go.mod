@@ -1,18 +1,14 @@
 module app
 
-go 1.22.0
+go 1.23.3
 
 toolchain go1.23.4
 
 require (
 	github.com/bootcamp-go/web v1.0.0
-	github.com/go-chi/chi/v5 v5.0.10
+	github.com/go-chi/chi/v5 v5.1.0
 	github.com/go-sql-driver/mysql v1.7.1
+	github.com/izabelly/go-web v0.0.0
 )
 
-require (
-	github.com/golang-migrate/migrate/v4 v4.18.1 // indirect
-	github.com/hashicorp/errwrap v1.1.0 // indirect
-	github.com/hashicorp/go-multierror v1.1.1 // indirect
-	go.uber.org/atomic v1.11.0 // indirect
-)
+replace github.com/izabelly/go-web => ../go-web
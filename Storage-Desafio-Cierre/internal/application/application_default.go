@@ -4,15 +4,27 @@ import (
 	"app/internal/handler"
 	"app/internal/repository"
 	"app/internal/service"
+	"context"
 	"database/sql"
 	"encoding/json"
 	"fmt"
-	"net/http"
 	"os"
+	"time"
 
+	"github.com/bootcamp-go/web/response"
 	"github.com/go-chi/chi/v5"
 	"github.com/go-chi/chi/v5/middleware"
 	"github.com/go-sql-driver/mysql"
+	"github.com/izabelly/go-web/pkg/audit"
+	"github.com/izabelly/go-web/pkg/auth"
+	"github.com/izabelly/go-web/pkg/bodylimit"
+	"github.com/izabelly/go-web/pkg/compress"
+	"github.com/izabelly/go-web/pkg/cors"
+	"github.com/izabelly/go-web/pkg/httpcache"
+	"github.com/izabelly/go-web/pkg/idempotency"
+	"github.com/izabelly/go-web/pkg/ratelimit"
+	"github.com/izabelly/go-web/pkg/secure"
+	"github.com/izabelly/go-web/pkg/tlsserver"
 )
 
 // ConfigApplicationDefault is the configuration for NewApplicationDefault.
@@ -21,14 +33,43 @@ type ConfigApplicationDefault struct {
 	Db *mysql.Config
 	// Addr is the server address.
 	Addr string
+	// JWT enables bearer token authentication when set.
+	JWT *auth.JWTConfig
+	// RateLimit is the limit per api key (or ip) for every route.
+	RateLimit ratelimit.Limit
+	// ReportRateLimit is the limit per api key (or ip) for the aggregation reports.
+	ReportRateLimit ratelimit.Limit
+	// IdempotencyTTL is how long a POST response is replayed for the same Idempotency-Key.
+	IdempotencyTTL time.Duration
+	// CacheTTL is how long a cached GET response lives without being invalidated.
+	CacheTTL time.Duration
+	// CompressMinSize is the smallest response body that is compressed.
+	CompressMinSize int
+	// MaxBodyBytes is the largest request body accepted by the POST endpoints.
+	MaxBodyBytes int64
+	// CORS is the cross-origin configuration for the browser apps.
+	CORS cors.Config
+	// Security are the security headers, nil uses secure.Default.
+	Security *secure.Config
+	// TLS is the certificate configuration, plain http when empty.
+	TLS tlsserver.Config
+	// ClientScopes are the scopes of each client certificate CN (mTLS).
+	ClientScopes map[string][]string
 }
 
 // NewApplicationDefault creates a new ApplicationDefault.
 func NewApplicationDefault(config *ConfigApplicationDefault) *ApplicationDefault {
 	// default values
 	defaultCfg := &ConfigApplicationDefault{
-		Db:   nil,
-		Addr: ":8080",
+		Db:              nil,
+		Addr:            ":8080",
+		RateLimit:       ratelimit.Limit{Requests: 300, Per: time.Minute},
+		ReportRateLimit: ratelimit.Limit{Requests: 30, Per: time.Minute},
+		IdempotencyTTL:  24 * time.Hour,
+		CacheTTL:        5 * time.Minute,
+		CompressMinSize: 1024,
+		MaxBodyBytes:    64 << 10,
+		Security:        &secure.Default,
 	}
 	if config != nil {
 		if config.Db != nil {
@@ -37,11 +78,47 @@ func NewApplicationDefault(config *ConfigApplicationDefault) *ApplicationDefault
 		if config.Addr != "" {
 			defaultCfg.Addr = config.Addr
 		}
+		if config.RateLimit.Requests > 0 {
+			defaultCfg.RateLimit = config.RateLimit
+		}
+		if config.ReportRateLimit.Requests > 0 {
+			defaultCfg.ReportRateLimit = config.ReportRateLimit
+		}
+		if config.IdempotencyTTL > 0 {
+			defaultCfg.IdempotencyTTL = config.IdempotencyTTL
+		}
+		if config.CacheTTL > 0 {
+			defaultCfg.CacheTTL = config.CacheTTL
+		}
+		if config.CompressMinSize > 0 {
+			defaultCfg.CompressMinSize = config.CompressMinSize
+		}
+		if config.MaxBodyBytes > 0 {
+			defaultCfg.MaxBodyBytes = config.MaxBodyBytes
+		}
+		if config.Security != nil {
+			defaultCfg.Security = config.Security
+		}
+		defaultCfg.JWT = config.JWT
+		defaultCfg.CORS = config.CORS
+		defaultCfg.TLS = config.TLS
+		defaultCfg.ClientScopes = config.ClientScopes
 	}
 
 	return &ApplicationDefault{
-		cfgDb:   defaultCfg.Db,
-		cfgAddr: defaultCfg.Addr,
+		cfgDb:              defaultCfg.Db,
+		cfgAddr:            defaultCfg.Addr,
+		cfgJWT:             defaultCfg.JWT,
+		cfgRateLimit:       defaultCfg.RateLimit,
+		cfgReportRateLimit: defaultCfg.ReportRateLimit,
+		cfgIdempotencyTTL:  defaultCfg.IdempotencyTTL,
+		cfgCacheTTL:        defaultCfg.CacheTTL,
+		cfgCompressMinSize: defaultCfg.CompressMinSize,
+		cfgMaxBodyBytes:    defaultCfg.MaxBodyBytes,
+		cfgCORS:            defaultCfg.CORS,
+		cfgSecurity:        *defaultCfg.Security,
+		cfgTLS:             defaultCfg.TLS,
+		cfgClientScopes:    defaultCfg.ClientScopes,
 	}
 }
 
@@ -51,6 +128,28 @@ type ApplicationDefault struct {
 	cfgDb *mysql.Config
 	// cfgAddr is the server address.
 	cfgAddr string
+	// cfgJWT is the bearer token configuration, nil disables it.
+	cfgJWT *auth.JWTConfig
+	// cfgRateLimit is the limit for every route.
+	cfgRateLimit ratelimit.Limit
+	// cfgReportRateLimit is the limit for the aggregation reports.
+	cfgReportRateLimit ratelimit.Limit
+	// cfgIdempotencyTTL is how long the Idempotency-Key responses are kept.
+	cfgIdempotencyTTL time.Duration
+	// cfgCacheTTL is how long a cached GET response lives.
+	cfgCacheTTL time.Duration
+	// cfgCompressMinSize is the smallest response body that is compressed.
+	cfgCompressMinSize int
+	// cfgMaxBodyBytes is the largest request body accepted by the POST endpoints.
+	cfgMaxBodyBytes int64
+	// cfgCORS is the cross-origin configuration.
+	cfgCORS cors.Config
+	// cfgSecurity are the security headers.
+	cfgSecurity secure.Config
+	// cfgTLS is the certificate configuration.
+	cfgTLS tlsserver.Config
+	// cfgClientScopes are the scopes of each client certificate CN.
+	cfgClientScopes map[string][]string
 	// db is the database connection.
 	db *sql.DB
 	// router is the chi router.
@@ -59,6 +158,14 @@ type ApplicationDefault struct {
 
 // SetUp sets up the application.
 func (a *ApplicationDefault) SetUp() (err error) {
+	// - rate limits: an invalid limit would never (or always) admit a request
+	if err = a.cfgRateLimit.Validate(); err != nil {
+		return
+	}
+	if err = a.cfgReportRateLimit.Validate(); err != nil {
+		return
+	}
+
 	// dependencies
 	// - db: init
 	a.db, err = sql.Open("mysql", a.cfgDb.FormatDSN())
@@ -118,65 +225,100 @@ func (a *ApplicationDefault) SetUp() (err error) {
 		}
 	}
 
+	// - cache: responses are invalidated by the repositories on save
+	cache := httpcache.New(a.cfgCacheTTL, 1000)
 	// - repository
-	rpCustomer := repository.NewCustomersMySQL(a.db)
-	rpProduct := repository.NewProductsMySQL(a.db)
-	rpInvoice := repository.NewInvoicesMySQL(a.db)
-	rpSale := repository.NewSalesMySQL(a.db)
+	rpCustomer := repository.NewCustomersInvalidating(repository.NewCustomersMySQL(a.db), cache)
+	rpProduct := repository.NewProductsInvalidating(repository.NewProductsMySQL(a.db), cache)
+	rpInvoice := repository.NewInvoicesInvalidating(repository.NewInvoicesMySQL(a.db), cache)
+	rpSale := repository.NewSalesInvalidating(repository.NewSalesMySQL(a.db), cache)
+	// - audit
+	auditLog := audit.NewLogger(repository.NewAuditMySQL(a.db))
 	// - service
-	svCustomer := service.NewCustomersDefault(rpCustomer)
-	svProduct := service.NewProductsDefault(rpProduct)
-	svInvoice := service.NewInvoicesDefault(rpInvoice)
-	svSale := service.NewSalesDefault(rpSale)
+	svCustomer := service.NewCustomersDefault(rpCustomer, auditLog)
+	svProduct := service.NewProductsDefault(rpProduct, auditLog)
+	svInvoice := service.NewInvoicesDefault(rpInvoice, auditLog)
+	svSale := service.NewSalesDefault(rpSale, auditLog)
 	// - handler
 	hdCustomer := handler.NewCustomersDefault(svCustomer)
 	hdProduct := handler.NewProductsDefault(svProduct)
 	hdInvoice := handler.NewInvoicesDefault(svInvoice)
 	hdSale := handler.NewSalesDefault(svSale)
+	hdAudit := handler.NewAuditDefault(auditLog)
 
 	// routes
 	// - router
 	a.router = chi.NewRouter()
 	// - middlewares
+	a.router.Use(audit.RequestID)
 	a.router.Use(middleware.Logger)
 	a.router.Use(middleware.Recoverer)
+	a.router.Use(secure.Headers(a.cfgSecurity))
+	a.router.Use(cors.Middleware(a.cfgCORS))
+	a.router.Use(auth.ClientCert(a.cfgClientScopes))
+	a.router.Use(compress.Middleware(compress.Config{MinSize: a.cfgCompressMinSize}))
+	if a.cfgJWT != nil {
+		verifier, err := auth.NewJWTVerifier(*a.cfgJWT)
+		if err != nil {
+			return fmt.Errorf("erro ao configurar jwt: %w", err)
+		}
+		a.router.Use(auth.Bearer(verifier, response.Error))
+	}
+	limiter := ratelimit.NewMemory()
+	a.router.Use(ratelimit.Middleware(limiter, "api", a.cfgRateLimit, ratelimit.ByPrincipal, response.Error))
+	reports := ratelimit.Middleware(limiter, "reports", a.cfgReportRateLimit, ratelimit.ByPrincipal, response.Error)
+	idempotent := idempotency.Middleware(idempotency.NewMemory(), a.cfgIdempotencyTTL, response.Error)
+	limit := bodylimit.Middleware(a.cfgMaxBodyBytes)
 	// - endpoints
 	a.router.Route("/customers", func(r chi.Router) {
 		// - GET /customers
-		r.Get("/", hdCustomer.GetAll())
-		r.Get("/conditions", hdCustomer.GetConditionsCustomer())
-		r.Get("/actives", hdCustomer.GetCustomersMoreActives())
+		r.With(cache.Middleware(repository.TagCustomers)).Get("/", hdCustomer.GetAll())
+		r.With(reports, cache.Middleware(repository.TagCustomers, repository.TagInvoices)).Get("/conditions", hdCustomer.GetConditionsCustomer())
+		r.With(reports, cache.Middleware(repository.TagCustomers, repository.TagInvoices)).Get("/actives", hdCustomer.GetCustomersMoreActives())
 		// - POST /customers
-		r.Post("/", hdCustomer.Create())
+		r.With(limit, idempotent).Post("/", hdCustomer.Create())
 	})
 	a.router.Route("/products", func(r chi.Router) {
 		// - GET /products
-		r.Get("/", hdProduct.GetAll())
-		r.Get("/sold", hdProduct.GetProductsMoreSold())
+		r.With(cache.Middleware(repository.TagProducts)).Get("/", hdProduct.GetAll())
+		r.With(reports, cache.Middleware(repository.TagProducts, repository.TagSales)).Get("/sold", hdProduct.GetProductsMoreSold())
 		// - POST /products
-		r.Post("/", hdProduct.Create())
+		r.With(limit, idempotent).Post("/", hdProduct.Create())
 	})
 	a.router.Route("/invoices", func(r chi.Router) {
 		// - GET /invoices
-		r.Get("/", hdInvoice.GetAll())
+		r.With(cache.Middleware(repository.TagInvoices)).Get("/", hdInvoice.GetAll())
 		// - POST /invoices
-		r.Post("/", hdInvoice.Create())
+		r.With(limit, idempotent).Post("/", hdInvoice.Create())
 	})
 	a.router.Route("/sales", func(r chi.Router) {
 		// - GET /sales
-		r.Get("/", hdSale.GetAll())
+		r.With(cache.Middleware(repository.TagSales)).Get("/", hdSale.GetAll())
 		// - POST /sales
-		r.Post("/", hdSale.Create())
+		r.With(limit, idempotent).Post("/", hdSale.Create())
 	})
+	// - operational endpoints: only mounted when a principal can be authenticated
+	if a.cfgJWT != nil || len(a.cfgClientScopes) > 0 {
+		a.router.Group(func(r chi.Router) {
+			r.Use(auth.RequireScope(scopeAuditRead, response.Error))
+			// - GET /cache/stats
+			r.Get("/cache/stats", cache.StatsHandler)
+			// - GET /audit
+			r.Get("/audit", hdAudit.GetAll())
+		})
+	}
 
 	return
 }
 
+// scopeAuditRead is the scope required on /audit and /cache/stats.
+const scopeAuditRead = "audit:read"
+
 // Run runs the application.
 func (a *ApplicationDefault) Run() (err error) {
 	defer a.db.Close()
 
-	err = http.ListenAndServe(a.cfgAddr, a.router)
+	err = tlsserver.ListenAndServe(context.Background(), a.cfgAddr, a.router, a.cfgTLS)
 	return
 }
 
@@ -1,11 +1,13 @@
 package internal
 
+import "context"
+
 // ServiceCustomer is the interface that wraps the basic methods that a customer service should implement.
 type ServiceCustomer interface {
 	// FindAll returns all customers
 	FindAll() (c []Customer, err error)
 	// Save saves a customer
-	Save(c *Customer) (err error)
+	Save(ctx context.Context, c *Customer) (err error)
 	GetConditionsCustomer() (customersConditions []CustomersConditions, err error)
 	GetCustomersMoreActives() (customersActives []CustomersMoreActives, err error)
 }
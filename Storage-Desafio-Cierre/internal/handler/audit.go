@@ -0,0 +1,44 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/bootcamp-go/web/response"
+	"github.com/izabelly/go-web/pkg/audit"
+)
+
+// NewAuditDefault returns a new AuditDefault
+func NewAuditDefault(au *audit.Logger) *AuditDefault {
+	return &AuditDefault{au: au}
+}
+
+// AuditDefault is a struct that returns the audit log handlers
+type AuditDefault struct {
+	// au is the audit logger
+	au *audit.Logger
+}
+
+// GetAll returns the audit entries filtered by ?entity=&id=&from=&to=
+func (h *AuditDefault) GetAll() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		// request
+		filter, err := audit.ParseFilter(r.URL.Query())
+		if err != nil {
+			response.Error(w, http.StatusBadRequest, "invalid audit filter")
+			return
+		}
+
+		// process
+		entries, err := h.au.Query(filter)
+		if err != nil {
+			response.Error(w, http.StatusInternalServerError, "error getting audit log")
+			return
+		}
+
+		// response
+		response.JSON(w, http.StatusOK, map[string]any{
+			"message": "audit log found",
+			"data":    entries,
+		})
+	}
+}
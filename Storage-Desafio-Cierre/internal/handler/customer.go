@@ -1,12 +1,14 @@
 package handler
 
 import (
+	"log"
 	"net/http"
 
 	"app/internal"
 
 	"github.com/bootcamp-go/web/request"
 	"github.com/bootcamp-go/web/response"
+	"github.com/izabelly/go-web/pkg/negotiate"
 )
 
 // NewCustomersDefault returns a new CustomersDefault
@@ -93,7 +95,7 @@ func (h *CustomersDefault) Create() http.HandlerFunc {
 			},
 		}
 		// - save
-		err = h.sv.Save(&c)
+		err = h.sv.Save(r.Context(), &c)
 		if err != nil {
 			response.Error(w, http.StatusInternalServerError, "error saving customer")
 			return
@@ -145,6 +147,13 @@ type CustomerMoreActivesJSON struct {
 
 func (h *CustomersDefault) GetCustomersMoreActives() http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
+		format, err := negotiate.Negotiate(r)
+		negotiate.Vary(w)
+		if err != nil {
+			response.Error(w, http.StatusNotAcceptable, "format not acceptable")
+			return
+		}
+
 		c, err := h.sv.GetCustomersMoreActives()
 		if err != nil {
 			response.Error(w, http.StatusInternalServerError, err.Error())
@@ -159,6 +168,12 @@ func (h *CustomersDefault) GetCustomersMoreActives() http.HandlerFunc {
 				Amount:    v.Amount,
 			}
 		}
+		if format != negotiate.JSON {
+			if err := negotiate.Write(w, format, http.StatusOK, "customers", csJSON); err != nil {
+				log.Println("error writing customers:", err)
+			}
+			return
+		}
 		response.JSON(w, http.StatusOK, map[string]any{
 			"message": "customer",
 			"data":    csJSON,
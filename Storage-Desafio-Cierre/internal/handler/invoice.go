@@ -1,12 +1,14 @@
 package handler
 
 import (
+	"log"
 	"net/http"
 
 	"app/internal"
 
 	"github.com/bootcamp-go/web/request"
 	"github.com/bootcamp-go/web/response"
+	"github.com/izabelly/go-web/pkg/negotiate"
 )
 
 // NewInvoicesDefault returns a new InvoicesDefault
@@ -31,7 +33,13 @@ type InvoiceJSON struct {
 func (h *InvoicesDefault) GetAll() http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		// request
-		// ...
+		// - format: Accept header or ?format=json|csv|xml|ndjson
+		format, err := negotiate.Negotiate(r)
+		negotiate.Vary(w)
+		if err != nil {
+			response.Error(w, http.StatusNotAcceptable, "format not acceptable")
+			return
+		}
 
 		// process
 		i, err := h.sv.FindAll()
@@ -51,6 +59,12 @@ func (h *InvoicesDefault) GetAll() http.HandlerFunc {
 				CustomerId: v.CustomerId,
 			}
 		}
+		if format != negotiate.JSON {
+			if err := negotiate.Write(w, format, http.StatusOK, "invoices", ivJSON); err != nil {
+				log.Println("error writing invoices:", err)
+			}
+			return
+		}
 		response.JSON(w, http.StatusOK, map[string]any{
 			"message": "invoices found",
 			"data":    ivJSON,
@@ -86,7 +100,7 @@ func (h *InvoicesDefault) Create() http.HandlerFunc {
 			},
 		}
 		// - save
-		err = h.sv.Save(&i)
+		err = h.sv.Save(r.Context(), &i)
 		if err != nil {
 			response.Error(w, http.StatusInternalServerError, "error saving invoice")
 			return
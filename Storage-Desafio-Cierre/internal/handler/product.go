@@ -89,7 +89,7 @@ func (h *ProductsDefault) Create() http.HandlerFunc {
 			},
 		}
 		// - save
-		err = h.sv.Save(&p)
+		err = h.sv.Save(r.Context(), &p)
 		if err != nil {
 			response.Error(w, http.StatusInternalServerError, "error creating product")
 			return
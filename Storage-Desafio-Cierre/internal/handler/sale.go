@@ -87,7 +87,7 @@ func (h *SalesDefault) Create() http.HandlerFunc {
 			},
 		}
 		// - save
-		err = h.sv.Save(&s)
+		err = h.sv.Save(r.Context(), &s)
 		if err != nil {
 			response.Error(w, http.StatusInternalServerError, "error saving sale")
 			return
@@ -1,9 +1,11 @@
 package internal
 
+import "context"
+
 // ServiceInvoice is the interface that wraps the basic methods that an invoice service should implement.
 type ServiceInvoice interface {
 	// FindAll returns all invoices
 	FindAll() (i []Invoice, err error)
 	// Save saves an invoice
-	Save(i *Invoice) (err error)
+	Save(ctx context.Context, i *Invoice) (err error)
 }
\ No newline at end of file
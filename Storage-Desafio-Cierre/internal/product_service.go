@@ -1,10 +1,12 @@
 package internal
 
+import "context"
+
 // ServiceProduct is the interface that wraps the basic Product methods.
 type ServiceProduct interface {
 	// FindAll returns all products.
 	FindAll() (p []Product, err error)
 	// Save saves a product.
-	Save(p *Product) (err error)
+	Save(ctx context.Context, p *Product) (err error)
 	GetProductsMoreSold() (products []ProductsSold, err error)
 }
@@ -0,0 +1,102 @@
+package repository
+
+import (
+	"database/sql"
+	"encoding/json"
+	"strings"
+	"time"
+
+	"github.com/izabelly/go-web/pkg/audit"
+)
+
+// NewAuditMySQL creates new mysql store for the audit log.
+func NewAuditMySQL(db *sql.DB) *AuditMySQL {
+	return &AuditMySQL{db}
+}
+
+// AuditMySQL is the MySQL implementation of audit.Store.
+// It only inserts and selects, rows are never updated or deleted.
+type AuditMySQL struct {
+	// db is the database connection.
+	db *sql.DB
+}
+
+// auditTimeLayout is how datetime(6) columns are returned when the DSN has no parseTime.
+const auditTimeLayout = "2006-01-02 15:04:05.999999"
+
+// Append inserts the entry into the audit_log table.
+func (r *AuditMySQL) Append(e audit.Entry) (err error) {
+	diff, err := json.Marshal(e.Diff)
+	if err != nil {
+		return
+	}
+
+	// execute the query
+	_, err = r.db.Exec(
+		"INSERT INTO audit_log (`timestamp`, `actor`, `action`, `entity`, `entity_id`, `request_id`, `diff`) VALUES (?, ?, ?, ?, ?, ?, ?)",
+		e.Timestamp.UTC().Format(auditTimeLayout), e.Actor, e.Action, e.Entity, e.EntityID, e.RequestID, diff,
+	)
+	return
+}
+
+// Query returns the entries that match the filter, oldest first.
+func (r *AuditMySQL) Query(f audit.Filter) (entries []audit.Entry, err error) {
+	// build the where clause
+	var where []string
+	var args []any
+	if f.Entity != "" {
+		where = append(where, "`entity` = ?")
+		args = append(args, f.Entity)
+	}
+	if f.EntityID != "" {
+		where = append(where, "`entity_id` = ?")
+		args = append(args, f.EntityID)
+	}
+	if !f.From.IsZero() {
+		where = append(where, "`timestamp` >= ?")
+		args = append(args, f.From.UTC().Format(auditTimeLayout))
+	}
+	if !f.To.IsZero() {
+		where = append(where, "`timestamp` <= ?")
+		args = append(args, f.To.UTC().Format(auditTimeLayout))
+	}
+
+	query := "SELECT `timestamp`, `actor`, `action`, `entity`, `entity_id`, `request_id`, `diff` FROM audit_log"
+	if len(where) > 0 {
+		query += " WHERE " + strings.Join(where, " AND ")
+	}
+	query += " ORDER BY `id`"
+
+	// execute the query
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	// iterate over the rows
+	entries = []audit.Entry{}
+	for rows.Next() {
+		var e audit.Entry
+		var timestamp string
+		var requestID sql.NullString
+		var diff []byte
+		err := rows.Scan(&timestamp, &e.Actor, &e.Action, &e.Entity, &e.EntityID, &requestID, &diff)
+		if err != nil {
+			return nil, err
+		}
+		e.Timestamp, err = time.Parse(auditTimeLayout, timestamp)
+		if err != nil {
+			return nil, err
+		}
+		e.RequestID = requestID.String
+		if len(diff) > 0 {
+			if err := json.Unmarshal(diff, &e.Diff); err != nil {
+				return nil, err
+			}
+		}
+		entries = append(entries, e)
+	}
+	err = rows.Err()
+	return
+}
@@ -0,0 +1,99 @@
+package repository
+
+import (
+	"app/internal"
+
+	"github.com/izabelly/go-web/pkg/httpcache"
+)
+
+// Cache tags, one per table. A cached response is tagged with every table it reads.
+const (
+	TagCustomers = "customers"
+	TagInvoices  = "invoices"
+	TagProducts  = "products"
+	TagSales     = "sales"
+)
+
+// NewCustomersInvalidating wraps rp so that every saved customer invalidates the cache.
+func NewCustomersInvalidating(rp internal.RepositoryCustomer, cache *httpcache.Cache) *CustomersInvalidating {
+	return &CustomersInvalidating{RepositoryCustomer: rp, cache: cache}
+}
+
+// CustomersInvalidating is a customer repository that invalidates the customers tag on writes.
+type CustomersInvalidating struct {
+	internal.RepositoryCustomer
+	// cache is the response cache.
+	cache *httpcache.Cache
+}
+
+// Save saves the customer and invalidates the responses that read customers.
+func (r *CustomersInvalidating) Save(c *internal.Customer) (err error) {
+	err = r.RepositoryCustomer.Save(c)
+	if err == nil {
+		r.cache.Invalidate(TagCustomers)
+	}
+	return
+}
+
+// NewInvoicesInvalidating wraps rp so that every saved invoice invalidates the cache.
+func NewInvoicesInvalidating(rp internal.RepositoryInvoice, cache *httpcache.Cache) *InvoicesInvalidating {
+	return &InvoicesInvalidating{RepositoryInvoice: rp, cache: cache}
+}
+
+// InvoicesInvalidating is an invoice repository that invalidates the invoices tag on writes.
+type InvoicesInvalidating struct {
+	internal.RepositoryInvoice
+	// cache is the response cache.
+	cache *httpcache.Cache
+}
+
+// Save saves the invoice and invalidates the responses that read invoices.
+func (r *InvoicesInvalidating) Save(i *internal.Invoice) (err error) {
+	err = r.RepositoryInvoice.Save(i)
+	if err == nil {
+		r.cache.Invalidate(TagInvoices)
+	}
+	return
+}
+
+// NewProductsInvalidating wraps rp so that every saved product invalidates the cache.
+func NewProductsInvalidating(rp internal.RepositoryProduct, cache *httpcache.Cache) *ProductsInvalidating {
+	return &ProductsInvalidating{RepositoryProduct: rp, cache: cache}
+}
+
+// ProductsInvalidating is a product repository that invalidates the products tag on writes.
+type ProductsInvalidating struct {
+	internal.RepositoryProduct
+	// cache is the response cache.
+	cache *httpcache.Cache
+}
+
+// Save saves the product and invalidates the responses that read products.
+func (r *ProductsInvalidating) Save(p *internal.Product) (err error) {
+	err = r.RepositoryProduct.Save(p)
+	if err == nil {
+		r.cache.Invalidate(TagProducts)
+	}
+	return
+}
+
+// NewSalesInvalidating wraps rp so that every saved sale invalidates the cache.
+func NewSalesInvalidating(rp internal.RepositorySale, cache *httpcache.Cache) *SalesInvalidating {
+	return &SalesInvalidating{RepositorySale: rp, cache: cache}
+}
+
+// SalesInvalidating is a sale repository that invalidates the sales tag on writes.
+type SalesInvalidating struct {
+	internal.RepositorySale
+	// cache is the response cache.
+	cache *httpcache.Cache
+}
+
+// Save saves the sale and invalidates the responses that read sales.
+func (r *SalesInvalidating) Save(s *internal.Sale) (err error) {
+	err = r.RepositorySale.Save(s)
+	if err == nil {
+		r.cache.Invalidate(TagSales)
+	}
+	return
+}
@@ -1,9 +1,11 @@
 package internal
 
+import "context"
+
 // ServiceSale is the interface that wraps the basic ServiceSale methods.
 type ServiceSale interface {
 	// FindAll returns all sales.
 	FindAll() (s []Sale, err error)
 	// Save saves a sale.
-	Save(s *Sale) (err error)
+	Save(ctx context.Context, s *Sale) (err error)
 }
\ No newline at end of file
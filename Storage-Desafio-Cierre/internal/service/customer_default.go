@@ -2,17 +2,23 @@ package service
 
 import (
 	"app/internal"
+	"context"
+	"log"
+
+	"github.com/izabelly/go-web/pkg/audit"
 )
 
 // NewCustomersDefault creates new default service for customer entity.
-func NewCustomersDefault(rp internal.RepositoryCustomer) *CustomersDefault {
-	return &CustomersDefault{rp}
+func NewCustomersDefault(rp internal.RepositoryCustomer, au *audit.Logger) *CustomersDefault {
+	return &CustomersDefault{rp: rp, au: au}
 }
 
 // CustomersDefault is the default service implementation for customer entity.
 type CustomersDefault struct {
 	// rp is the repository for customer entity.
 	rp internal.RepositoryCustomer
+	// au records the mutations in the audit log, nil disables it.
+	au *audit.Logger
 }
 
 // FindAll returns all customers.
@@ -22,8 +28,16 @@ func (s *CustomersDefault) FindAll() (c []internal.Customer, err error) {
 }
 
 // Save saves the customer.
-func (s *CustomersDefault) Save(c *internal.Customer) (err error) {
+func (s *CustomersDefault) Save(ctx context.Context, c *internal.Customer) (err error) {
 	err = s.rp.Save(c)
+	if err != nil {
+		return
+	}
+
+	// audit
+	if errAudit := s.au.Record(ctx, audit.ActionCreate, "customer", c.Id, nil, c); errAudit != nil {
+		log.Println("audit:", errAudit)
+	}
 	return
 }
 
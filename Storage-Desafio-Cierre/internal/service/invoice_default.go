@@ -1,16 +1,24 @@
 package service
 
-import "app/internal"
+import (
+	"app/internal"
+	"context"
+	"log"
+
+	"github.com/izabelly/go-web/pkg/audit"
+)
 
 // NewInvoicesDefault creates new default service for invoice entity.
-func NewInvoicesDefault(rp internal.RepositoryInvoice) *InvoicesDefault {
-	return &InvoicesDefault{rp}
+func NewInvoicesDefault(rp internal.RepositoryInvoice, au *audit.Logger) *InvoicesDefault {
+	return &InvoicesDefault{rp: rp, au: au}
 }
 
 // InvoicesDefault is the default service implementation for invoice entity.
 type InvoicesDefault struct {
 	// rp is the repository for invoice entity.
 	rp internal.RepositoryInvoice
+	// au records the mutations in the audit log, nil disables it.
+	au *audit.Logger
 }
 
 // FindAll returns all invoices.
@@ -20,7 +28,15 @@ func (s *InvoicesDefault) FindAll() (i []internal.Invoice, err error) {
 }
 
 // Save saves the invoice.
-func (s *InvoicesDefault) Save(i *internal.Invoice) (err error) {
+func (s *InvoicesDefault) Save(ctx context.Context, i *internal.Invoice) (err error) {
 	err = s.rp.Save(i)
+	if err != nil {
+		return
+	}
+
+	// audit
+	if errAudit := s.au.Record(ctx, audit.ActionCreate, "invoice", i.Id, nil, i); errAudit != nil {
+		log.Println("audit:", errAudit)
+	}
 	return
 }
\ No newline at end of file
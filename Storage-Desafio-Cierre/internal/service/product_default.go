@@ -1,16 +1,24 @@
 package service
 
-import "app/internal"
+import (
+	"app/internal"
+	"context"
+	"log"
+
+	"github.com/izabelly/go-web/pkg/audit"
+)
 
 // NewProductsDefault creates new default service for product entity.
-func NewProductsDefault(rp internal.RepositoryProduct) *ProductsDefault {
-	return &ProductsDefault{rp}
+func NewProductsDefault(rp internal.RepositoryProduct, au *audit.Logger) *ProductsDefault {
+	return &ProductsDefault{rp: rp, au: au}
 }
 
 // ProductsDefault is the default service implementation for product entity.
 type ProductsDefault struct {
 	// rp is the repository for product entity.
 	rp internal.RepositoryProduct
+	// au records the mutations in the audit log, nil disables it.
+	au *audit.Logger
 }
 
 // FindAll returns all products.
@@ -20,8 +28,16 @@ func (s *ProductsDefault) FindAll() (p []internal.Product, err error) {
 }
 
 // Save saves the product.
-func (s *ProductsDefault) Save(p *internal.Product) (err error) {
+func (s *ProductsDefault) Save(ctx context.Context, p *internal.Product) (err error) {
 	err = s.rp.Save(p)
+	if err != nil {
+		return
+	}
+
+	// audit
+	if errAudit := s.au.Record(ctx, audit.ActionCreate, "product", p.Id, nil, p); errAudit != nil {
+		log.Println("audit:", errAudit)
+	}
 	return
 }
 
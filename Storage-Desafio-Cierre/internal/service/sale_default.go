@@ -1,16 +1,24 @@
 package service
 
-import "app/internal"
+import (
+	"app/internal"
+	"context"
+	"log"
+
+	"github.com/izabelly/go-web/pkg/audit"
+)
 
 // NewSalesDefault creates new default service for sale entity.
-func NewSalesDefault(rp internal.RepositorySale) *SalesDefault {
-	return &SalesDefault{rp}
+func NewSalesDefault(rp internal.RepositorySale, au *audit.Logger) *SalesDefault {
+	return &SalesDefault{rp: rp, au: au}
 }
 
 // SalesDefault is the default service implementation for sale entity.
 type SalesDefault struct {
 	// rp is the repository for sale entity.
 	rp internal.RepositorySale
+	// au records the mutations in the audit log, nil disables it.
+	au *audit.Logger
 }
 
 // FindAll returns all sales.
@@ -20,7 +28,15 @@ func (sv *SalesDefault) FindAll() (s []internal.Sale, err error) {
 }
 
 // Save saves the sale.
-func (sv *SalesDefault) Save(s *internal.Sale) (err error) {
+func (sv *SalesDefault) Save(ctx context.Context, s *internal.Sale) (err error) {
 	err = sv.rp.Save(s)
+	if err != nil {
+		return
+	}
+
+	// audit
+	if errAudit := sv.au.Record(ctx, audit.ActionCreate, "sale", s.Id, nil, s); errAudit != nil {
+		log.Println("audit:", errAudit)
+	}
 	return
 }
\ No newline at end of file
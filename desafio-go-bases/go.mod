@@ -1,11 +1,16 @@
 module github.com/bootcamp-go/desafio-go-bases
 
-go 1.17
+go 1.23.3
+
+require (
+	github.com/izabelly/go-web v0.0.0
+	github.com/stretchr/testify v1.10.0
+)
 
 require (
 	github.com/davecgh/go-spew v1.1.1 // indirect
-	github.com/gocarina/gocsv v0.0.0-20240520201108-78e41c74b4b1 // indirect
 	github.com/pmezard/go-difflib v1.0.0 // indirect
-	github.com/stretchr/testify v1.10.0 // indirect
 	gopkg.in/yaml.v3 v3.0.1 // indirect
 )
+
+replace github.com/izabelly/go-web => ../go-web
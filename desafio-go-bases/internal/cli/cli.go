@@ -0,0 +1,533 @@
+// Package cli implementa o comando tickets: count, period, percentage e summary
+// sobre um CSV lido de --file ou da entrada padrão.
+package cli
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+	_ "time/tzdata" // fusos de --tz e --dest-tz mesmo sem o zoneinfo do sistema
+
+	"github.com/bootcamp-go/desafio-go-bases/internal/tickets"
+	"github.com/izabelly/go-web/pkg/country"
+	dayperiod "github.com/izabelly/go-web/pkg/period" // period é o subcomando
+	"github.com/izabelly/go-web/pkg/share"
+)
+
+// Formatos de saída
+const (
+	FormatTable = "table"
+	FormatJSON  = "json"
+	FormatCSV   = "csv"
+)
+
+// Códigos de saída
+const (
+	ExitOK    = 0
+	ExitError = 1
+	ExitUsage = 2
+)
+
+const usage = `uso: tickets <comando> [opções]
+
+comandos:
+  count       --destination PAÍS   total de tickets do destino
+  period      [--name PERÍODO]     total de tickets por período (madrugada, manha, tarde, noite ou os de --periods)
+  percentage  --destination PAÍS   razão (0-1) e porcentagem (0-100) dos tickets que vão para o destino
+              [--precision N] [--rounding half-up|half-even|down|up]
+  summary                          totais, receita e períodos de cada destino
+  prices      [--destination PAÍS] estatísticas e histograma dos preços, por destino e no total
+              [--percentiles 50,90,99] [--buckets N]
+
+PAÍS é o nome, um apelido ou o código ISO 3166 do destino; as saídas usam o código alpha-2
+
+opções comuns:
+  --file ARQUIVO      CSV dos tickets; vazio ou "-" lê da entrada padrão
+  --format FORMATO    table (padrão), json ou csv
+  --delimiter C       separador de campos (padrão ",")
+  --encoding E        utf-8 (padrão) ou latin1
+  --lenient           ignora as linhas inválidas e as lista no stderr
+  --workers N         goroutines usadas na leitura (padrão 1)
+  --periods FAIXAS    faixas do dia, ex: "madrugada=00:00-06:30,manha=06:30-12:00,tarde=12:00-18:00,noite=18:00-24:00"
+  --source-tz FUSO    fuso em que os horários do arquivo foram registrados (padrão UTC)
+  --tz FUSO           fuso em que os períodos são contados (padrão o de --source-tz)
+  --dest-tz LISTA     fuso de cada destino, ex: "Brazil=America/Sao_Paulo,Japan=Asia/Tokyo"
+  --date AAAA-MM-DD   dia dos horários, usado nas regras de horário de verão (padrão hoje)
+`
+
+// errUsage indica um erro nos argumentos; a mensagem já foi escrita
+var errUsage = errors.New("uso inválido")
+
+// result é a saída de um comando: a tabela (table/csv) e o valor para o json
+type result struct {
+	header []string
+	rows   [][]string
+	json   interface{}
+}
+
+// env são as entradas e saídas do comando
+type env struct {
+	stdin  io.Reader
+	stdout io.Writer
+	stderr io.Writer
+}
+
+// options são as opções comuns a todos os comandos
+type options struct {
+	file      string
+	format    string
+	delimiter string
+	encoding  string
+	lenient   bool
+	workers   int
+	periods   string
+	sourceTZ  string
+	tz        string
+	destTZ    string
+	date      string
+	// prices guarda os preços na agregação (comando prices)
+	prices bool
+	// fs é o FlagSet do comando, usado para escrever o uso quando uma opção é inválida
+	fs *flag.FlagSet
+}
+
+// Run executa o comando de args e retorna o código de saída
+func Run(args []string, stdin io.Reader, stdout, stderr io.Writer) int {
+	if len(args) == 0 || args[0] == "-h" || args[0] == "--help" || args[0] == "help" {
+		fmt.Fprint(stderr, usage)
+		if len(args) == 0 {
+			return ExitUsage
+		}
+		return ExitOK
+	}
+
+	commands := map[string]func([]string, *env) error{
+		"count":      count,
+		"period":     period,
+		"percentage": percentage,
+		"summary":    summary,
+		"prices":     prices,
+	}
+	command, ok := commands[args[0]]
+	if !ok {
+		fmt.Fprintf(stderr, "comando desconhecido %q\n\n%s", args[0], usage)
+		return ExitUsage
+	}
+
+	err := command(args[1:], &env{stdin: stdin, stdout: stdout, stderr: stderr})
+	if errors.Is(err, errUsage) {
+		return ExitUsage
+	}
+	if err != nil {
+		fmt.Fprintln(stderr, "erro:", err)
+		return ExitError
+	}
+	return ExitOK
+}
+
+func count(args []string, e *env) error {
+	fs, opts := newFlagSet("count", e.stderr)
+	destination := fs.String("destination", "", "destino dos tickets")
+	if err := parse(fs, args); err != nil {
+		return err
+	}
+	if *destination == "" {
+		return usageError(fs, "--destination é obrigatório")
+	}
+
+	stats, err := opts.aggregate(e)
+	if err != nil {
+		return err
+	}
+
+	code, total := country.Normalize(*destination), stats.Count(*destination)
+	return opts.render(e.stdout, result{
+		header: []string{"destination", "count"},
+		rows:   [][]string{{code, strconv.Itoa(total)}},
+		json:   map[string]interface{}{"destination": code, "count": total},
+	})
+}
+
+func period(args []string, e *env) error {
+	fs, opts := newFlagSet("period", e.stderr)
+	name := fs.String("name", "", "período do dia; vazio lista todos")
+	if err := parse(fs, args); err != nil {
+		return err
+	}
+
+	config, err := opts.config()
+	if err != nil {
+		return err
+	}
+	names := config.Period.Names()
+	if *name != "" {
+		if !contains(names, *name) {
+			return usageError(fs, fmt.Sprintf("período %q inválido, use %s", *name, strings.Join(names, ", ")))
+		}
+		names = []string{*name}
+	}
+
+	stats, err := opts.aggregate(e)
+	if err != nil {
+		return err
+	}
+
+	res := result{header: []string{"period", "count"}}
+	counts := make([]map[string]interface{}, 0, len(names))
+	for _, n := range names {
+		res.rows = append(res.rows, []string{n, strconv.Itoa(stats.Periods[n])})
+		counts = append(counts, map[string]interface{}{"period": n, "count": stats.Periods[n]})
+	}
+	res.json = counts
+	if len(counts) == 1 {
+		res.json = counts[0]
+	}
+	return opts.render(e.stdout, res)
+}
+
+func percentage(args []string, e *env) error {
+	fs, opts := newFlagSet("percentage", e.stderr)
+	destination := fs.String("destination", "", "destino dos tickets")
+	precision := fs.Int("precision", share.DefaultOptions.Precision, "casas decimais da porcentagem (a razão tem duas a mais); -1 não arredonda")
+	rounding := fs.String("rounding", share.DefaultOptions.Rounding, "arredondamento: half-up, half-even, down ou up")
+	if err := parse(fs, args); err != nil {
+		return err
+	}
+	if *destination == "" {
+		return usageError(fs, "--destination é obrigatório")
+	}
+	shareOpts := share.Options{Precision: *precision, Rounding: *rounding}
+	if err := shareOpts.Validate(); err != nil {
+		return usageError(fs, err.Error())
+	}
+
+	stats, err := opts.aggregate(e)
+	if err != nil {
+		return err
+	}
+
+	participation, err := stats.Share(*destination, shareOpts)
+	if err != nil {
+		return err
+	}
+	ratioPrecision := shareOpts.Precision
+	if ratioPrecision != share.FullPrecision {
+		ratioPrecision += 2
+	}
+	return opts.render(e.stdout, result{
+		header: []string{"destination", "count", "total", "ratio", "percentage"},
+		rows: [][]string{{
+			participation.Destination,
+			strconv.Itoa(participation.Count),
+			strconv.Itoa(participation.Total),
+			strconv.FormatFloat(participation.Ratio, 'f', ratioPrecision, 64),
+			strconv.FormatFloat(participation.Percentage, 'f', shareOpts.Precision, 64),
+		}},
+		json: participation,
+	})
+}
+
+// destinationSummary é uma linha do summary; Destination é o código do país
+type destinationSummary struct {
+	Destination string         `json:"destination"`
+	Name        string         `json:"name"`
+	Count       int            `json:"count"`
+	Percentage  float64        `json:"percentage"`
+	Revenue     float64        `json:"revenue"`
+	Periods     map[string]int `json:"periods"`
+}
+
+func summary(args []string, e *env) error {
+	fs, opts := newFlagSet("summary", e.stderr)
+	if err := parse(fs, args); err != nil {
+		return err
+	}
+
+	stats, err := opts.aggregate(e)
+	if err != nil {
+		return err
+	}
+
+	names := make([]string, 0, len(stats.Destinations))
+	for name := range stats.Destinations {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	periods := stats.PeriodNames()
+	res := result{header: append([]string{"destination", "count", "percentage", "revenue"}, periods...)}
+	destinations := make([]destinationSummary, 0, len(names))
+	for _, name := range names {
+		dest := stats.Destinations[name]
+		row := []string{name, strconv.Itoa(dest.Count), formatFloat(stats.Percentage(name)), formatFloat(dest.Revenue)}
+		for _, p := range periods {
+			row = append(row, strconv.Itoa(dest.Periods[p]))
+		}
+		res.rows = append(res.rows, row)
+		destinations = append(destinations, destinationSummary{
+			Destination: name,
+			Name:        country.Name(name),
+			Count:       dest.Count,
+			Percentage:  stats.Percentage(name),
+			Revenue:     dest.Revenue,
+			Periods:     dest.Periods,
+		})
+	}
+	res.json = map[string]interface{}{
+		"total":        stats.Total,
+		"revenue":      stats.Revenue,
+		"periods":      stats.Periods,
+		"destinations": destinations,
+	}
+	return opts.render(e.stdout, res)
+}
+
+func prices(args []string, e *env) error {
+	fs, opts := newFlagSet("prices", e.stderr)
+	destination := fs.String("destination", "", "destino dos tickets; vazio lista todos e o total")
+	percentiles := fs.String("percentiles", "", "percentis calculados, ex: 50,90,99")
+	buckets := fs.Int("buckets", tickets.DefaultBuckets, "faixas do histograma (só no json)")
+	if err := parse(fs, args); err != nil {
+		return err
+	}
+
+	priceOpts := tickets.PriceOptions{Buckets: *buckets}
+	for _, item := range strings.Split(*percentiles, ",") {
+		if item = strings.TrimSpace(item); item == "" {
+			continue
+		}
+		p, err := strconv.ParseFloat(item, 64)
+		if err != nil {
+			return usageError(fs, fmt.Sprintf("percentil inválido %q", item))
+		}
+		priceOpts.Percentiles = append(priceOpts.Percentiles, p)
+	}
+	if len(priceOpts.Percentiles) == 0 {
+		priceOpts.Percentiles = tickets.DefaultPercentiles
+	}
+	if _, err := tickets.NewPriceStats(nil, priceOpts); err != nil {
+		return usageError(fs, err.Error())
+	}
+
+	opts.prices = true
+	stats, err := opts.aggregate(e)
+	if err != nil {
+		return err
+	}
+
+	header := []string{"destination", "count", "min", "max", "mean", "median", "stddev"}
+	for _, p := range priceOpts.Percentiles {
+		header = append(header, "p"+strconv.FormatFloat(p, 'f', -1, 64))
+	}
+	res := result{header: header}
+	row := func(name string, price tickets.PriceStats) {
+		values := []string{name, strconv.Itoa(price.Count), formatFloat(price.Min), formatFloat(price.Max),
+			formatFloat(price.Mean), formatFloat(price.Median), formatFloat(price.StdDev)}
+		for _, key := range header[7:] {
+			values = append(values, formatFloat(price.Percentiles[key]))
+		}
+		res.rows = append(res.rows, values)
+	}
+
+	if *destination != "" {
+		price, err := stats.PriceStats(*destination, priceOpts)
+		if err != nil {
+			return err
+		}
+		code := country.Normalize(*destination)
+		row(code, price)
+		res.json = map[string]interface{}{"destination": code, "prices": price}
+		return opts.render(e.stdout, res)
+	}
+
+	byDestination, err := stats.PriceStatsByDestination(priceOpts)
+	if err != nil {
+		return err
+	}
+	global, err := stats.PriceStats("", priceOpts)
+	if err != nil {
+		return err
+	}
+	names := make([]string, 0, len(byDestination))
+	for name := range byDestination {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		row(name, byDestination[name])
+	}
+	row("(total)", global)
+	res.json = map[string]interface{}{"global": global, "destinations": byDestination}
+	return opts.render(e.stdout, res)
+}
+
+func newFlagSet(name string, stderr io.Writer) (*flag.FlagSet, *options) {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	opts := &options{fs: fs}
+	fs.SetOutput(stderr)
+	fs.StringVar(&opts.file, "file", "", `CSV dos tickets; vazio ou "-" lê da entrada padrão`)
+	fs.StringVar(&opts.format, "format", FormatTable, "formato da saída: table, json ou csv")
+	fs.StringVar(&opts.delimiter, "delimiter", ",", "separador de campos")
+	fs.StringVar(&opts.encoding, "encoding", tickets.EncodingUTF8, "encoding do arquivo: utf-8 ou latin1")
+	fs.BoolVar(&opts.lenient, "lenient", false, "ignora as linhas inválidas")
+	fs.IntVar(&opts.workers, "workers", 1, "goroutines usadas na leitura")
+	fs.StringVar(&opts.periods, "periods", "", "faixas do dia no formato nome=HH:MM-HH:MM,...")
+	fs.StringVar(&opts.sourceTZ, "source-tz", "UTC", "fuso dos horários do arquivo")
+	fs.StringVar(&opts.tz, "tz", "", "fuso em que os períodos são contados")
+	fs.StringVar(&opts.destTZ, "dest-tz", "", "fuso de cada destino no formato destino=fuso,...")
+	fs.StringVar(&opts.date, "date", "", "dia dos horários (AAAA-MM-DD)")
+	return fs, opts
+}
+
+func parse(fs *flag.FlagSet, args []string) error {
+	if err := fs.Parse(args); err != nil {
+		// o flag já escreveu o erro e o uso
+		return errUsage
+	}
+	if fs.NArg() > 0 {
+		return usageError(fs, fmt.Sprintf("argumento inesperado %q", fs.Arg(0)))
+	}
+	return nil
+}
+
+func usageError(fs *flag.FlagSet, msg string) error {
+	fmt.Fprintf(fs.Output(), "%s: %s\n", fs.Name(), msg)
+	fs.Usage()
+	return errUsage
+}
+
+// config converte as opções comuns nas opções da agregação; opções inválidas são erros de uso
+func (o *options) config() (tickets.AggregateOptions, error) {
+	var config tickets.AggregateOptions
+	if o.format != FormatTable && o.format != FormatJSON && o.format != FormatCSV {
+		return config, usageError(o.fs, fmt.Sprintf("formato %q inválido, use table, json ou csv", o.format))
+	}
+	delimiter := []rune(o.delimiter)
+	if len(delimiter) != 1 {
+		return config, usageError(o.fs, fmt.Sprintf("delimitador %q inválido", o.delimiter))
+	}
+
+	source, err := time.LoadLocation(o.sourceTZ)
+	if err != nil {
+		return config, usageError(o.fs, "--source-tz: "+err.Error())
+	}
+	config.ReadOptions = tickets.ReadOptions{
+		Delimiter: delimiter[0],
+		Encoding:  o.encoding,
+		Lenient:   o.lenient,
+		Location:  source,
+	}
+	if o.date != "" {
+		if config.Date, err = time.ParseInLocation("2006-01-02", o.date, source); err != nil {
+			return config, usageError(o.fs, fmt.Sprintf("--date %q inválida, use AAAA-MM-DD", o.date))
+		}
+	}
+
+	if o.periods != "" {
+		if config.Period.Periods, err = dayperiod.Parse(o.periods); err != nil {
+			return config, usageError(o.fs, "--periods: "+err.Error())
+		}
+	}
+	if o.tz != "" {
+		if config.Period.Location, err = time.LoadLocation(o.tz); err != nil {
+			return config, usageError(o.fs, "--tz: "+err.Error())
+		}
+	}
+	if o.destTZ != "" {
+		if config.Period.Destinations, err = dayperiod.ParseLocations(o.destTZ); err != nil {
+			return config, usageError(o.fs, "--dest-tz: "+err.Error())
+		}
+	}
+
+	config.Workers = o.workers
+	config.Prices = o.prices
+	return config, nil
+}
+
+// aggregate lê o arquivo (ou stdin) uma vez e lista no stderr as linhas ignoradas
+func (o *options) aggregate(e *env) (*tickets.Stats, error) {
+	config, err := o.config()
+	if err != nil {
+		return nil, err
+	}
+
+	in := e.stdin
+	if o.file == "" && isTerminal(in) {
+		return nil, errors.New("informe --file ou envie o CSV pela entrada padrão")
+	}
+	if o.file != "" && o.file != "-" {
+		file, err := os.Open(o.file)
+		if err != nil {
+			return nil, err
+		}
+		defer file.Close()
+		in = file
+	}
+
+	stats, err := tickets.Aggregate(in, config)
+	if err != nil {
+		return nil, err
+	}
+	for _, lineErr := range stats.Report.Errors {
+		fmt.Fprintln(e.stderr, "ignorada:", lineErr.Error())
+	}
+	return stats, nil
+}
+
+// render escreve o resultado no formato escolhido
+func (o *options) render(w io.Writer, res result) error {
+	switch o.format {
+	case FormatJSON:
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(res.json)
+	case FormatCSV:
+		cw := csv.NewWriter(w)
+		if err := cw.Write(res.header); err != nil {
+			return err
+		}
+		if err := cw.WriteAll(res.rows); err != nil {
+			return err
+		}
+		return cw.Error()
+	default:
+		tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(tw, strings.ToUpper(strings.Join(res.header, "\t")))
+		for _, row := range res.rows {
+			fmt.Fprintln(tw, strings.Join(row, "\t"))
+		}
+		return tw.Flush()
+	}
+}
+
+// isTerminal indica se a entrada é um terminal, onde esperar o CSV travaria o comando
+func isTerminal(in io.Reader) bool {
+	file, ok := in.(*os.File)
+	if !ok {
+		return false
+	}
+	info, err := file.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
+
+func formatFloat(value float64) string {
+	return strconv.FormatFloat(value, 'f', 2, 64)
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,179 @@
+package cli_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/bootcamp-go/desafio-go-bases/internal/cli"
+	"github.com/izabelly/go-web/pkg/share/sharetest"
+	"github.com/stretchr/testify/require"
+)
+
+const data = "1,Ann,a@x.com,Brazil,10:00,100\n" +
+	"2,Bob,b@x.com,Chile,21:00,200\n" +
+	"3,Cy,c@x.com,Brazil,3:00,50\n" +
+	"4,Dee,d@x.com,Peru,15:30,50\n"
+
+func run(stdin string, args ...string) (code int, stdout, stderr string) {
+	var out, errOut bytes.Buffer
+	code = cli.Run(args, strings.NewReader(stdin), &out, &errOut)
+	return code, out.String(), errOut.String()
+}
+
+func TestRun(t *testing.T) {
+	t.Run("count pela entrada padrão em csv", func(t *testing.T) {
+		code, stdout, _ := run(data, "count", "--destination", " brasil", "--format", "csv")
+
+		require.Equal(t, cli.ExitOK, code)
+		require.Equal(t, "destination,count\nBR,2\n", stdout)
+	})
+
+	t.Run("period em json", func(t *testing.T) {
+		code, stdout, _ := run(data, "period", "--name", "tarde", "--format", "json")
+
+		require.Equal(t, cli.ExitOK, code)
+		require.JSONEq(t, `{"period":"tarde","count":1}`, stdout)
+	})
+
+	t.Run("percentage em tabela", func(t *testing.T) {
+		code, stdout, _ := run(data, "percentage", "--destination", "Brazil")
+
+		require.Equal(t, cli.ExitOK, code)
+		require.Contains(t, stdout, "DESTINATION")
+		require.Contains(t, stdout, "50.00")
+	})
+
+	t.Run("percentage com precisão e arredondamento", func(t *testing.T) {
+		code, stdout, _ := run(data, "percentage", "--destination", "PE", "--precision", "0", "--rounding", "down", "--format", "csv")
+
+		require.Equal(t, cli.ExitOK, code)
+		require.Equal(t, "destination,count,total,ratio,percentage\nPE,1,4,0.25,25\n", stdout)
+
+		code, stdout, _ = run(data, "percentage", "--destination", "Chile", "--precision", "-1", "--format", "json")
+		require.Equal(t, cli.ExitOK, code)
+		require.JSONEq(t, `{"destination":"CL","count":1,"total":4,"ratio":0.25,"percentage":25}`, stdout)
+
+		code, _, _ = run(data, "percentage", "--destination", "Chile", "--rounding", "bancário")
+		require.Equal(t, cli.ExitUsage, code)
+	})
+
+	t.Run("summary lendo de --file", func(t *testing.T) {
+		file := filepath.Join(t.TempDir(), "tickets.csv")
+		require.NoError(t, os.WriteFile(file, []byte(data), 0644))
+
+		code, stdout, _ := run("", "summary", "--file", file, "--format", "csv")
+
+		require.Equal(t, cli.ExitOK, code)
+		require.Equal(t, "destination,count,percentage,revenue,madrugada,manha,tarde,noite\n"+
+			"BR,2,50.00,150.00,1,1,0,0\n"+
+			"CL,1,25.00,200.00,0,0,0,1\n"+
+			"PE,1,25.00,50.00,0,0,1,0\n", stdout)
+	})
+
+	t.Run("períodos e fusos configurados", func(t *testing.T) {
+		code, stdout, _ := run(data, "period", "--format", "csv",
+			"--periods", "dia=06:30-18:30,noite=18:30-06:30", "--tz", "America/Sao_Paulo", "--date", "2024-01-15")
+
+		require.Equal(t, cli.ExitOK, code)
+		// em São Paulo: 07:00, 18:00, 00:00 e 12:30
+		require.Equal(t, "period,count\ndia,3\nnoite,1\n", stdout)
+
+		code, _, stderr := run(data, "period", "--name", "tarde", "--periods", "dia=00:00-24:00")
+		require.Equal(t, cli.ExitUsage, code)
+		require.Contains(t, stderr, "use dia")
+	})
+
+	t.Run("prices por destino e no total", func(t *testing.T) {
+		code, stdout, _ := run(data, "prices", "--percentiles", "50", "--format", "csv")
+
+		require.Equal(t, cli.ExitOK, code)
+		require.Equal(t, "destination,count,min,max,mean,median,stddev,p50\n"+
+			"BR,2,50.00,100.00,75.00,75.00,25.00,75.00\n"+
+			"CL,1,200.00,200.00,200.00,200.00,0.00,200.00\n"+
+			"PE,1,50.00,50.00,50.00,50.00,0.00,50.00\n"+
+			"(total),4,50.00,200.00,100.00,75.00,61.24,75.00\n", stdout)
+
+		code, stdout, _ = run(data, "prices", "--destination", "Brazil", "--buckets", "2", "--format", "json")
+		require.Equal(t, cli.ExitOK, code)
+		require.Contains(t, stdout, `"histogram"`)
+
+		code, _, _ = run(data, "prices", "--percentiles", "150")
+		require.Equal(t, cli.ExitUsage, code)
+	})
+
+	t.Run("lenient lista as linhas ignoradas no stderr", func(t *testing.T) {
+		code, stdout, stderr := run(data+"5,Eve,e@x.com,Peru,abc,10\n", "count", "--destination", "Peru", "--lenient", "--format", "csv")
+
+		require.Equal(t, cli.ExitOK, code)
+		require.Equal(t, "destination,count\nPE,1\n", stdout)
+		require.Contains(t, stderr, "linha 5")
+	})
+
+	t.Run("linha inválida no modo estrito", func(t *testing.T) {
+		code, stdout, stderr := run("1,Ann,a@x.com,Brazil,abc,100\n", "count", "--destination", "Brazil")
+
+		require.Equal(t, cli.ExitError, code)
+		require.Empty(t, stdout)
+		require.Contains(t, stderr, "linha 1")
+	})
+
+	t.Run("erros de uso", func(t *testing.T) {
+		code, _, _ := run(data)
+		require.Equal(t, cli.ExitUsage, code)
+
+		code, _, stderr := run(data, "total")
+		require.Equal(t, cli.ExitUsage, code)
+		require.Contains(t, stderr, "comando desconhecido")
+
+		code, _, stderr = run(data, "count")
+		require.Equal(t, cli.ExitUsage, code)
+		require.Contains(t, stderr, "--destination é obrigatório")
+
+		code, _, _ = run(data, "period", "--name", "almoco")
+		require.Equal(t, cli.ExitUsage, code)
+
+		code, _, stderr = run(data, "summary", "--format", "xml")
+		require.Equal(t, cli.ExitUsage, code)
+		require.Contains(t, stderr, "formato")
+		require.Contains(t, stderr, "-format")
+	})
+
+	t.Run("opções inválidas são erros de uso", func(t *testing.T) {
+		cases := [][]string{
+			{"--delimiter", ";;"},
+			{"--source-tz", "Mars/Olympus"},
+			{"--tz", "Mars/Olympus"},
+			{"--dest-tz", "Brazil=Mars/Olympus"},
+			{"--date", "15/01/2024"},
+			{"--periods", "dia=00:00-12:00"},
+		}
+		for _, args := range cases {
+			code, stdout, stderr := run(data, append([]string{"period"}, args...)...)
+			require.Equal(t, cli.ExitUsage, code, args)
+			require.Empty(t, stdout, args)
+			require.Contains(t, stderr, "period: ", args)
+			require.Contains(t, stderr, "-dest-tz", args)
+		}
+	})
+}
+
+// TestRun_ShareCases confere o percentage com os mesmos casos do teste do serviço HTTP
+// (desafio-go-web), então o CLI e o HTTP dão o mesmo valor para o mesmo arquivo
+func TestRun_ShareCases(t *testing.T) {
+	for _, c := range sharetest.Cases {
+		t.Run(c.Destination, func(t *testing.T) {
+			code, stdout, stderr := run(sharetest.TicketsCSV, "percentage", "--destination", c.Destination,
+				"--precision", strconv.Itoa(c.Options.Precision), "--rounding", c.Options.Rounding, "--format", "json")
+
+			require.Equal(t, cli.ExitOK, code, stderr)
+			expected, err := json.Marshal(c.Expected)
+			require.NoError(t, err)
+			require.JSONEq(t, string(expected), stdout)
+		})
+	}
+}
@@ -0,0 +1,14 @@
+package tickets_test
+
+import (
+	"os"
+	"testing"
+
+	"github.com/bootcamp-go/desafio-go-bases/internal/tickets"
+)
+
+// os testes rodam em internal/tickets, o arquivo fica na raiz do módulo
+func TestMain(m *testing.M) {
+	tickets.FileName = "../../tickets.csv"
+	os.Exit(m.Run())
+}
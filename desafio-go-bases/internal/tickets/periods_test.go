@@ -0,0 +1,61 @@
+package tickets_test
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/bootcamp-go/desafio-go-bases/internal/tickets"
+	"github.com/izabelly/go-web/pkg/period"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPeriods(t *testing.T) {
+	at := func(clock string) time.Time {
+		result, err := tickets.ParseHHMMIn(clock, time.Date(2024, 1, 15, 0, 0, 0, 0, time.UTC))
+		require.NoError(t, err)
+		return result
+	}
+
+	t.Run("faixas padrão mantêm os limites originais", func(t *testing.T) {
+		require.Equal(t, tickets.Madrugada, tickets.PeriodOf(at("06:59")))
+		require.Equal(t, tickets.Manha, tickets.PeriodOf(at("07:00")))
+		require.Equal(t, tickets.Manha, tickets.PeriodOf(at("12:59")))
+		require.Equal(t, tickets.Tarde, tickets.PeriodOf(at("19:59")))
+		require.Equal(t, tickets.Noite, tickets.PeriodOf(at("20:00")))
+	})
+
+	t.Run("períodos contados no fuso do destino", func(t *testing.T) {
+		saoPaulo := time.FixedZone("BRT", -3*60*60)
+		tokyo := time.FixedZone("JST", 9*60*60)
+		data := "1,Ann,a@x.com,Brazil,02:00,100\n" +
+			"2,Bob,b@x.com,Japan,02:00,100\n" +
+			"3,Cy,c@x.com,Peru,02:00,100\n"
+
+		stats, err := tickets.Aggregate(strings.NewReader(data), tickets.AggregateOptions{
+			ReadOptions: tickets.ReadOptions{Date: time.Date(2024, 1, 15, 0, 0, 0, 0, time.UTC)},
+			Period: period.Options{
+				Location:     time.FixedZone("PET", -5*60*60),
+				Destinations: map[string]*time.Location{"BR": saoPaulo, "Japan": tokyo},
+			},
+		})
+
+		require.NoError(t, err)
+		// 02:00 UTC: 23:00 em São Paulo, 11:00 em Tóquio e 21:00 em Lima
+		require.Equal(t, 1, stats.Destinations["BR"].Periods[tickets.Noite])
+		require.Equal(t, 1, stats.Destinations["JP"].Periods[tickets.Manha])
+		require.Equal(t, 1, stats.Destinations["PE"].Periods[tickets.Noite])
+		require.Equal(t, map[string]int{tickets.Madrugada: 0, tickets.Manha: 1, tickets.Tarde: 0, tickets.Noite: 2}, stats.Periods)
+	})
+
+	t.Run("horários lidos no fuso e no dia configurados", func(t *testing.T) {
+		loc := time.FixedZone("BRT", -3*60*60)
+		result, _, err := tickets.ReadAll(strings.NewReader("1,Ann,a@x.com,Brazil,22:15,100\n"), tickets.ReadOptions{
+			Location: loc,
+			Date:     time.Date(2024, 3, 10, 0, 0, 0, 0, loc),
+		})
+
+		require.NoError(t, err)
+		require.Equal(t, time.Date(2024, 3, 11, 1, 15, 0, 0, time.UTC), result[0].Time.UTC())
+	})
+}
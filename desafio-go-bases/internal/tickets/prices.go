@@ -0,0 +1,168 @@
+package tickets
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+
+	"github.com/izabelly/go-web/pkg/country"
+)
+
+// ErrPricesNotCollected indica que a agregação foi feita sem AggregateOptions.Prices
+var ErrPricesNotCollected = errors.New("os preços não foram guardados na agregação (use AggregateOptions.Prices)")
+
+// DefaultPercentiles são os percentis calculados quando PriceOptions.Percentiles está vazio
+var DefaultPercentiles = []float64{25, 50, 75, 90, 95, 99}
+
+// DefaultBuckets é o número de faixas do histograma quando PriceOptions.Buckets é zero
+const DefaultBuckets = 10
+
+// PriceOptions configura o cálculo das estatísticas de preço
+type PriceOptions struct {
+	// Percentiles são os percentis (0-100) calculados; vazio usa DefaultPercentiles
+	Percentiles []float64
+	// Buckets é o número de faixas de mesmo tamanho do histograma; zero usa DefaultBuckets
+	Buckets int
+}
+
+// Bucket é uma faixa do histograma, de From até To (a última inclui To)
+type Bucket struct {
+	From  float64 `json:"from"`
+	To    float64 `json:"to"`
+	Count int     `json:"count"`
+}
+
+// PriceStats é a distribuição dos preços de um conjunto de tickets
+type PriceStats struct {
+	Count  int     `json:"count"`
+	Min    float64 `json:"min"`
+	Max    float64 `json:"max"`
+	Mean   float64 `json:"mean"`
+	Median float64 `json:"median"`
+	// StdDev é o desvio padrão populacional
+	StdDev float64 `json:"stddev"`
+	// Percentiles usa as chaves "p90", "p99.9"...
+	Percentiles map[string]float64 `json:"percentiles"`
+	Histogram   []Bucket           `json:"histogram"`
+}
+
+// NewPriceStats calcula as estatísticas dos preços; prices não é alterado
+func NewPriceStats(prices []float64, opts PriceOptions) (PriceStats, error) {
+	percentiles := opts.Percentiles
+	if len(percentiles) == 0 {
+		percentiles = DefaultPercentiles
+	}
+	buckets := opts.Buckets
+	if buckets == 0 {
+		buckets = DefaultBuckets
+	}
+	if buckets < 0 {
+		return PriceStats{}, fmt.Errorf("número de faixas inválido %d", buckets)
+	}
+	for _, p := range percentiles {
+		if p < 0 || p > 100 || math.IsNaN(p) {
+			return PriceStats{}, fmt.Errorf("percentil inválido %v (use 0-100)", p)
+		}
+	}
+
+	stats := PriceStats{Count: len(prices), Percentiles: map[string]float64{}, Histogram: []Bucket{}}
+	if len(prices) == 0 {
+		return stats, nil
+	}
+
+	sorted := append([]float64(nil), prices...)
+	sort.Float64s(sorted)
+
+	sum := 0.0
+	for _, price := range sorted {
+		sum += price
+	}
+	stats.Min = sorted[0]
+	stats.Max = sorted[len(sorted)-1]
+	stats.Mean = sum / float64(len(sorted))
+	stats.Median = percentile(sorted, 50)
+
+	squares := 0.0
+	for _, price := range sorted {
+		squares += (price - stats.Mean) * (price - stats.Mean)
+	}
+	stats.StdDev = math.Sqrt(squares / float64(len(sorted)))
+
+	for _, p := range percentiles {
+		stats.Percentiles["p"+strconv.FormatFloat(p, 'f', -1, 64)] = percentile(sorted, p)
+	}
+	stats.Histogram = histogram(sorted, buckets)
+	return stats, nil
+}
+
+// PriceStats retorna as estatísticas de preço do destino (nome, apelido ou código),
+// ou de todos os tickets quando destination é vazio
+func (s *Stats) PriceStats(destination string, opts PriceOptions) (PriceStats, error) {
+	if !s.keepPrices {
+		return PriceStats{}, ErrPricesNotCollected
+	}
+	if destination == "" {
+		var prices []float64
+		for _, dest := range s.Destinations {
+			prices = append(prices, dest.prices...)
+		}
+		return NewPriceStats(prices, opts)
+	}
+
+	var prices []float64
+	if dest, ok := s.Destinations[country.Normalize(destination)]; ok {
+		prices = dest.prices
+	}
+	return NewPriceStats(prices, opts)
+}
+
+// PriceStatsByDestination retorna as estatísticas de preço de cada destino pelo código
+func (s *Stats) PriceStatsByDestination(opts PriceOptions) (map[string]PriceStats, error) {
+	if !s.keepPrices {
+		return nil, ErrPricesNotCollected
+	}
+	result := make(map[string]PriceStats, len(s.Destinations))
+	for name, dest := range s.Destinations {
+		stats, err := NewPriceStats(dest.prices, opts)
+		if err != nil {
+			return nil, err
+		}
+		result[name] = stats
+	}
+	return result, nil
+}
+
+// percentile interpola linearmente entre os dois valores mais próximos (sorted ordenado)
+func percentile(sorted []float64, p float64) float64 {
+	rank := p / 100 * float64(len(sorted)-1)
+	lower := int(math.Floor(rank))
+	upper := int(math.Ceil(rank))
+	return sorted[lower] + (sorted[upper]-sorted[lower])*(rank-float64(lower))
+}
+
+// histogram divide [min, max] em faixas de mesmo tamanho (sorted ordenado)
+func histogram(sorted []float64, buckets int) []Bucket {
+	low, high := sorted[0], sorted[len(sorted)-1]
+	if low == high {
+		return []Bucket{{From: low, To: high, Count: len(sorted)}}
+	}
+
+	width := (high - low) / float64(buckets)
+	result := make([]Bucket, buckets)
+	for i := range result {
+		result[i].From = low + width*float64(i)
+		result[i].To = low + width*float64(i+1)
+	}
+	result[buckets-1].To = high
+
+	for _, price := range sorted {
+		i := int((price - low) / width)
+		if i >= buckets {
+			i = buckets - 1
+		}
+		result[i].Count++
+	}
+	return result
+}
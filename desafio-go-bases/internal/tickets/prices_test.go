@@ -0,0 +1,83 @@
+package tickets_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/bootcamp-go/desafio-go-bases/internal/tickets"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewPriceStats(t *testing.T) {
+	t.Run("estatísticas e histograma", func(t *testing.T) {
+		prices := []float64{40, 10, 30, 20, 100}
+
+		result, err := tickets.NewPriceStats(prices, tickets.PriceOptions{Percentiles: []float64{25, 90}, Buckets: 3})
+
+		require.NoError(t, err)
+		require.Equal(t, []float64{40, 10, 30, 20, 100}, prices)
+		require.Equal(t, 5, result.Count)
+		require.Equal(t, 10.0, result.Min)
+		require.Equal(t, 100.0, result.Max)
+		require.Equal(t, 40.0, result.Mean)
+		require.Equal(t, 30.0, result.Median)
+		require.InDelta(t, 31.6228, result.StdDev, 1e-4)
+		require.Equal(t, map[string]float64{"p25": 20, "p90": 76}, result.Percentiles)
+		require.Equal(t, []tickets.Bucket{
+			{From: 10, To: 40, Count: 3},
+			{From: 40, To: 70, Count: 1},
+			{From: 70, To: 100, Count: 1},
+		}, result.Histogram)
+	})
+
+	t.Run("preços iguais e sem preços", func(t *testing.T) {
+		result, err := tickets.NewPriceStats([]float64{5, 5}, tickets.PriceOptions{})
+		require.NoError(t, err)
+		require.Equal(t, []tickets.Bucket{{From: 5, To: 5, Count: 2}}, result.Histogram)
+		require.Len(t, result.Percentiles, len(tickets.DefaultPercentiles))
+
+		result, err = tickets.NewPriceStats(nil, tickets.PriceOptions{})
+		require.NoError(t, err)
+		require.Equal(t, 0, result.Count)
+		require.Empty(t, result.Histogram)
+	})
+
+	t.Run("opções inválidas", func(t *testing.T) {
+		_, err := tickets.NewPriceStats([]float64{1}, tickets.PriceOptions{Percentiles: []float64{101}})
+		require.Error(t, err)
+
+		_, err = tickets.NewPriceStats([]float64{1}, tickets.PriceOptions{Buckets: -1})
+		require.Error(t, err)
+	})
+
+	t.Run("por destino e global a partir da agregação", func(t *testing.T) {
+		data := generate(3000)
+
+		stats, err := tickets.Aggregate(strings.NewReader(data), tickets.AggregateOptions{Prices: true, Workers: 3, BatchSize: 100})
+		require.NoError(t, err)
+
+		global, err := stats.PriceStats("", tickets.PriceOptions{})
+		require.NoError(t, err)
+		require.Equal(t, 3000, global.Count)
+		require.Equal(t, 100.0, global.Min)
+		require.Equal(t, 106.0, global.Max)
+		require.InDelta(t, stats.Revenue/3000, global.Mean, 1e-9)
+
+		byDestination, err := stats.PriceStatsByDestination(tickets.PriceOptions{})
+		require.NoError(t, err)
+		require.Len(t, byDestination, 3)
+		require.Equal(t, 1000, byDestination["CL"].Count)
+
+		unknown, err := stats.PriceStats("Japan", tickets.PriceOptions{})
+		require.NoError(t, err)
+		require.Equal(t, 0, unknown.Count)
+	})
+
+	t.Run("agregação sem os preços", func(t *testing.T) {
+		stats, err := tickets.Aggregate(strings.NewReader(generate(10)), tickets.AggregateOptions{})
+		require.NoError(t, err)
+
+		_, err = stats.PriceStats("Chile", tickets.PriceOptions{})
+		require.ErrorIs(t, err, tickets.ErrPricesNotCollected)
+	})
+}
@@ -0,0 +1,298 @@
+package tickets
+
+import (
+	"bufio"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/izabelly/go-web/pkg/ticketcsv"
+)
+
+// Colunas usadas no mapeamento
+const (
+	ColumnID          = ticketcsv.ColumnID
+	ColumnName        = ticketcsv.ColumnName
+	ColumnEmail       = ticketcsv.ColumnEmail
+	ColumnDestination = ticketcsv.ColumnDestination
+	ColumnTime        = ticketcsv.ColumnTime
+	ColumnPrice       = ticketcsv.ColumnPrice
+)
+
+// Modos de cabeçalho
+const (
+	HeaderAuto    = ticketcsv.HeaderAuto
+	HeaderPresent = ticketcsv.HeaderPresent
+	HeaderAbsent  = ticketcsv.HeaderAbsent
+)
+
+// Encodings aceitos
+const (
+	EncodingUTF8   = ticketcsv.EncodingUTF8
+	EncodingLatin1 = ticketcsv.EncodingLatin1
+)
+
+// DefaultTimeFormats são os formatos de horário aceitos quando ReadOptions.TimeFormats está vazio
+var DefaultTimeFormats = ticketcsv.DefaultTimeFormats
+
+// ReadOptions configura a leitura do CSV. O valor zero lê o layout original em modo estrito.
+type ReadOptions struct {
+	// Delimiter é o separador de campos; zero usa ','
+	Delimiter rune
+	// Encoding é utf-8 (padrão, com ou sem BOM) ou latin1
+	Encoding string
+	// Header é auto (padrão), present ou absent
+	Header string
+	// Columns mapeia coluna -> índice (base 0) e tem precedência sobre o cabeçalho
+	Columns map[string]int
+	// TimeFormats são os layouts aceitos para o horário; vazio usa DefaultTimeFormats
+	TimeFormats []string
+	// Lenient ignora as linhas inválidas e as registra no Report em vez de abortar
+	Lenient bool
+	// Location é o fuso em que os horários do arquivo foram registrados; nil usa UTC
+	Location *time.Location
+	// Date é o dia dos horários (o arquivo só tem a hora), usado na conversão de fuso
+	// com horário de verão; zero usa o dia atual em Location
+	Date time.Time
+}
+
+// LineError é o erro de uma linha do arquivo
+type LineError struct {
+	Line int
+	Err  error
+}
+
+func (e *LineError) Error() string {
+	return fmt.Sprintf("linha %d: %v", e.Line, e.Err)
+}
+
+func (e *LineError) Unwrap() error {
+	return e.Err
+}
+
+// Report resume a leitura
+type Report struct {
+	// Lines é o total de linhas de dados lidas (sem o cabeçalho)
+	Lines int
+	// Loaded é o total de tickets válidos
+	Loaded int
+	// Errors são as linhas ignoradas no modo leniente
+	Errors []LineError
+}
+
+// Reader lê tickets de um CSV, um por vez
+type Reader struct {
+	src     *bufio.Reader
+	csv     *csv.Reader
+	opts    ReadOptions
+	columns map[string]int
+	width   int
+	line    int // última linha física lida por start
+	pending []string
+	started bool
+	report  Report
+}
+
+// NewReader cria um Reader sobre r com as opções informadas
+func NewReader(r io.Reader, opts ReadOptions) (*Reader, error) {
+	decoded, err := ticketcsv.Decode(r, opts.Encoding)
+	if err != nil {
+		return nil, err
+	}
+
+	// o csv.Reader usa o próprio src como buffer, então depois de cada linha
+	// src está posicionado no início da próxima (usado na leitura paralela)
+	src := bufio.NewReaderSize(decoded, 64*1024)
+
+	if len(opts.TimeFormats) == 0 {
+		opts.TimeFormats = DefaultTimeFormats
+	}
+	if opts.Header == "" {
+		opts.Header = HeaderAuto
+	}
+	if opts.Location == nil {
+		opts.Location = time.UTC
+	}
+	if opts.Date.IsZero() {
+		opts.Date = time.Now()
+	}
+	opts.Date = opts.Date.In(opts.Location)
+
+	return &Reader{src: src, csv: newCSVReader(src, opts.Delimiter), opts: opts}, nil
+}
+
+func newCSVReader(in io.Reader, delimiter rune) *csv.Reader {
+	c := csv.NewReader(in)
+	if delimiter != 0 {
+		c.Comma = delimiter
+	}
+	c.FieldsPerRecord = -1
+	c.TrimLeadingSpace = true
+	c.ReuseRecord = true
+	return c
+}
+
+// Next retorna o próximo ticket válido ou io.EOF no fim do arquivo. No modo
+// estrito uma linha inválida retorna *LineError; no leniente ela vai para o Report.
+func (r *Reader) Next() (Ticket, error) {
+	if !r.started {
+		if err := r.start(); err != nil {
+			return Ticket{}, err
+		}
+	}
+
+	for {
+		record, err := r.read()
+		if err != nil {
+			var parseErr *csv.ParseError
+			if !errors.As(err, &parseErr) {
+				return Ticket{}, err
+			}
+			// linha malformada (ex: aspas sem fechar)
+			lineErr := &LineError{Line: parseErr.Line, Err: parseErr.Err}
+			r.report.Lines++
+			if !r.opts.Lenient {
+				return Ticket{}, lineErr
+			}
+			r.report.Errors = append(r.report.Errors, *lineErr)
+			continue
+		}
+		r.report.Lines++
+
+		ticket, err := r.parse(record)
+		if err != nil {
+			line, _ := r.csv.FieldPos(0)
+			lineErr := &LineError{Line: line, Err: err}
+			if !r.opts.Lenient {
+				return Ticket{}, lineErr
+			}
+			r.report.Errors = append(r.report.Errors, *lineErr)
+			continue
+		}
+
+		r.report.Loaded++
+		return ticket, nil
+	}
+}
+
+// Report retorna o resumo do que foi lido até agora
+func (r *Reader) Report() Report {
+	return r.report
+}
+
+// ReadAll lê todos os tickets do arquivo
+func ReadAll(in io.Reader, opts ReadOptions) ([]Ticket, Report, error) {
+	r, err := NewReader(in, opts)
+	if err != nil {
+		return nil, Report{}, err
+	}
+
+	var tickets []Ticket
+	for {
+		ticket, err := r.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, r.Report(), err
+		}
+		tickets = append(tickets, ticket)
+	}
+	return tickets, r.Report(), nil
+}
+
+// At retorna o horário de clock no dia e no fuso de day
+func At(day, clock time.Time) time.Time {
+	return time.Date(day.Year(), day.Month(), day.Day(), clock.Hour(), clock.Minute(), clock.Second(), 0, day.Location())
+}
+
+// start lê a primeira linha e define o mapeamento das colunas
+func (r *Reader) start() error {
+	r.started = true
+
+	first, err := r.csv.Read()
+	var parseErr *csv.ParseError
+	if err == io.EOF || (errors.As(err, &parseErr) && r.opts.Lenient) {
+		// sem primeira linha válida vale o layout padrão
+		r.columns = ticketcsv.Mapping(nil, r.opts.Columns)
+		r.width = ticketcsv.Width(r.columns)
+		if parseErr != nil {
+			r.line = parseErr.Line
+			r.report.Lines++
+			r.report.Errors = append(r.report.Errors, LineError{Line: parseErr.Line, Err: parseErr.Err})
+		}
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	first = append([]string(nil), first...)
+	r.line, _ = r.csv.FieldPos(len(first) - 1)
+	r.line += strings.Count(first[len(first)-1], "\n")
+
+	if ticketcsv.IsHeader(r.opts.Header, first) {
+		r.columns = ticketcsv.Mapping(first, r.opts.Columns)
+	} else {
+		r.columns = ticketcsv.Mapping(nil, r.opts.Columns)
+		r.pending = first
+	}
+
+	for _, column := range []string{ColumnDestination, ColumnTime, ColumnPrice} {
+		if _, ok := r.columns[column]; !ok {
+			return fmt.Errorf("coluna %q não encontrada no cabeçalho", column)
+		}
+	}
+	r.width = ticketcsv.Width(r.columns)
+	return nil
+}
+
+func (r *Reader) read() ([]string, error) {
+	if r.pending != nil {
+		record := r.pending
+		r.pending = nil
+		return record, nil
+	}
+	return r.csv.Read()
+}
+
+func (r *Reader) parse(record []string) (Ticket, error) {
+	if len(record) < r.width {
+		return Ticket{}, fmt.Errorf("esperadas ao menos %d colunas, encontradas %d", r.width, len(record))
+	}
+	field := func(column string) string {
+		index, ok := r.columns[column]
+		if !ok {
+			return ""
+		}
+		return strings.TrimSpace(record[index])
+	}
+
+	destination := field(ColumnDestination)
+	if destination == "" {
+		return Ticket{}, errors.New("destino vazio")
+	}
+
+	price, err := strconv.ParseFloat(field(ColumnPrice), 64)
+	if err != nil {
+		return Ticket{}, fmt.Errorf("preço inválido %q", field(ColumnPrice))
+	}
+
+	ticketTime, err := ticketcsv.ParseTime(field(ColumnTime), r.opts.TimeFormats)
+	if err != nil {
+		return Ticket{}, err
+	}
+	ticketTime = At(r.opts.Date, ticketTime)
+
+	return Ticket{
+		ID:          field(ColumnID),
+		Name:        field(ColumnName),
+		Email:       field(ColumnEmail),
+		Destination: destination,
+		Time:        ticketTime,
+		Price:       price,
+	}, nil
+}
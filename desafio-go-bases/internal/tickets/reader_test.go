@@ -0,0 +1,78 @@
+package tickets_test
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/bootcamp-go/desafio-go-bases/internal/tickets"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReadAll(t *testing.T) {
+	t.Run("cabeçalho detectado com colunas em outra ordem", func(t *testing.T) {
+		data := "Preço;Destino;Hora;Nome;Email;ID\n" +
+			"785;Finland;5:11 pm;Tait;tmc0@scribd.com;1\n" +
+			"537;China;20:19:00;Padget;pmckee1@hexun.com;2\n"
+
+		result, report, err := tickets.ReadAll(strings.NewReader(data), tickets.ReadOptions{Delimiter: ';'})
+
+		require.NoError(t, err)
+		require.Len(t, result, 2)
+		require.Equal(t, "Finland", result[0].Destination)
+		require.Equal(t, "1", result[0].ID)
+		require.Equal(t, 17, result[0].Time.Hour())
+		require.Equal(t, 20, result[1].Time.Hour())
+		require.Equal(t, 785.0, result[0].Price)
+		require.Equal(t, tickets.Report{Lines: 2, Loaded: 2}, report)
+	})
+
+	t.Run("modo estrito para na primeira linha inválida", func(t *testing.T) {
+		data := "1,Tait,tmc0@scribd.com,Finland,17:11,785\n" +
+			"2,Padget,pmckee1@hexun.com,China,20:19,abc\n"
+
+		_, _, err := tickets.ReadAll(strings.NewReader(data), tickets.ReadOptions{})
+
+		var lineErr *tickets.LineError
+		require.True(t, errors.As(err, &lineErr))
+		require.Equal(t, 2, lineErr.Line)
+	})
+
+	t.Run("modo leniente registra as linhas inválidas", func(t *testing.T) {
+		data := "1,Tait,tmc0@scribd.com,Finland,17:11,785\n" +
+			"2,Padget,pmckee1@hexun.com,China\n" +
+			"3,Yalonda,yjermyn2@omniture.com,China,25:61,579\n" +
+			"4,Ann,ann@x.com,Brazil,9:05,100\n"
+
+		result, report, err := tickets.ReadAll(strings.NewReader(data), tickets.ReadOptions{Lenient: true})
+
+		require.NoError(t, err)
+		require.Len(t, result, 2)
+		require.Equal(t, 4, report.Lines)
+		require.Equal(t, 2, report.Loaded)
+		require.Len(t, report.Errors, 2)
+		require.Equal(t, 2, report.Errors[0].Line)
+		require.Equal(t, 3, report.Errors[1].Line)
+	})
+
+	t.Run("latin1 e mapeamento manual", func(t *testing.T) {
+		data := "S\xe3o Tom\xe9|x|1|12:00|9.5\n"
+
+		result, _, err := tickets.ReadAll(strings.NewReader(data), tickets.ReadOptions{
+			Delimiter: '|',
+			Encoding:  tickets.EncodingLatin1,
+			Columns: map[string]int{
+				tickets.ColumnDestination: 0,
+				tickets.ColumnName:        1,
+				tickets.ColumnID:          2,
+				tickets.ColumnTime:        3,
+				tickets.ColumnPrice:       4,
+				tickets.ColumnEmail:       1,
+			},
+		})
+
+		require.NoError(t, err)
+		require.Equal(t, "São Tomé", result[0].Destination)
+		require.Equal(t, 9.5, result[0].Price)
+	})
+}
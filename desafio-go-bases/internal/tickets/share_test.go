@@ -0,0 +1,29 @@
+package tickets_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/bootcamp-go/desafio-go-bases/internal/tickets"
+	"github.com/izabelly/go-web/pkg/share"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStatsShare(t *testing.T) {
+	t.Run("participação do destino nas estatísticas", func(t *testing.T) {
+		stats, err := tickets.Aggregate(strings.NewReader("1,Ann,a@x.com,Brazil,10:00,100\n2,Bob,b@x.com,Chile,21:00,200\n3,Cy,c@x.com,Peru,3:00,50\n"), tickets.AggregateOptions{})
+		require.NoError(t, err)
+
+		result, err := stats.Share("chile", share.DefaultOptions)
+		require.NoError(t, err)
+		require.Equal(t, share.Share{Destination: "CL", Count: 1, Total: 3, Ratio: 0.3333, Percentage: 33.33}, result)
+	})
+
+	t.Run("opções inválidas", func(t *testing.T) {
+		stats, err := tickets.Aggregate(strings.NewReader("1,Ann,a@x.com,Brazil,10:00,100\n"), tickets.AggregateOptions{})
+		require.NoError(t, err)
+
+		_, err = stats.Share("Brazil", share.Options{Rounding: "bancário"})
+		require.ErrorIs(t, err, share.ErrInvalidOptions)
+	})
+}
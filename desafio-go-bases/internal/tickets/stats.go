@@ -0,0 +1,371 @@
+package tickets
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"errors"
+	"io"
+	"os"
+	"sort"
+	"sync"
+
+	"github.com/izabelly/go-web/pkg/country"
+	"github.com/izabelly/go-web/pkg/period"
+	"github.com/izabelly/go-web/pkg/share"
+)
+
+// DestinationStats são os totais de um destino
+type DestinationStats struct {
+	Count   int            `json:"count"`
+	Revenue float64        `json:"revenue"`
+	Periods map[string]int `json:"periods"`
+
+	// prices só é preenchido com AggregateOptions.Prices
+	prices []float64
+}
+
+// Stats são todas as estatísticas calculadas em uma única leitura do arquivo.
+// A memória usada depende do número de destinos, não do número de tickets.
+type Stats struct {
+	Total   int            `json:"total"`
+	Revenue float64        `json:"revenue"`
+	Periods map[string]int `json:"periods"`
+	// Destinations usa como chave o código ISO 3166-1 alpha-2 do destino
+	Destinations map[string]*DestinationStats `json:"destinations"`
+	Report       Report                       `json:"-"`
+
+	periods    period.Options
+	keepPrices bool
+}
+
+// NewStats cria as estatísticas vazias com as faixas padrão
+func NewStats() *Stats {
+	return NewStatsWithPeriods(period.Options{})
+}
+
+// NewStatsWithPeriods cria as estatísticas vazias contando os períodos segundo opts
+func NewStatsWithPeriods(opts period.Options) *Stats {
+	return &Stats{
+		Periods:      newPeriods(opts),
+		Destinations: map[string]*DestinationStats{},
+		periods:      opts,
+	}
+}
+
+// PeriodNames retorna os nomes dos períodos na ordem das faixas
+func (s *Stats) PeriodNames() []string {
+	return s.periods.Names()
+}
+
+// Add soma um ticket às estatísticas
+func (s *Stats) Add(ticket Ticket) {
+	period := s.periods.Of(ticket.Destination, ticket.Time)
+
+	s.Total++
+	s.Revenue += ticket.Price
+	s.Periods[period]++
+
+	code := country.Normalize(ticket.Destination)
+	dest, ok := s.Destinations[code]
+	if !ok {
+		dest = &DestinationStats{Periods: newPeriods(s.periods)}
+		s.Destinations[code] = dest
+	}
+	dest.Count++
+	dest.Revenue += ticket.Price
+	dest.Periods[period]++
+	if s.keepPrices {
+		dest.prices = append(dest.prices, ticket.Price)
+	}
+}
+
+// Merge soma as estatísticas de other (ex: de outra goroutine)
+func (s *Stats) Merge(other *Stats) {
+	s.Total += other.Total
+	s.Revenue += other.Revenue
+	for period, count := range other.Periods {
+		s.Periods[period] += count
+	}
+
+	for name, o := range other.Destinations {
+		dest, ok := s.Destinations[name]
+		if !ok {
+			dest = &DestinationStats{Periods: newPeriods(s.periods)}
+			s.Destinations[name] = dest
+		}
+		dest.Count += o.Count
+		dest.Revenue += o.Revenue
+		for period, count := range o.Periods {
+			dest.Periods[period] += count
+		}
+		dest.prices = append(dest.prices, o.prices...)
+	}
+
+	s.Report.Lines += other.Report.Lines
+	s.Report.Loaded += other.Report.Loaded
+	s.Report.Errors = append(s.Report.Errors, other.Report.Errors...)
+	sort.Slice(s.Report.Errors, func(i, j int) bool { return s.Report.Errors[i].Line < s.Report.Errors[j].Line })
+}
+
+// Count retorna o total de tickets do destino, informado pelo nome, apelido ou código
+func (s *Stats) Count(destination string) int {
+	if dest, ok := s.Destinations[country.Normalize(destination)]; ok {
+		return dest.Count
+	}
+	return 0
+}
+
+// Ratio retorna a participação (0-1) dos tickets que vão para o destino, sem arredondar
+func (s *Stats) Ratio(destination string) float64 {
+	if s.Total == 0 {
+		return 0
+	}
+	return float64(s.Count(destination)) / float64(s.Total)
+}
+
+// Percentage retorna a porcentagem (0-100) dos tickets que vão para o destino, sem
+// arredondar; use Share para arredondar
+func (s *Stats) Percentage(destination string) float64 {
+	if s.Total == 0 {
+		return 0
+	}
+	return float64(s.Count(destination)) * 100 / float64(s.Total)
+}
+
+// Share retorna a participação do destino (nome, apelido ou código) no total de tickets,
+// calculada pelo pacote share, o mesmo do serviço HTTP
+func (s *Stats) Share(destination string, opts share.Options) (share.Share, error) {
+	result, err := share.New(s.Count(destination), s.Total, opts)
+	if err != nil {
+		return share.Share{}, err
+	}
+	result.Destination = country.Normalize(destination)
+	return result, nil
+}
+
+// AggregateOptions configura a agregação
+type AggregateOptions struct {
+	ReadOptions
+	// Workers é o número de goroutines que convertem as linhas; 0 ou 1 lê tudo na goroutine atual
+	Workers int
+	// BatchSize é o número de linhas enviadas a cada worker por vez; 0 usa 1024
+	BatchSize int
+	// Period define as faixas do dia e os fusos em que os períodos são contados
+	Period period.Options
+	// Prices guarda os preços de cada destino para Stats.PriceStats; a memória passa
+	// a depender do número de tickets
+	Prices bool
+}
+
+func newStats(opts AggregateOptions) *Stats {
+	stats := NewStatsWithPeriods(opts.Period)
+	stats.keepPrices = opts.Prices
+	return stats
+}
+
+// Aggregate calcula as estatísticas lendo o CSV uma única vez, sem guardar os tickets
+func Aggregate(in io.Reader, opts AggregateOptions) (*Stats, error) {
+	r, err := NewReader(in, opts.ReadOptions)
+	if err != nil {
+		return nil, err
+	}
+
+	if opts.Workers > 1 {
+		return r.aggregateParallel(opts)
+	}
+
+	stats := newStats(opts)
+	for {
+		ticket, err := r.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		stats.Add(ticket)
+	}
+	stats.Report = r.Report()
+	return stats, nil
+}
+
+// AggregateFile abre o arquivo e chama Aggregate
+func AggregateFile(path string, opts AggregateOptions) (*Stats, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	return Aggregate(file, opts)
+}
+
+// chunk é um lote de linhas ainda não convertidas, começando na linha line
+type chunk struct {
+	line int
+	data []byte
+}
+
+// aggregateParallel lê o arquivo em lotes de linhas na goroutine atual e distribui
+// o parse do CSV e a conversão entre os workers; cada worker agrega localmente e o
+// resultado é somado no fim. No modo estrito o erro retornado é o da primeira linha
+// inválida, como na leitura sequencial.
+func (r *Reader) aggregateParallel(opts AggregateOptions) (*Stats, error) {
+	workers, batchSize := opts.Workers, opts.BatchSize
+	if batchSize <= 0 {
+		batchSize = 1024
+	}
+	if !r.started {
+		if err := r.start(); err != nil {
+			return nil, err
+		}
+	}
+
+	stats := newStats(opts)
+	stats.Report = r.report
+	// a primeira linha já foi lida por start quando não era cabeçalho
+	if r.pending != nil {
+		r.parseRecord(stats, r.pending, r.line)
+		r.pending = nil
+	}
+
+	chunks := make(chan chunk, workers)
+	results := make(chan *Stats, workers)
+	stop := make(chan struct{})
+	var once sync.Once
+	failed := func() { once.Do(func() { close(stop) }) }
+	if !r.opts.Lenient && len(stats.Report.Errors) > 0 {
+		failed()
+	}
+
+	for i := 0; i < workers; i++ {
+		go func() {
+			local := newStats(opts)
+			for c := range chunks {
+				if !r.parseChunk(local, c) && !r.opts.Lenient {
+					failed()
+				}
+			}
+			results <- local
+		}()
+	}
+
+	readErr := r.produce(chunks, batchSize, stop)
+	close(chunks)
+	for i := 0; i < workers; i++ {
+		stats.Merge(<-results)
+	}
+
+	if readErr != nil {
+		return nil, readErr
+	}
+	if !r.opts.Lenient && len(stats.Report.Errors) > 0 {
+		first := stats.Report.Errors[0]
+		return nil, &first
+	}
+	return stats, nil
+}
+
+// parseChunk converte as linhas do lote; retorna false se alguma linha for inválida
+func (r *Reader) parseChunk(stats *Stats, c chunk) bool {
+	ok := true
+	in := newCSVReader(bytes.NewReader(c.data), r.opts.Delimiter)
+	for {
+		record, err := in.Read()
+		if err == io.EOF {
+			return ok
+		}
+		if err != nil {
+			var parseErr *csv.ParseError
+			if !errors.As(err, &parseErr) {
+				return false
+			}
+			stats.Report.Lines++
+			stats.Report.Errors = append(stats.Report.Errors, LineError{Line: c.line + parseErr.Line - 1, Err: parseErr.Err})
+			ok = false
+			continue
+		}
+
+		line, _ := in.FieldPos(0)
+		if !r.parseRecord(stats, record, c.line+line-1) {
+			ok = false
+		}
+	}
+}
+
+// parseRecord converte e soma uma linha; retorna false se ela for inválida
+func (r *Reader) parseRecord(stats *Stats, record []string, line int) bool {
+	stats.Report.Lines++
+	ticket, err := r.parse(record)
+	if err != nil {
+		stats.Report.Errors = append(stats.Report.Errors, LineError{Line: line, Err: err})
+		return false
+	}
+	stats.Report.Loaded++
+	stats.Add(ticket)
+	return true
+}
+
+// produce separa o arquivo em lotes de batchSize registros sem fazer o parse. Um
+// registro termina no fim de uma linha com aspas balanceadas, então campos entre
+// aspas com quebra de linha não são divididos.
+func (r *Reader) produce(chunks chan<- chunk, batchSize int, stop <-chan struct{}) error {
+	line := r.line + 1
+	current := chunk{line: line}
+	records, quoted := 0, false
+
+	send := func() bool {
+		select {
+		case chunks <- current:
+			current = chunk{line: line}
+			records = 0
+			return true
+		case <-stop:
+			return false
+		}
+	}
+
+	for {
+		data, err := r.src.ReadSlice('\n')
+		for err == bufio.ErrBufferFull {
+			// linha maior que o buffer
+			current.data = append(current.data, data...)
+			data, err = r.src.ReadSlice('\n')
+		}
+		if err != nil && err != io.EOF {
+			return err
+		}
+
+		current.data = append(current.data, data...)
+		if len(data) > 0 {
+			line++
+			if bytes.Count(data, []byte{'"'})%2 == 1 {
+				quoted = !quoted
+			}
+			if !quoted {
+				records++
+			}
+		}
+
+		if err == io.EOF {
+			break
+		}
+		if records == batchSize && !quoted && !send() {
+			return nil
+		}
+	}
+
+	if len(current.data) > 0 {
+		send()
+	}
+	return nil
+}
+
+func newPeriods(opts period.Options) map[string]int {
+	periods := map[string]int{}
+	for _, name := range opts.Names() {
+		periods[name] = 0
+	}
+	return periods
+}
@@ -0,0 +1,141 @@
+package tickets_test
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/bootcamp-go/desafio-go-bases/internal/tickets"
+	"github.com/izabelly/go-web/pkg/country"
+	"github.com/stretchr/testify/require"
+)
+
+// generate monta um CSV com n tickets alternando entre Brazil, Chile e Peru
+func generate(n int) string {
+	var sb strings.Builder
+	destinations := []string{"Brazil", "Chile", "Peru"}
+	for i := 1; i <= n; i++ {
+		fmt.Fprintf(&sb, "%d,Name %d,n%d@mail.com,%s,%d:%02d,%d\n", i, i, i, destinations[i%3], i%24, i%60, 100+i%7)
+	}
+	return sb.String()
+}
+
+func TestAggregate(t *testing.T) {
+	t.Run("sequencial e paralelo chegam ao mesmo resultado", func(t *testing.T) {
+		data := generate(10000)
+
+		sequential, err := tickets.Aggregate(strings.NewReader(data), tickets.AggregateOptions{})
+		require.NoError(t, err)
+		parallel, err := tickets.Aggregate(strings.NewReader(data), tickets.AggregateOptions{Workers: 4, BatchSize: 100})
+		require.NoError(t, err)
+
+		require.Equal(t, 10000, sequential.Total)
+		require.Equal(t, 3334, sequential.Count("Chile"))
+		require.Equal(t, sequential.Total, parallel.Total)
+		require.InDelta(t, sequential.Revenue, parallel.Revenue, 1e-6)
+		require.Equal(t, sequential.Periods, parallel.Periods)
+		for name, dest := range sequential.Destinations {
+			require.Equal(t, dest.Count, parallel.Destinations[name].Count)
+			require.Equal(t, dest.Periods, parallel.Destinations[name].Periods)
+		}
+		require.Equal(t, sequential.Report, parallel.Report)
+		require.InDelta(t, 33.34, parallel.Percentage("Chile"), 1e-9)
+	})
+
+	t.Run("paralelo com cabeçalho e campos com quebra de linha", func(t *testing.T) {
+		data := "id,name,email,destination,time,price\n" +
+			"1,\"Ann\nLee\",a@x.com,Brazil,10:00,100\n" +
+			"2,Bob,b@x.com,Chile,21:00,200\n" +
+			"3,\"Cy, \"\"Jr\"\"\",c@x.com,Brazil,3:00,50\n" +
+			"4,Dee,d@x.com,Peru,abc,50\n"
+
+		stats, err := tickets.Aggregate(strings.NewReader(data), tickets.AggregateOptions{
+			ReadOptions: tickets.ReadOptions{Lenient: true},
+			Workers:     2,
+			BatchSize:   1,
+		})
+
+		require.NoError(t, err)
+		require.Equal(t, 3, stats.Total)
+		require.Equal(t, 2, stats.Count("Brazil"))
+		require.Equal(t, []tickets.LineError{{Line: 6, Err: stats.Report.Errors[0].Err}}, stats.Report.Errors)
+	})
+
+	t.Run("destinos agrupados pelo código do país", func(t *testing.T) {
+		data := "1,Ann,a@x.com,Brazil,10:00,100\n" +
+			"2,Bob,b@x.com,brazil,21:00,200\n" +
+			"3,Cy,c@x.com,Brasil ,3:00,50\n" +
+			"4,Dee,d@x.com,BR,3:00,50\n"
+
+		stats, err := tickets.Aggregate(strings.NewReader(data), tickets.AggregateOptions{})
+
+		require.NoError(t, err)
+		require.Len(t, stats.Destinations, 1)
+		require.Equal(t, 4, stats.Destinations["BR"].Count)
+		require.Equal(t, 4, stats.Count("BRA"))
+		require.Equal(t, 100.0, stats.Percentage(" brasil"))
+	})
+
+	t.Run("paralelo no modo estrito retorna a primeira linha inválida", func(t *testing.T) {
+		lines := strings.Split(generate(5000), "\n")
+		lines[4999] = "5000,x,x@x.com,Peru,10:00,abc"
+		lines[1234] = "1235,x,x@x.com,Peru,99:00,10"
+		data := strings.Join(lines, "\n")
+
+		_, err := tickets.Aggregate(strings.NewReader(data), tickets.AggregateOptions{Workers: 3, BatchSize: 64})
+
+		var lineErr *tickets.LineError
+		require.True(t, errors.As(err, &lineErr))
+		require.Equal(t, 1235, lineErr.Line)
+	})
+
+	t.Run("paralelo no modo leniente junta os relatórios", func(t *testing.T) {
+		lines := strings.Split(generate(3000), "\n")
+		lines[10] = "11,x,x@x.com,Peru"
+		lines[2000] = "2001,x,x@x.com,Peru,10:00,abc"
+		data := strings.Join(lines, "\n")
+
+		stats, err := tickets.Aggregate(strings.NewReader(data), tickets.AggregateOptions{
+			ReadOptions: tickets.ReadOptions{Lenient: true},
+			Workers:     2,
+			BatchSize:   50,
+		})
+
+		require.NoError(t, err)
+		require.Equal(t, 2998, stats.Total)
+		require.Equal(t, 3000, stats.Report.Lines)
+		require.Len(t, stats.Report.Errors, 2)
+		require.Equal(t, 11, stats.Report.Errors[0].Line)
+		require.Equal(t, 2001, stats.Report.Errors[1].Line)
+	})
+}
+
+func BenchmarkAggregate(b *testing.B) {
+	data := generate(100000)
+	for _, workers := range []int{1, 4} {
+		b.Run(fmt.Sprintf("workers=%d", workers), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				if _, err := tickets.Aggregate(strings.NewReader(data), tickets.AggregateOptions{Workers: workers}); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+func TestDestinations_Dataset(t *testing.T) {
+	// todos os destinos do arquivo estão na tabela de países
+	f, err := os.Open("../../tickets.csv")
+	require.NoError(t, err)
+	defer f.Close()
+	records, err := csv.NewReader(f).ReadAll()
+	require.NoError(t, err)
+
+	for _, record := range records {
+		_, ok := country.Lookup(record[3])
+		require.True(t, ok, record[3])
+	}
+}
@@ -1,11 +1,11 @@
 package tickets
 
 import (
-	"encoding/csv"
 	"fmt"
-	"os"
-	"strconv"
 	"time"
+
+	"github.com/izabelly/go-web/pkg/period"
+	"github.com/izabelly/go-web/pkg/share"
 )
 
 type Ticket struct {
@@ -18,135 +18,72 @@ type Ticket struct {
 }
 
 const (
-	Madrugada = "madrugada"
-	Manha     = "manha"
-	Tarde     = "tarde"
-	Noite     = "noite"
+	Madrugada = period.Madrugada
+	Manha     = period.Manha
+	Tarde     = period.Tarde
+	Noite     = period.Noite
 )
 
-var fileName string = "/Users/idmelo/Documents/GO/desafio-go-bases/tickets.csv"
+// FileName é o arquivo lido por GetTotalTickets, GetMornings e AverageDestination,
+// relativo ao diretório atual
+var FileName = "tickets.csv"
+
+// Options são as opções de leitura e de períodos usadas por GetTotalTickets,
+// GetMornings e AverageDestination
+var Options AggregateOptions
+
+// PeriodOf retorna o período do dia do horário segundo period.Default
+func PeriodOf(t time.Time) string {
+	return period.Default.Of(t)
+}
 
 // ejemplo 1
 func GetTotalTickets(destination string) (int, error) {
-	passagem, err := listTicket()
+	stats, err := AggregateFile(FileName, Options)
 	if err != nil {
-		fmt.Println("Erro ao preencher tickets:", err)
-	}
-
-	total := 0
-	for _, ticket := range passagem {
-		if ticket.Destination == destination {
-			total++
-		}
+		return 0, fmt.Errorf("erro ao preencher tickets: %w", err)
 	}
 
-	return total, nil
+	return stats.Count(destination), nil
 }
 
 // ejemplo 2
 func GetMornings(periodo string) (int, error) {
-	passagem, err := listTicket()
+	stats, err := AggregateFile(FileName, Options)
 	if err != nil {
-		fmt.Println("Erro ao preencher tickets:", err)
+		return 0, fmt.Errorf("erro ao preencher tickets: %w", err)
 	}
 
-	var total = 0
-	for _, ticket := range passagem {
-		hour := ticket.Time.Hour()
-
-		switch periodo {
-		case "madrugada":
-			if hour >= 0 && hour <= 6 {
-				total++
-			}
-		case "manha":
-			if hour >= 7 && hour <= 12 {
-				total++
-			}
-		case "tarde":
-			if hour >= 13 && hour <= 19 {
-				total++
-			}
-		case "noite":
-			if hour >= 20 && hour <= 23 {
-				total++
-			}
-		}
-	}
-
-	return total, nil
+	return stats.Periods[periodo], nil
 }
 
 // ejemplo 3
-func AverageDestination(destination string) (int, error) {
-	passagem, err := listTicket()
+// AverageDestination retorna a porcentagem (0-100) dos tickets que vão para o destino,
+// sem arredondar; use DestinationShare para a razão e o arredondamento
+func AverageDestination(destination string) (float64, error) {
+	result, err := DestinationShare(destination, share.Options{Precision: share.FullPrecision})
 	if err != nil {
-		fmt.Println("Erro ao preencher tickets:", err)
-		return 0, nil
-	}
-
-	totalTickets := len(passagem)
-	if totalTickets == 0 {
-		return 0, nil
-	}
-
-	total := 0
-	for _, ticket := range passagem {
-		if ticket.Destination == destination {
-			total++
-		}
+		return 0, err
 	}
 
-	value := (total * 100) / totalTickets
-
-	return value, nil
+	return result.Percentage, nil
 }
 
-func listTicket() ([]Ticket, error) {
-	file, err := os.Open(fileName)
-	if err != nil {
-		return nil, err
+// DestinationShare retorna a participação do destino no total de tickets de FileName
+func DestinationShare(destination string, opts share.Options) (share.Share, error) {
+	if err := opts.Validate(); err != nil {
+		return share.Share{}, err
 	}
-
-	defer file.Close()
-
-	data, err := csv.NewReader(file).ReadAll()
+	stats, err := AggregateFile(FileName, Options)
 	if err != nil {
-		return nil, err
+		return share.Share{}, fmt.Errorf("erro ao preencher tickets: %w", err)
 	}
 
-	var passagem []Ticket
-
-	for _, dados := range data {
-		if len(dados) < 6 {
-			continue
-		}
-
-		price, err := strconv.ParseFloat(dados[5], 64)
-		if err != nil {
-			return nil, err
-		}
-
-		ticketTime, err := ParseHHMM(dados[4])
-		if err != nil {
-			return nil, err
-		}
-
-		ticket := Ticket{
-			ID:          dados[0],
-			Name:        dados[1],
-			Email:       dados[2],
-			Destination: dados[3],
-			Time:        ticketTime,
-			Price:       price,
-		}
-
-		passagem = append(passagem, ticket)
-	}
-
-	return passagem, nil
+	return stats.Share(destination, opts)
 }
 
+// ParseHHMM converte HH:MM em um horário sem data (0000-01-01) em UTC; use
+// ParseHHMMIn para posicionar o horário em um dia e fuso
 func ParseHHMM(timeStr string) (time.Time, error) {
 	timestamp, err := time.Parse("15:04", timeStr)
 	if err != nil {
@@ -154,3 +91,12 @@ func ParseHHMM(timeStr string) (time.Time, error) {
 	}
 	return timestamp, nil
 }
+
+// ParseHHMMIn converte HH:MM no horário do dia e do fuso de day
+func ParseHHMMIn(timeStr string, day time.Time) (time.Time, error) {
+	clock, err := ParseHHMM(timeStr)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return At(day, clock), nil
+}
@@ -4,6 +4,7 @@ import (
 	"testing"
 
 	"github.com/bootcamp-go/desafio-go-bases/internal/tickets"
+	"github.com/izabelly/go-web/pkg/share"
 	"github.com/stretchr/testify/require"
 )
 
@@ -50,13 +51,35 @@ func TestGetMornings(t *testing.T) {
 func TestAverageDestination(t *testing.T) {
 	t.Run("1", func(t *testing.T) {
 		result, _ := tickets.AverageDestination("Brazil")
-		expected := 4
+		expected := 4.5
 		require.Equal(t, result, expected)
 	})
 
 	t.Run("2", func(t *testing.T) {
 		result, _ := tickets.AverageDestination("Philippines")
-		expected := 4
+		expected := 4.6
 		require.Equal(t, result, expected)
 	})
 }
+
+func TestDestinationShare(t *testing.T) {
+	t.Run("razão e porcentagem arredondadas", func(t *testing.T) {
+		result, err := tickets.DestinationShare("Brasil", share.Options{Precision: 0, Rounding: share.RoundHalfEven})
+		expected := share.Share{Destination: "BR", Count: 45, Total: 1000, Ratio: 0.04, Percentage: 4}
+		require.NoError(t, err)
+		require.Equal(t, expected, result)
+	})
+
+	t.Run("erro ao ler o arquivo", func(t *testing.T) {
+		fileName := tickets.FileName
+		tickets.FileName = "nao-existe.csv"
+		defer func() { tickets.FileName = fileName }()
+
+		_, err := tickets.AverageDestination("Brazil")
+		require.Error(t, err)
+		_, err = tickets.GetTotalTickets("Brazil")
+		require.Error(t, err)
+		_, err = tickets.GetMornings("noite")
+		require.Error(t, err)
+	})
+}
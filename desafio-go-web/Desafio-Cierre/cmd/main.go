@@ -1,25 +1,67 @@
 package main
 
 import (
+	"app/internal"
 	"app/internal/application"
+	"app/internal/loader"
 	"fmt"
+	"os"
+	"time"
+	_ "time/tzdata" // time zones of TICKETS_TZ and TICKETS_DEST_TZ even without the system zoneinfo
 
+	"github.com/izabelly/go-web/pkg/auth"
+	"github.com/izabelly/go-web/pkg/period"
+	"github.com/izabelly/go-web/pkg/tlsserver"
 	"github.com/joho/godotenv"
 )
 
 func main() {
 	// env
 	godotenv.Load()
+	// - jwt: optional, enabled when a secret or a jwks file is set
+	var jwt *auth.JWTConfig
+	if os.Getenv("JWT_SECRET") != "" || os.Getenv("JWT_JWKS_FILE") != "" {
+		jwt = &auth.JWTConfig{
+			Secret:   []byte(os.Getenv("JWT_SECRET")),
+			JWKSFile: os.Getenv("JWT_JWKS_FILE"),
+			Issuer:   os.Getenv("JWT_ISSUER"),
+			Audience: os.Getenv("JWT_AUDIENCE"),
+		}
+	}
+	// - reload: TICKETS_RELOAD_INTERVAL (ex: 30s) watches the db file, empty disables it
+	reloadInterval, _ := time.ParseDuration(os.Getenv("TICKETS_RELOAD_INTERVAL"))
+	// - csv: TICKETS_CSV_DELIMITER, TICKETS_CSV_ENCODING, TICKETS_CSV_HEADER and TICKETS_CSV_LENIENT
+	csvOptions := loader.OptionsTicketCSV{
+		Encoding: os.Getenv("TICKETS_CSV_ENCODING"),
+		Header:   os.Getenv("TICKETS_CSV_HEADER"),
+		Lenient:  os.Getenv("TICKETS_CSV_LENIENT") == "true",
+	}
+	if delimiter := []rune(os.Getenv("TICKETS_CSV_DELIMITER")); len(delimiter) == 1 {
+		csvOptions.Delimiter = delimiter[0]
+	}
+	// - periods: TICKETS_PERIODS, TICKETS_TZ, TICKETS_DEST_TZ, TICKETS_SOURCE_TZ and TICKETS_DATE
+	periods, err := periodOptions()
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
 	// application
 	// - config
 	cfg := &application.ConfigAppDefault{
 		ServerAddr: ":8080",
 		DbFile:     "docs/db/tickets.csv",
+		JWT:        jwt,
+		// - tls: TLS_CERT_FILE, TLS_KEY_FILE, TLS_CLIENT_CA_FILE, TLS_CLIENT_AUTH, TLS_MIN_VERSION
+		TLS:            tlsserver.FromEnv(),
+		ClientScopes:   auth.ParseScopeMap(os.Getenv("TLS_CLIENT_SCOPES")),
+		ReloadInterval: reloadInterval,
+		Loader:         csvOptions,
+		Periods:        periods,
 	}
 	app := application.NewApplicationDefault(cfg)
 
 	// - setup
-	err := app.SetUp()
+	err = app.SetUp()
 	if err != nil {
 		fmt.Println(err)
 		return
@@ -32,3 +74,41 @@ func main() {
 		return
 	}
 }
+
+// periodOptions reads the periods of the day and their time zones from the env, in the
+// same formats as the --periods, --tz, --dest-tz, --source-tz and --date flags of the
+// desafio-go-bases CLI, so both count the same file in the same periods
+func periodOptions() (opts internal.PeriodOptions, err error) {
+	if spec := os.Getenv("TICKETS_PERIODS"); spec != "" {
+		if opts.Periods, err = period.Parse(spec); err != nil {
+			err = fmt.Errorf("TICKETS_PERIODS: %w", err)
+			return
+		}
+	}
+	if zone := os.Getenv("TICKETS_TZ"); zone != "" {
+		if opts.Location, err = time.LoadLocation(zone); err != nil {
+			err = fmt.Errorf("TICKETS_TZ: %w", err)
+			return
+		}
+	}
+	if spec := os.Getenv("TICKETS_DEST_TZ"); spec != "" {
+		if opts.Destinations, err = period.ParseLocations(spec); err != nil {
+			err = fmt.Errorf("TICKETS_DEST_TZ: %w", err)
+			return
+		}
+	}
+	opts.Source = time.UTC
+	if zone := os.Getenv("TICKETS_SOURCE_TZ"); zone != "" {
+		if opts.Source, err = time.LoadLocation(zone); err != nil {
+			err = fmt.Errorf("TICKETS_SOURCE_TZ: %w", err)
+			return
+		}
+	}
+	if date := os.Getenv("TICKETS_DATE"); date != "" {
+		if opts.Date, err = time.ParseInLocation("2006-01-02", date, opts.Source); err != nil {
+			err = fmt.Errorf("TICKETS_DATE %q: use YYYY-MM-DD", date)
+			return
+		}
+	}
+	return
+}
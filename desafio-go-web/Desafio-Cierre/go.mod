@@ -1,14 +1,19 @@
 module app
 
-go 1.21.2
+go 1.23.3
 
-require github.com/go-chi/chi/v5 v5.1.0
+require (
+	github.com/go-chi/chi/v5 v5.1.0
+	github.com/izabelly/go-web v0.0.0
+)
 
 require (
-	github.com/bootcamp-go/web v1.0.0 // indirect
+	github.com/bootcamp-go/web v1.0.0
 	github.com/davecgh/go-spew v1.1.1 // indirect
-	github.com/joho/godotenv v1.5.1 // indirect
+	github.com/joho/godotenv v1.5.1
 	github.com/pmezard/go-difflib v1.0.0 // indirect
-	github.com/stretchr/testify v1.10.0 // indirect
+	github.com/stretchr/testify v1.10.0
 	gopkg.in/yaml.v3 v3.0.1 // indirect
 )
+
+replace github.com/izabelly/go-web => ../../go-web
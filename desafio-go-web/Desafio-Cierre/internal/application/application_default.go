@@ -1,14 +1,21 @@
 package application
 
 import (
+	"app/internal"
 	"app/internal/handler"
 	"app/internal/loader"
 	"app/internal/repository"
 	"app/internal/service"
+	"context"
 	"log"
 	"net/http"
+	"time"
 
+	"github.com/bootcamp-go/web/response"
 	"github.com/go-chi/chi/v5"
+	"github.com/izabelly/go-web/pkg/auth"
+	"github.com/izabelly/go-web/pkg/ratelimit"
+	"github.com/izabelly/go-web/pkg/tlsserver"
 )
 
 // ConfigServerChi is a struct that represents the configuration for ServerChi
@@ -17,6 +24,20 @@ type ConfigAppDefault struct {
 	ServerAddr string
 	// dbFile represents the path to the database file
 	DbFile string
+	// JWT enables bearer token authentication on /ticket when set
+	JWT *auth.JWTConfig
+	// RateLimit represents the limit per api key (or ip) on /ticket
+	RateLimit ratelimit.Limit
+	// TLS represents the certificate configuration, plain http when empty
+	TLS tlsserver.Config
+	// ClientScopes represents the scopes of each client certificate CN (mTLS)
+	ClientScopes map[string][]string
+	// Loader represents the delimiter, encoding, columns and strictness of the db file
+	Loader loader.OptionsTicketCSV
+	// ReloadInterval represents how often the db file is checked for changes, 0 disables the watcher
+	ReloadInterval time.Duration
+	// Periods represents the ranges of the day and the time zones the tickets are counted in
+	Periods internal.PeriodOptions
 }
 
 // NewApplicationDefault creates a new default application
@@ -25,6 +46,7 @@ func NewApplicationDefault(cfg *ConfigAppDefault) *ApplicationDefault {
 	defaultRouter := chi.NewRouter()
 	defaultConfig := &ConfigAppDefault{
 		ServerAddr: ":8080",
+		RateLimit:  ratelimit.Limit{Requests: 300, Per: time.Minute},
 	}
 	if cfg != nil {
 		if cfg.ServerAddr != "" {
@@ -33,12 +55,28 @@ func NewApplicationDefault(cfg *ConfigAppDefault) *ApplicationDefault {
 		if cfg.DbFile != "" {
 			defaultConfig.DbFile = cfg.DbFile
 		}
+		if cfg.RateLimit.Requests > 0 {
+			defaultConfig.RateLimit = cfg.RateLimit
+		}
+		defaultConfig.JWT = cfg.JWT
+		defaultConfig.TLS = cfg.TLS
+		defaultConfig.ClientScopes = cfg.ClientScopes
+		defaultConfig.ReloadInterval = cfg.ReloadInterval
+		defaultConfig.Loader = cfg.Loader
+		defaultConfig.Periods = cfg.Periods
 	}
 
 	return &ApplicationDefault{
-		rt:         defaultRouter,
-		serverAddr: defaultConfig.ServerAddr,
-		dbFile:     defaultConfig.DbFile,
+		rt:             defaultRouter,
+		serverAddr:     defaultConfig.ServerAddr,
+		dbFile:         defaultConfig.DbFile,
+		jwt:            defaultConfig.JWT,
+		rateLimit:      defaultConfig.RateLimit,
+		tls:            defaultConfig.TLS,
+		clientScopes:   defaultConfig.ClientScopes,
+		reloadInterval: defaultConfig.ReloadInterval,
+		loader:         defaultConfig.Loader,
+		periods:        defaultConfig.Periods,
 	}
 }
 
@@ -50,22 +88,63 @@ type ApplicationDefault struct {
 	serverAddr string
 	// dbFile represents the path to the database file
 	dbFile string
+	// jwt represents the bearer token configuration, nil disables it
+	jwt *auth.JWTConfig
+	// rateLimit represents the limit per api key (or ip) on /ticket
+	rateLimit ratelimit.Limit
+	// tls represents the certificate configuration
+	tls tlsserver.Config
+	// clientScopes represents the scopes of each client certificate CN
+	clientScopes map[string][]string
+	// loader represents the options of the db file
+	loader loader.OptionsTicketCSV
+	// reloadInterval represents how often the db file is checked for changes
+	reloadInterval time.Duration
+	// periods represents the ranges of the day and the time zones the tickets are counted in
+	periods internal.PeriodOptions
+	// reload represents the service that reloads the db file
+	reload *service.ServiceReloadDefault
 }
 
+// scopeReload is the scope required on /admin/reload when authentication is configured
+const scopeReload = "tickets:reload"
+
 // Run is a method that runs the application
 func (a *ApplicationDefault) SetUp() (err error) {
+	// - rate limit: an invalid limit would never (or always) admit a request
+	if err = a.rateLimit.Validate(); err != nil {
+		return
+	}
+
 	// dependencies
-	db := loader.NewLoaderTicketCSV(a.dbFile)
+	db := loader.NewLoaderTicketCSVWithOptions(a.dbFile, a.loader)
 	tickets, err := db.Load()
 	if err != nil {
 		log.Println("failed to load")
 		return
 	}
-	rp := repository.NewRepositoryTicketMap(0, tickets)
+	for _, lineErr := range db.Report().Errors {
+		log.Println("skipped", lineErr.String())
+	}
+	rp := repository.NewRepositoryTicketMapWithPeriods(0, tickets, loader.NewWriterTicketCSV(db), a.periods)
 	// service ...
+	a.reload = service.NewServiceReloadDefault(db, rp)
+	reloadHandler := handler.NewHandlerReloadDefault(a.reload)
 	service := service.NewServiceTicketDefault(rp)
 	// handler ...
 	handler := handler.NewHandlerTicketDefault(service)
+	// auth ...
+	var verifier *auth.JWTVerifier
+	if a.jwt != nil {
+		verifier, err = auth.NewJWTVerifier(*a.jwt)
+		if err != nil {
+			log.Println("failed to set up jwt")
+			return
+		}
+	}
+
+	// middlewares
+	(*a).rt.Use(auth.ClientCert(a.clientScopes))
 
 	// routes
 	(*a).rt.Get("/health", func(w http.ResponseWriter, r *http.Request) {
@@ -75,16 +154,63 @@ func (a *ApplicationDefault) SetUp() (err error) {
 	})
 
 	(*a).rt.Route("/ticket", func(rt chi.Router) {
-		// - GET /ticket
-		rt.Get("/", handler.GetTotalAmountTickets)
+		if verifier != nil {
+			rt.Use(auth.Bearer(verifier, response.Error))
+		}
+		rt.Use(ratelimit.Middleware(ratelimit.NewMemory(), "ticket", a.rateLimit, ratelimit.ByPrincipal, response.Error))
+		// - GET /ticket, with a query string it searches the tickets
+		rt.Get("/", handler.GetTickets)
 		rt.Get("/getByCountry/{dest}", handler.GetTicketsAmountByDestinationCountry)
 		rt.Get("/getAverage/{dest}", handler.GetAverageCountry)
+		rt.Get("/getPercentage/{dest}", handler.GetPercentageTicketsByDestinationCountry)
+		rt.Get("/getRevenue/{dest}", handler.GetRevenueByDestinationCountry)
+		rt.Get("/getAveragePrice/{dest}", handler.GetAveragePriceByDestinationCountry)
+		rt.Get("/getStats/{dest}", handler.GetCountryStats)
+		rt.Get("/getByPeriod/{period}", handler.GetTicketsAmountByPeriodName)
+		// - GET /ticket/periods and /ticket/breakdown
+		rt.Get("/periods", handler.GetTicketsAmountByPeriod)
+		rt.Get("/breakdown", handler.GetBreakdown)
+		// - GET /ticket/stats/price?country=
+		rt.Get("/stats/price", handler.GetPriceStats)
+		// - GET /ticket/share/{dest}?precision=&rounding=
+		rt.Get("/share/{dest}", handler.GetShare)
+		// - crud
+		rt.Post("/", handler.CreateTicket)
+		rt.Get("/{id}", handler.GetTicketById)
+		rt.Put("/{id}", handler.UpdateTicket)
+		rt.Patch("/{id}", handler.PatchTicket)
+		rt.Delete("/{id}", handler.DeleteTicket)
+	})
+
+	// - /admin is mounted only with authentication, without it anyone could force a reload;
+	// the watcher (TICKETS_RELOAD_INTERVAL) still picks up a new export of the file
+	if verifier == nil && len(a.clientScopes) == 0 {
+		log.Println("no jwt or client scopes configured, /admin/reload is disabled")
+		return
+	}
+	(*a).rt.Route("/admin", func(rt chi.Router) {
+		if verifier != nil {
+			rt.Use(auth.Bearer(verifier, response.Error))
+		}
+		rt.Use(auth.RequireScope(scopeReload, response.Error))
+		// - POST /admin/reload
+		rt.Post("/reload", reloadHandler.Reload)
+		rt.Get("/reload", reloadHandler.GetLastReload)
 	})
 	return
 }
 
+
 // Run runs the application
 func (a *ApplicationDefault) Run() (err error) {
-	err = http.ListenAndServe(a.serverAddr, a.rt)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	// - watch the db file for a new export
+	if a.reloadInterval > 0 && a.reload != nil {
+		go a.reload.Watch(ctx, a.reloadInterval)
+	}
+
+	err = tlsserver.ListenAndServe(ctx, a.serverAddr, a.rt, a.tls)
 	return
 }
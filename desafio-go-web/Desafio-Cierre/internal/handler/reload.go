@@ -0,0 +1,58 @@
+package handler
+
+import (
+	"app/internal"
+	"app/internal/service"
+	"errors"
+	"net/http"
+
+	"github.com/bootcamp-go/web/response"
+)
+
+func NewHandlerReloadDefault(sv *service.ServiceReloadDefault) *HandlerReloadDefault {
+	return &HandlerReloadDefault{
+		sv: sv,
+	}
+}
+
+type HandlerReloadDefault struct {
+	// sv is the service that will be used by the handler
+	sv *service.ServiceReloadDefault
+}
+
+// Reload re-reads the tickets file; a bad file answers 422 and keeps the previous tickets
+func (h *HandlerReloadDefault) Reload(w http.ResponseWriter, r *http.Request) {
+	result, err := h.sv.Reload()
+	if err != nil {
+		status := http.StatusInternalServerError
+		if errors.Is(err, internal.ErrInvalidDataset) {
+			status = http.StatusUnprocessableEntity
+		}
+		response.JSON(w, status, map[string]any{
+			"message": "Reload failed, previous tickets kept",
+			"data":    result,
+		})
+		return
+	}
+
+	response.JSON(w, http.StatusOK, map[string]any{
+		"message": "Tickets reloaded",
+		"data":    result,
+	})
+}
+
+// GetLastReload returns the outcome of the last reload
+func (h *HandlerReloadDefault) GetLastReload(w http.ResponseWriter, r *http.Request) {
+	result, ok := h.sv.LastReload()
+	if !ok {
+		response.JSON(w, http.StatusNotFound, map[string]string{
+			"error": "no reload yet",
+		})
+		return
+	}
+
+	response.JSON(w, http.StatusOK, map[string]any{
+		"message": "Last reload",
+		"data":    result,
+	})
+}
@@ -1,11 +1,21 @@
 package handler
 
 import (
+	"app/internal"
 	"app/internal/service"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
 	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
 
+	"github.com/bootcamp-go/web/request"
 	"github.com/bootcamp-go/web/response"
 	"github.com/go-chi/chi/v5"
+	"github.com/izabelly/go-web/pkg/share"
 )
 
 func NewHandlerTicketDefault(sv *service.ServiceTicketDefault) *HandlerTicketDefault {
@@ -32,35 +42,499 @@ func (h *HandlerTicketDefault) GetTotalAmountTickets(w http.ResponseWriter, r *h
 	})
 }
 
+// GetTickets returns the total amount of tickets when there is no query string, otherwise
+// the tickets that match ?country=A,B&hourFrom=&hourTo=&priceMin=&priceMax=&email_domain=,
+// paginated with ?page=&pageSize= and sorted with ?sort=price or ?sort=-price.
+// With ?groupBy= it returns the amount of matching tickets per value of the field.
+func (h *HandlerTicketDefault) GetTickets(w http.ResponseWriter, r *http.Request) {
+	if r.URL.RawQuery == "" {
+		h.GetTotalAmountTickets(w, r)
+		return
+	}
+
+	q, err := ticketQuery(r)
+	if err != nil {
+		response.JSON(w, http.StatusBadRequest, map[string]string{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	if q.GroupBy != "" {
+		counts, err := h.sv.CountTicketsBy(q)
+		if err != nil {
+			writeServiceError(w, err)
+			return
+		}
+
+		response.JSON(w, http.StatusOK, map[string]any{
+			"message": "Tickets per " + q.GroupBy + ":",
+			"data":    counts,
+		})
+		return
+	}
+
+	page, err := h.sv.SearchTickets(q)
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+
+	response.JSON(w, http.StatusOK, map[string]any{
+		"message": "Tickets found",
+		"data":    page,
+	})
+}
+
 func (h *HandlerTicketDefault) GetTicketsAmountByDestinationCountry(w http.ResponseWriter, r *http.Request) {
 	country := chi.URLParam(r, "dest")
 
-	tickets, err := h.sv.GetTicketsAmountByDestinationCountry(country)
+	total, err := h.sv.GetTicketsCountByDestinationCountry(country)
 	if err != nil {
 		response.JSON(w, http.StatusNotFound, map[string]string{
 			"error": "No tickets found for the specified country: " + country,
 		})
+		return
 	}
 
 	response.JSON(w, http.StatusOK, map[string]any{
 		"message": "Total tickets:",
-		"data":    len(tickets),
+		"data":    total,
 	})
 }
 
+// GetAverageCountry returns the share (0-1) of the tickets of the country, ?precision=&rounding= round it
 func (h *HandlerTicketDefault) GetAverageCountry(w http.ResponseWriter, r *http.Request) {
 	country := chi.URLParam(r, "dest")
-	tickets, err := h.sv.GetAverageCountry(country)
+	opts, err := shareOptions(r.URL.Query(), share.Options{Precision: share.FullPrecision})
 	if err != nil {
-		response.JSON(w, http.StatusNotFound, map[string]string{
+		writeServiceError(w, err)
+		return
+	}
+	result, err := h.sv.GetShareByDestinationCountry(country, opts)
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+
+	response.JSON(w, http.StatusOK, map[string]any{
+		"message": "Average tickets for the country " + country + ":",
+		"data":    result.Ratio,
+	})
+}
+
+// GetPercentageTicketsByDestinationCountry returns the percentage (0-100) of the tickets of the country, ?precision=&rounding= round it
+func (h *HandlerTicketDefault) GetPercentageTicketsByDestinationCountry(w http.ResponseWriter, r *http.Request) {
+	country := chi.URLParam(r, "dest")
+	opts, err := shareOptions(r.URL.Query(), share.Options{Precision: share.FullPrecision})
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+	result, err := h.sv.GetShareByDestinationCountry(country, opts)
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+
+	response.JSON(w, http.StatusOK, map[string]any{
+		"message": "Percentage of tickets for the country " + country + ":",
+		"data":    result.Percentage,
+	})
+}
+
+// GetShare returns the count, the ratio and the percentage of the tickets of the country,
+// rounded with share.DefaultOptions unless ?precision=&rounding= are set
+func (h *HandlerTicketDefault) GetShare(w http.ResponseWriter, r *http.Request) {
+	country := chi.URLParam(r, "dest")
+	opts, err := shareOptions(r.URL.Query(), share.DefaultOptions)
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+	result, err := h.sv.GetShareByDestinationCountry(country, opts)
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+
+	response.JSON(w, http.StatusOK, map[string]any{
+		"message": "Share of tickets for the country " + country + ":",
+		"data":    result,
+	})
+}
+
+func (h *HandlerTicketDefault) GetTicketsAmountByPeriod(w http.ResponseWriter, r *http.Request) {
+	periods, err := h.sv.GetTicketsAmountByPeriod()
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+
+	response.JSON(w, http.StatusOK, map[string]any{
+		"message": "Total tickets per period:",
+		"data":    periods,
+	})
+}
+
+func (h *HandlerTicketDefault) GetTicketsAmountByPeriodName(w http.ResponseWriter, r *http.Request) {
+	period := chi.URLParam(r, "period")
+	total, err := h.sv.GetTicketsAmountByPeriodName(period)
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+
+	response.JSON(w, http.StatusOK, map[string]any{
+		"message": "Total tickets for the period " + period + ":",
+		"data":    total,
+	})
+}
+
+func (h *HandlerTicketDefault) GetRevenueByDestinationCountry(w http.ResponseWriter, r *http.Request) {
+	country := chi.URLParam(r, "dest")
+	revenue, err := h.sv.GetRevenueByDestinationCountry(country)
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+
+	response.JSON(w, http.StatusOK, map[string]any{
+		"message": "Revenue for the country " + country + ":",
+		"data":    revenue,
+	})
+}
+
+func (h *HandlerTicketDefault) GetAveragePriceByDestinationCountry(w http.ResponseWriter, r *http.Request) {
+	country := chi.URLParam(r, "dest")
+	average, err := h.sv.GetAveragePriceByDestinationCountry(country)
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+
+	response.JSON(w, http.StatusOK, map[string]any{
+		"message": "Average price for the country " + country + ":",
+		"data":    average,
+	})
+}
+
+func (h *HandlerTicketDefault) GetCountryStats(w http.ResponseWriter, r *http.Request) {
+	country := chi.URLParam(r, "dest")
+	stats, err := h.sv.GetCountryStats(country)
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+
+	response.JSON(w, http.StatusOK, map[string]any{
+		"message": "Stats for the country " + country + ":",
+		"data":    stats,
+	})
+}
+
+func (h *HandlerTicketDefault) GetBreakdown(w http.ResponseWriter, r *http.Request) {
+	breakdown, err := h.sv.GetBreakdown()
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+
+	response.JSON(w, http.StatusOK, map[string]any{
+		"message": "Stats per country:",
+		"data":    breakdown,
+	})
+}
+
+// GetPriceStats returns the price distribution of the ?country= tickets, or of all the
+// tickets and of every country when there is no country. ?percentiles=50,90 and ?buckets=
+// configure the percentiles and the histogram.
+func (h *HandlerTicketDefault) GetPriceStats(w http.ResponseWriter, r *http.Request) {
+	var opts internal.PriceStatsOptions
+	query := r.URL.Query()
+	if value := query.Get("percentiles"); value != "" {
+		for _, item := range strings.Split(value, ",") {
+			p, err := strconv.ParseFloat(strings.TrimSpace(item), 64)
+			if err != nil {
+				response.JSON(w, http.StatusBadRequest, map[string]string{
+					"error": "Invalid percentile: " + item,
+				})
+				return
+			}
+			opts.Percentiles = append(opts.Percentiles, p)
+		}
+	}
+	if value := query.Get("buckets"); value != "" {
+		buckets, err := strconv.Atoi(value)
+		if err != nil || buckets < 1 {
+			response.JSON(w, http.StatusBadRequest, map[string]string{
+				"error": "Invalid buckets: " + value,
+			})
+			return
+		}
+		opts.Buckets = buckets
+	}
+
+	country := query.Get("country")
+	if country == "" {
+		report, err := h.sv.GetPriceReport(opts)
+		if err != nil {
+			writeServiceError(w, err)
+			return
+		}
+
+		response.JSON(w, http.StatusOK, map[string]any{
+			"message": "Price stats:",
+			"data":    report,
+		})
+		return
+	}
+
+	stats, err := h.sv.GetPriceStatsByDestinationCountry(country, opts)
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+
+	response.JSON(w, http.StatusOK, map[string]any{
+		"message": "Price stats for the country " + country + ":",
+		"data":    stats,
+	})
+}
+
+// CreateTicket adds a new ticket from the json body
+func (h *HandlerTicketDefault) CreateTicket(w http.ResponseWriter, r *http.Request) {
+	var ticket internal.Ticket
+	if err := request.JSON(r, &ticket.Attributes); err != nil {
+		response.JSON(w, http.StatusBadRequest, map[string]string{
 			"error": err.Error(),
 		})
 		return
 	}
 
+	if err := h.sv.CreateTicket(&ticket); err != nil {
+		writeServiceError(w, err)
+		return
+	}
+
+	response.JSON(w, http.StatusCreated, map[string]any{
+		"message": "Ticket created",
+		"data":    ticket,
+	})
+}
+
+// GetTicketById returns the ticket of the id in the url
+func (h *HandlerTicketDefault) GetTicketById(w http.ResponseWriter, r *http.Request) {
+	id, ok := ticketId(w, r)
+	if !ok {
+		return
+	}
+
+	ticket, err := h.sv.GetTicketById(id)
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+
 	response.JSON(w, http.StatusOK, map[string]any{
-		"message": "Average tickets for the country " + country + ":",
-		"data":    tickets,
+		"message": "Ticket found",
+		"data":    ticket,
+	})
+}
+
+// UpdateTicket replaces all the attributes of the ticket
+func (h *HandlerTicketDefault) UpdateTicket(w http.ResponseWriter, r *http.Request) {
+	id, ok := ticketId(w, r)
+	if !ok {
+		return
+	}
+
+	ticket := internal.Ticket{Id: id}
+	if err := request.JSON(r, &ticket.Attributes); err != nil {
+		response.JSON(w, http.StatusBadRequest, map[string]string{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	if err := h.sv.UpdateTicket(&ticket); err != nil {
+		writeServiceError(w, err)
+		return
+	}
+
+	response.JSON(w, http.StatusOK, map[string]any{
+		"message": "Ticket updated",
+		"data":    ticket,
 	})
+}
+
+// PatchTicket changes only the attributes present in the json body
+func (h *HandlerTicketDefault) PatchTicket(w http.ResponseWriter, r *http.Request) {
+	id, ok := ticketId(w, r)
+	if !ok {
+		return
+	}
+
+	body, err := io.ReadAll(r.Body)
+	if err != nil || !json.Valid(body) {
+		response.JSON(w, http.StatusBadRequest, map[string]string{
+			"error": request.ErrRequestJSONInvalid.Error(),
+		})
+		return
+	}
+
+	// decoding over the current attributes keeps the fields missing in the body
+	ticket, err := h.sv.PatchTicket(id, func(t *internal.TicketAttributes) error {
+		if err := json.Unmarshal(body, t); err != nil {
+			return errors.Join(internal.ErrInvalidTicket, err)
+		}
+		return nil
+	})
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+
+	response.JSON(w, http.StatusOK, map[string]any{
+		"message": "Ticket updated",
+		"data":    ticket,
+	})
+}
 
+// DeleteTicket removes the ticket of the id in the url
+func (h *HandlerTicketDefault) DeleteTicket(w http.ResponseWriter, r *http.Request) {
+	id, ok := ticketId(w, r)
+	if !ok {
+		return
+	}
+
+	if err := h.sv.DeleteTicket(id); err != nil {
+		writeServiceError(w, err)
+		return
+	}
+
+	response.JSON(w, http.StatusNoContent, nil)
+}
+
+// ticketId reads the id from the url, answering 400 when it is not a number
+func ticketId(w http.ResponseWriter, r *http.Request) (id int, ok bool) {
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil || id <= 0 {
+		response.JSON(w, http.StatusBadRequest, map[string]string{
+			"error": "invalid id",
+		})
+		return
+	}
+
+	ok = true
+	return
+}
+
+// ticketQuery reads the filters, the pagination, the sorting and the grouping of the query string
+func ticketQuery(r *http.Request) (q internal.TicketQuery, err error) {
+	values := r.URL.Query()
+
+	for _, country := range strings.Split(values.Get("country"), ",") {
+		if country = strings.TrimSpace(country); country != "" {
+			q.Filter.Countries = append(q.Filter.Countries, country)
+		}
+	}
+	if q.Filter.HourFrom, err = queryMinute(values, "hourFrom"); err != nil {
+		return
+	}
+	if q.Filter.HourTo, err = queryMinute(values, "hourTo"); err != nil {
+		return
+	}
+	if q.Filter.PriceMin, err = queryFloat(values, "priceMin"); err != nil {
+		return
+	}
+	if q.Filter.PriceMax, err = queryFloat(values, "priceMax"); err != nil {
+		return
+	}
+	q.Filter.EmailDomain = strings.TrimPrefix(strings.TrimSpace(values.Get("email_domain")), "@")
+
+	if q.Page, err = queryInt(values, "page"); err != nil {
+		return
+	}
+	if q.PageSize, err = queryInt(values, "pageSize"); err != nil {
+		return
+	}
+	q.Sort = values.Get("sort")
+	q.GroupBy = values.Get("groupBy")
+	return
+}
+
+func queryMinute(values url.Values, key string) (minute *int, err error) {
+	value := values.Get(key)
+	if value == "" {
+		return
+	}
+	m, err := internal.MinuteOf(value)
+	if err != nil {
+		err = fmt.Errorf("invalid %s %q, use HH:MM", key, value)
+		return
+	}
+	minute = &m
+	return
+}
+
+func queryFloat(values url.Values, key string) (number *float64, err error) {
+	value := values.Get(key)
+	if value == "" {
+		return
+	}
+	n, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		err = fmt.Errorf("invalid %s %q", key, value)
+		return
+	}
+	number = &n
+	return
+}
+
+func queryInt(values url.Values, key string) (number int, err error) {
+	value := values.Get(key)
+	if value == "" {
+		return
+	}
+	if number, err = strconv.Atoi(value); err != nil {
+		err = fmt.Errorf("invalid %s %q", key, value)
+	}
+	return
+}
+
+// shareOptions reads ?precision= and ?rounding= over the defaults
+func shareOptions(values url.Values, defaults share.Options) (opts share.Options, err error) {
+	opts = defaults
+	if value := values.Get("precision"); value != "" {
+		if opts.Precision, err = strconv.Atoi(value); err != nil {
+			err = fmt.Errorf("%w: invalid precision %q", share.ErrInvalidOptions, value)
+			return
+		}
+	}
+	if value := values.Get("rounding"); value != "" {
+		opts.Rounding = value
+	}
+	err = opts.Validate()
+	return
+}
+
+// writeServiceError maps the service errors to the status code
+func writeServiceError(w http.ResponseWriter, err error) {
+	status := http.StatusInternalServerError
+	switch {
+	case errors.Is(err, internal.ErrCountryNotFound), errors.Is(err, internal.ErrTicketNotFound):
+		status = http.StatusNotFound
+	case errors.Is(err, internal.ErrInvalidPeriod), errors.Is(err, internal.ErrInvalidPriceOptions), errors.Is(err, internal.ErrInvalidQuery),
+		errors.Is(err, share.ErrInvalidOptions):
+		status = http.StatusBadRequest
+	case errors.Is(err, internal.ErrInvalidTicket):
+		status = http.StatusUnprocessableEntity
+	case errors.Is(err, internal.ErrSkippedLines):
+		status = http.StatusConflict
+	}
+
+	response.JSON(w, status, map[string]string{
+		"error": err.Error(),
+	})
 }
@@ -0,0 +1,52 @@
+package handler_test
+
+import (
+	"app/internal/handler"
+	"app/internal/loader"
+	"app/internal/repository"
+	"app/internal/service"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/izabelly/go-web/pkg/share/sharetest"
+	"github.com/stretchr/testify/require"
+)
+
+// Tests for HandlerTicketDefault.GetShare
+func TestHandlerTicketDefault_GetShare(t *testing.T) {
+	t.Run("success to return the same shares as the desafio-go-bases CLI for the same file", func(t *testing.T) {
+		// arrange
+		file := filepath.Join(t.TempDir(), "tickets.csv")
+		require.NoError(t, os.WriteFile(file, []byte(sharetest.TicketsCSV), 0644))
+		tickets, err := loader.NewLoaderTicketCSV(file).Load()
+		require.NoError(t, err)
+		hd := handler.NewHandlerTicketDefault(service.NewServiceTicketDefault(repository.NewRepositoryTicketMap(0, tickets, nil)))
+		rt := chi.NewRouter()
+		rt.Get("/ticket/share/{dest}", hd.GetShare)
+
+		for _, c := range sharetest.Cases {
+			// act
+			query := url.Values{"precision": {strconv.Itoa(c.Options.Precision)}, "rounding": {c.Options.Rounding}}
+			req := httptest.NewRequest("GET", "/ticket/share/"+url.PathEscape(c.Destination)+"?"+query.Encode(), nil)
+			res := httptest.NewRecorder()
+			rt.ServeHTTP(res, req)
+
+			// assert
+			require.Equal(t, http.StatusOK, res.Code, c.Destination)
+			var body struct {
+				Data json.RawMessage `json:"data"`
+			}
+			require.NoError(t, json.Unmarshal(res.Body.Bytes(), &body))
+			expected, err := json.Marshal(c.Expected)
+			require.NoError(t, err)
+			require.JSONEq(t, string(expected), string(body.Data), c.Destination)
+		}
+	})
+}
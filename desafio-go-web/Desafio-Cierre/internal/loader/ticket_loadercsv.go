@@ -3,26 +3,142 @@ package loader
 import (
 	"app/internal"
 	"encoding/csv"
+	"errors"
 	"fmt"
 	"io"
 	"os"
 	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/izabelly/go-web/pkg/ticketcsv"
+)
+
+// Columns of the column mapping, the header aliases of each one are the ones
+// of the ticketcsv package shared with the desafio-go-bases CLI
+const (
+	ColumnId      = ticketcsv.ColumnID
+	ColumnName    = ticketcsv.ColumnName
+	ColumnEmail   = ticketcsv.ColumnEmail
+	ColumnCountry = ticketcsv.ColumnDestination
+	ColumnHour    = ticketcsv.ColumnTime
+	ColumnPrice   = ticketcsv.ColumnPrice
+)
+
+// Header modes
+const (
+	// HeaderAuto detects the header by the content of the first line
+	HeaderAuto = ticketcsv.HeaderAuto
+	// HeaderPresent always treats the first line as the header
+	HeaderPresent = ticketcsv.HeaderPresent
+	// HeaderAbsent reads the first line as a ticket
+	HeaderAbsent = ticketcsv.HeaderAbsent
 )
 
+// Supported encodings
+const (
+	EncodingUTF8   = ticketcsv.EncodingUTF8
+	EncodingLatin1 = ticketcsv.EncodingLatin1
+)
+
+// DefaultHourFormats are the hour layouts accepted when the options have none
+var DefaultHourFormats = ticketcsv.DefaultTimeFormats
+
+// OptionsTicketCSV configures how the CSV is read, the zero value reads the
+// original layout (with or without header) in strict mode
+type OptionsTicketCSV struct {
+	// Delimiter represents the field separator, zero uses ','
+	Delimiter rune
+	// Encoding represents the file encoding, utf-8 (default, with or without BOM) or latin1
+	Encoding string
+	// Header represents the header mode, auto (default), present or absent
+	Header string
+	// Columns maps a column to its index (0 based), it takes precedence over the header
+	Columns map[string]int
+	// HourFormats represents the accepted hour layouts, empty uses DefaultHourFormats
+	HourFormats []string
+	// Lenient skips the invalid lines and reports them instead of failing the load
+	Lenient bool
+}
+
 // NewLoaderTicketCSV creates a new ticket loader from a CSV file
 func NewLoaderTicketCSV(filePath string) *LoaderTicketCSV {
-	return &LoaderTicketCSV{
+	return NewLoaderTicketCSVWithOptions(filePath, OptionsTicketCSV{})
+}
+
+// NewLoaderTicketCSVWithOptions creates a new ticket loader from a CSV file with custom options
+func NewLoaderTicketCSVWithOptions(filePath string, opts OptionsTicketCSV) *LoaderTicketCSV {
+	if len(opts.HourFormats) == 0 {
+		opts.HourFormats = DefaultHourFormats
+	}
+	if opts.Header == "" {
+		opts.Header = HeaderAuto
+	}
+	t := &LoaderTicketCSV{
 		filePath: filePath,
+		opts:     opts,
 	}
+	t.layout = t.defaultLayout()
+	return t
 }
 
 // LoaderTicketCSV represents a ticket loader from a CSV file
 type LoaderTicketCSV struct {
 	filePath string
+	opts     OptionsTicketCSV
+	// report represents the report of the last load
+	report internal.LoadReport
+	// layout represents the header and the columns found by the last load
+	layout layoutCSV
+	// mu protects version, the watcher reads it while the repository loads and writes
+	mu sync.Mutex
+	// version represents the file as last read by Load or written by the writer
+	version fileVersion
+}
+
+// fileVersion identifies a version of the file by its size and modification time
+type fileVersion struct {
+	size    int64
+	modTime time.Time
+}
+
+func versionOf(info os.FileInfo) fileVersion {
+	return fileVersion{size: info.Size(), modTime: info.ModTime()}
+}
+
+// layoutCSV represents how the tickets are laid out in the file
+type layoutCSV struct {
+	// header represents the header line as read, nil when the file has none
+	header []string
+	// columns maps each column to its index
+	columns map[string]int
+}
+
+// Report returns the report of the last load
+func (t *LoaderTicketCSV) Report() (report internal.LoadReport) {
+	report = t.report
+	return
+}
+
+// Changed reports whether the file changed since it was last read by Load or written by
+// the writer, so the changes made by the service itself do not trigger a reload
+func (t *LoaderTicketCSV) Changed() (changed bool, err error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	info, err := os.Stat(t.filePath)
+	if err != nil {
+		return
+	}
+	changed = versionOf(info) != t.version
+	return
 }
 
 // Load loads the tickets from the CSV file
 func (t *LoaderTicketCSV) Load() (ticket map[int]internal.TicketAttributes, err error) {
+	t.report = internal.LoadReport{}
+
 	// open the file
 	f, err := os.Open(t.filePath)
 	if err != nil {
@@ -31,11 +147,30 @@ func (t *LoaderTicketCSV) Load() (ticket map[int]internal.TicketAttributes, err
 	}
 	defer f.Close()
 
+	// the version read is the one opened, even if it is replaced while it is read
+	if info, e := f.Stat(); e == nil {
+		t.mu.Lock()
+		t.version = versionOf(info)
+		t.mu.Unlock()
+	}
+
 	// read the file
-	r := csv.NewReader(f)
+	decoded, err := ticketcsv.Decode(f, t.opts.Encoding)
+	if err != nil {
+		err = fmt.Errorf("error decoding file: %v", err)
+		return nil, err
+	}
+	r := csv.NewReader(decoded)
+	if t.opts.Delimiter != 0 {
+		r.Comma = t.opts.Delimiter
+	}
+	r.FieldsPerRecord = -1
+	r.TrimLeadingSpace = true
 
 	// read the records
 	tickets := make(map[int]internal.TicketAttributes)
+	var header []string
+	var columns map[string]int
 	for {
 		record, err := r.Read()
 		if err != nil {
@@ -43,34 +178,147 @@ func (t *LoaderTicketCSV) Load() (ticket map[int]internal.TicketAttributes, err
 				break
 			}
 
-			err = fmt.Errorf("error reading record: %v", err)
-			return nil, err
+			var parseErr *csv.ParseError
+			if !errors.As(err, &parseErr) {
+				err = fmt.Errorf("error reading record: %v", err)
+				return nil, err
+			}
+			if err = t.fail(parseErr.Line, parseErr.Err); err != nil {
+				return nil, err
+			}
+			continue
 		}
+		line, _ := r.FieldPos(0)
 
-		// serialize the record
-		id, err := strconv.Atoi(record[0])
-		if err != nil {
-			err = fmt.Errorf("erro convert id")
-			return nil, err
+		// the first line defines the columns
+		if columns == nil {
+			if ticketcsv.IsHeader(t.opts.Header, record) {
+				header, columns = record, ticketcsv.Mapping(record, t.opts.Columns)
+				if err = requireColumns(columns); err != nil {
+					return nil, err
+				}
+				continue
+			}
+			columns = ticketcsv.Mapping(nil, t.opts.Columns)
 		}
+		t.report.Lines++
 
-		price, err := strconv.ParseFloat(record[5], 64)
-		if err != nil {
-			err = fmt.Errorf("erro convert price")
-			return nil, err
+		// serialize the record
+		id, ticket, err := t.parse(record, columns)
+		if err == nil {
+			err = ticket.Validate()
 		}
-
-		ticket := internal.TicketAttributes{
-			Name:    record[1],
-			Email:   record[2],
-			Country: record[3],
-			Hour:    record[4],
-			Price:   price,
+		if err == nil {
+			if _, ok := tickets[id]; ok {
+				err = fmt.Errorf("duplicated id %d", id)
+			}
+		}
+		if err != nil {
+			if err = t.fail(line, err); err != nil {
+				return nil, err
+			}
+			continue
 		}
 
 		// add the ticket to the map
 		tickets[id] = ticket
+		t.report.Loaded++
 	}
 
+	// an empty file keeps the layout of the options
+	if columns != nil {
+		t.layout = layoutCSV{header: header, columns: columns}
+	}
 	return tickets, nil
 }
+
+// fail records the error of the line in lenient mode or returns it in strict mode
+func (t *LoaderTicketCSV) fail(line int, cause error) (err error) {
+	lineErr := internal.LineError{Line: line, Err: cause.Error()}
+	if !t.opts.Lenient {
+		err = fmt.Errorf("error reading record: %s", lineErr.String())
+		return
+	}
+	t.report.Errors = append(t.report.Errors, lineErr)
+	return
+}
+
+// defaultLayout is the layout of the options, used until a load finds the one of the file
+func (t *LoaderTicketCSV) defaultLayout() (layout layoutCSV) {
+	layout.columns = ticketcsv.Mapping(nil, t.opts.Columns)
+	if t.opts.Header == HeaderPresent {
+		layout.header = make([]string, ticketcsv.Width(layout.columns))
+		for name, index := range layout.columns {
+			layout.header[index] = name
+		}
+	}
+	return
+}
+
+func (t *LoaderTicketCSV) parse(record []string, columns map[string]int) (id int, ticket internal.TicketAttributes, err error) {
+	field := func(column string) string {
+		index, ok := columns[column]
+		if !ok || index >= len(record) {
+			return ""
+		}
+		return strings.TrimSpace(record[index])
+	}
+	if n := ticketcsv.Width(columns); n > len(record) {
+		err = fmt.Errorf("expected at least %d columns, found %d", n, len(record))
+		return
+	}
+
+	id, err = strconv.Atoi(field(ColumnId))
+	if err != nil {
+		err = fmt.Errorf("invalid id %q", field(ColumnId))
+		return
+	}
+
+	price, err := strconv.ParseFloat(field(ColumnPrice), 64)
+	if err != nil {
+		err = fmt.Errorf("invalid price %q", field(ColumnPrice))
+		return
+	}
+
+	hour, err := t.normalizeHour(field(ColumnHour))
+	if err != nil {
+		return
+	}
+
+	ticket = internal.TicketAttributes{
+		Name:    field(ColumnName),
+		Email:   field(ColumnEmail),
+		Country: field(ColumnCountry),
+		Hour:    hour,
+		Price:   price,
+	}
+	return
+}
+
+// normalizeHour keeps an H:MM hour as it is and converts the other formats to HH:MM
+func (t *LoaderTicketCSV) normalizeHour(value string) (hour string, err error) {
+	if _, e := time.Parse("15:04", value); e == nil {
+		hour = value
+		return
+	}
+
+	parsed, e := ticketcsv.ParseTime(value, t.opts.HourFormats)
+	if e != nil {
+		err = fmt.Errorf("invalid hour %q", value)
+		return
+	}
+	hour = parsed.Format("15:04")
+	return
+}
+
+// requireColumns checks the header has every column a valid ticket needs,
+// so a bad header fails once instead of on every line
+func requireColumns(columns map[string]int) (err error) {
+	for _, column := range []string{ColumnId, ColumnName, ColumnEmail, ColumnCountry, ColumnHour, ColumnPrice} {
+		if _, ok := columns[column]; !ok {
+			err = fmt.Errorf("column %q not found in the header", column)
+			return
+		}
+	}
+	return
+}
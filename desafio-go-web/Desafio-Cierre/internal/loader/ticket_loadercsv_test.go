@@ -0,0 +1,113 @@
+package loader_test
+
+import (
+	"app/internal"
+	"app/internal/loader"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/izabelly/go-web/pkg/country"
+	"github.com/stretchr/testify/require"
+)
+
+// Tests for LoaderTicketCSV.Load
+func TestLoaderTicketCSV_Load(t *testing.T) {
+	t.Run("success to load a file with header, other delimiter and hour formats", func(t *testing.T) {
+		// arrange
+		file := filepath.Join(t.TempDir(), "tickets.csv")
+		data := "\ufeffPrice;Country;Hour;Name;Email;Id\n" +
+			"785;Finland;5:11 pm;Tait;tmc0@scribd.com;1\n" +
+			"537;China;20:19:00;Padget;pmckee1@hexun.com;2\n"
+		require.NoError(t, os.WriteFile(file, []byte(data), 0644))
+		ld := loader.NewLoaderTicketCSVWithOptions(file, loader.OptionsTicketCSV{Delimiter: ';'})
+
+		// act
+		tickets, err := ld.Load()
+
+		// assert
+		expected := map[int]internal.TicketAttributes{
+			1: {Name: "Tait", Email: "tmc0@scribd.com", Country: "Finland", Hour: "17:11", Price: 785},
+			2: {Name: "Padget", Email: "pmckee1@hexun.com", Country: "China", Hour: "20:19", Price: 537},
+		}
+		require.NoError(t, err)
+		require.Equal(t, expected, tickets)
+		require.Equal(t, internal.LoadReport{Lines: 2, Loaded: 2}, ld.Report())
+	})
+
+	t.Run("error in strict mode on the first invalid line", func(t *testing.T) {
+		// arrange
+		file := filepath.Join(t.TempDir(), "tickets.csv")
+		data := "1,Tait,tmc0@scribd.com,Finland,17:11,785\n" +
+			"2,Padget,pmckee1@hexun.com,China,20:19,abc\n"
+		require.NoError(t, os.WriteFile(file, []byte(data), 0644))
+		ld := loader.NewLoaderTicketCSV(file)
+
+		// act
+		_, err := ld.Load()
+
+		// assert
+		require.EqualError(t, err, `error reading record: line 2: invalid price "abc"`)
+	})
+
+	t.Run("success in lenient mode reporting the skipped lines", func(t *testing.T) {
+		// arrange
+		file := filepath.Join(t.TempDir(), "tickets.csv")
+		data := "1,Tait,tmc0@scribd.com,Finland,17:11,785\n" +
+			"x,Padget,pmckee1@hexun.com,China,20:19,537\n" +
+			"3,Yalonda,yjermyn2@omniture.com,China\n" +
+			"1,Ann,ann@x.com,Brazil,9:05,100\n" +
+			"5,Bob,bob@x.com,Brazil,9:05,100\n"
+		require.NoError(t, os.WriteFile(file, []byte(data), 0644))
+		ld := loader.NewLoaderTicketCSVWithOptions(file, loader.OptionsTicketCSV{Lenient: true})
+
+		// act
+		tickets, err := ld.Load()
+
+		// assert
+		expectedReport := internal.LoadReport{
+			Lines:  5,
+			Loaded: 2,
+			Errors: []internal.LineError{
+				{Line: 2, Err: `invalid id "x"`},
+				{Line: 3, Err: "expected at least 6 columns, found 4"},
+				{Line: 4, Err: "duplicated id 1"},
+			},
+		}
+		require.NoError(t, err)
+		require.Len(t, tickets, 2)
+		require.Equal(t, expectedReport, ld.Report())
+	})
+
+	t.Run("error when the header lacks a column of the ticket, even in lenient mode", func(t *testing.T) {
+		// arrange
+		file := filepath.Join(t.TempDir(), "tickets.csv")
+		data := "id,name,country,hour,price\n" +
+			"1,Tait,Finland,17:11,785\n"
+		require.NoError(t, os.WriteFile(file, []byte(data), 0644))
+		ld := loader.NewLoaderTicketCSVWithOptions(file, loader.OptionsTicketCSV{Lenient: true})
+
+		// act
+		_, err := ld.Load()
+
+		// assert
+		require.EqualError(t, err, `column "email" not found in the header`)
+		require.Empty(t, ld.Report().Errors)
+	})
+}
+
+// Tests that every destination of the dataset is a known country
+func TestLoaderTicketCSV_Load_Dataset(t *testing.T) {
+	// arrange
+	ld := loader.NewLoaderTicketCSV("../../docs/db/tickets.csv")
+
+	// act
+	tickets, err := ld.Load()
+
+	// assert
+	require.NoError(t, err)
+	for id, ticket := range tickets {
+		_, ok := country.Lookup(ticket.Country)
+		require.True(t, ok, "ticket %d: %s", id, ticket.Country)
+	}
+}
@@ -0,0 +1,133 @@
+package loader
+
+import (
+	"app/internal"
+	"bytes"
+	"encoding/csv"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+
+	"github.com/izabelly/go-web/pkg/ticketcsv"
+)
+
+// NewWriterTicketCSV creates a new ticket writer to the CSV file of the loader
+func NewWriterTicketCSV(ld *LoaderTicketCSV) *WriterTicketCSV {
+	return &WriterTicketCSV{
+		ld: ld,
+	}
+}
+
+// WriterTicketCSV writes the tickets in the layout read by its LoaderTicketCSV: same
+// delimiter, encoding, header and column order. The repository serializes the loads
+// and the writes, so the writer can read the layout of the last load.
+type WriterTicketCSV struct {
+	ld *LoaderTicketCSV
+}
+
+// Write writes the tickets ordered by id to a temporary file and renames it over
+// the CSV, so a failure in the middle never leaves a truncated file.
+// It refuses to write while the last load skipped lines, they would be lost for good.
+func (t *WriterTicketCSV) Write(tickets map[int]internal.TicketAttributes) (err error) {
+	if skipped := len(t.ld.report.Errors); skipped > 0 {
+		err = fmt.Errorf("%w: %d skipped", internal.ErrSkippedLines, skipped)
+		return
+	}
+	filePath, opts, layout := t.ld.filePath, t.ld.opts, t.ld.layout
+
+	// create the temporary file in the same directory, rename is atomic only within a filesystem
+	f, err := os.CreateTemp(filepath.Dir(filePath), filepath.Base(filePath)+".tmp*")
+	if err != nil {
+		err = fmt.Errorf("error creating file: %v", err)
+		return
+	}
+	defer os.Remove(f.Name())
+	defer f.Close()
+
+	// keep the permissions of the current file
+	if info, e := os.Stat(filePath); e == nil {
+		f.Chmod(info.Mode().Perm())
+	} else {
+		f.Chmod(0644)
+	}
+
+	ids := make([]int, 0, len(tickets))
+	for id := range tickets {
+		ids = append(ids, id)
+	}
+	sort.Ints(ids)
+
+	// write the header and the records in UTF-8, each field at the index of its column
+	var buf bytes.Buffer
+	w := csv.NewWriter(&buf)
+	if opts.Delimiter != 0 {
+		w.Comma = opts.Delimiter
+	}
+	if layout.header != nil {
+		if err = w.Write(layout.header); err != nil {
+			err = fmt.Errorf("error writing header: %v", err)
+			return
+		}
+	}
+	n := max(len(layout.header), ticketcsv.Width(layout.columns))
+	for _, id := range ids {
+		ticket := tickets[id]
+		fields := map[string]string{
+			ColumnId:      strconv.Itoa(id),
+			ColumnName:    ticket.Name,
+			ColumnEmail:   ticket.Email,
+			ColumnCountry: ticket.Country,
+			ColumnHour:    ticket.Hour,
+			ColumnPrice:   strconv.FormatFloat(ticket.Price, 'f', -1, 64),
+		}
+		record := make([]string, n)
+		for column, index := range layout.columns {
+			record[index] = fields[column]
+		}
+		if err = w.Write(record); err != nil {
+			err = fmt.Errorf("error writing record: %v", err)
+			return
+		}
+	}
+	w.Flush()
+	if err = w.Error(); err != nil {
+		err = fmt.Errorf("error writing record: %v", err)
+		return
+	}
+
+	// convert to the encoding of the file
+	data, err := ticketcsv.Encode(buf.Bytes(), opts.Encoding)
+	if err != nil {
+		err = fmt.Errorf("error encoding file: %v", err)
+		return
+	}
+	if _, err = f.Write(data); err != nil {
+		err = fmt.Errorf("error writing file: %v", err)
+		return
+	}
+
+	// flush to disk before replacing the file
+	if err = f.Sync(); err != nil {
+		err = fmt.Errorf("error syncing file: %v", err)
+		return
+	}
+	if err = f.Close(); err != nil {
+		err = fmt.Errorf("error closing file: %v", err)
+		return
+	}
+
+	// replace the file and record its version in one step, so the watcher never
+	// sees the write of the service as a change made outside it
+	t.ld.mu.Lock()
+	defer t.ld.mu.Unlock()
+	if err = os.Rename(f.Name(), filePath); err != nil {
+		err = fmt.Errorf("error replacing file: %v", err)
+		return
+	}
+	if info, e := os.Stat(filePath); e == nil {
+		t.ld.version = versionOf(info)
+	}
+	return
+}
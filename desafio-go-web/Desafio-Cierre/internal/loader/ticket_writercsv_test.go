@@ -0,0 +1,84 @@
+package loader_test
+
+import (
+	"app/internal"
+	"app/internal/loader"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// Tests for WriterTicketCSV.Write
+func TestWriterTicketCSV_Write(t *testing.T) {
+	t.Run("success to keep the delimiter, encoding, header and column order of the file", func(t *testing.T) {
+		// arrange
+		file := filepath.Join(t.TempDir(), "tickets.csv")
+		data := "Price;Country;Hour;Name;Email;Id\n" +
+			"785;Finland;17:11;Tait;tmc0@scribd.com;1\n"
+		require.NoError(t, os.WriteFile(file, []byte(data), 0644))
+		opts := loader.OptionsTicketCSV{Delimiter: ';', Encoding: loader.EncodingLatin1}
+		ld := loader.NewLoaderTicketCSVWithOptions(file, opts)
+		tickets, err := ld.Load()
+		require.NoError(t, err)
+		tickets[2] = internal.TicketAttributes{Name: "José", Email: "jose@x.com", Country: "Perú", Hour: "9:05", Price: 100}
+
+		// act
+		err = loader.NewWriterTicketCSV(ld).Write(tickets)
+
+		// assert
+		require.NoError(t, err)
+		written, err := os.ReadFile(file)
+		require.NoError(t, err)
+		expected := "Price;Country;Hour;Name;Email;Id\n" +
+			"785;Finland;17:11;Tait;tmc0@scribd.com;1\n" +
+			"100;Per\xfa;9:05;Jos\xe9;jose@x.com;2\n"
+		require.Equal(t, expected, string(written))
+		reloaded, err := loader.NewLoaderTicketCSVWithOptions(file, opts).Load()
+		require.NoError(t, err)
+		require.Equal(t, tickets, reloaded)
+	})
+
+	t.Run("success to follow the column mapping of a file without header", func(t *testing.T) {
+		// arrange
+		file := filepath.Join(t.TempDir(), "tickets.csv")
+		require.NoError(t, os.WriteFile(file, []byte("Finland,785,1,17:11,Tait,tmc0@scribd.com\n"), 0644))
+		opts := loader.OptionsTicketCSV{
+			Header:  loader.HeaderAbsent,
+			Columns: map[string]int{loader.ColumnCountry: 0, loader.ColumnPrice: 1, loader.ColumnId: 2, loader.ColumnHour: 3, loader.ColumnName: 4, loader.ColumnEmail: 5},
+		}
+		ld := loader.NewLoaderTicketCSVWithOptions(file, opts)
+		tickets, err := ld.Load()
+		require.NoError(t, err)
+
+		// act
+		err = loader.NewWriterTicketCSV(ld).Write(tickets)
+
+		// assert
+		require.NoError(t, err)
+		written, err := os.ReadFile(file)
+		require.NoError(t, err)
+		require.Equal(t, "Finland,785,1,17:11,Tait,tmc0@scribd.com\n", string(written))
+	})
+
+	t.Run("error while the lenient load skipped lines", func(t *testing.T) {
+		// arrange
+		file := filepath.Join(t.TempDir(), "tickets.csv")
+		data := "1,Tait,tmc0@scribd.com,Finland,17:11,785\n" +
+			"2,Padget,pmckee1@hexun.com,China,20:19,abc\n"
+		require.NoError(t, os.WriteFile(file, []byte(data), 0644))
+		ld := loader.NewLoaderTicketCSVWithOptions(file, loader.OptionsTicketCSV{Lenient: true})
+		tickets, err := ld.Load()
+		require.NoError(t, err)
+
+		// act
+		err = loader.NewWriterTicketCSV(ld).Write(tickets)
+
+		// assert
+		require.ErrorIs(t, err, internal.ErrSkippedLines)
+		written, err := os.ReadFile(file)
+		require.NoError(t, err)
+		require.Equal(t, data, string(written))
+	})
+}
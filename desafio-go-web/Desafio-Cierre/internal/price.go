@@ -0,0 +1,49 @@
+package internal
+
+import "errors"
+
+// ErrInvalidPriceOptions is returned when the percentiles or the buckets of the price stats are invalid
+var ErrInvalidPriceOptions = errors.New("invalid price stats options")
+
+// DefaultPercentiles are the percentiles computed when the options have none
+var DefaultPercentiles = []float64{25, 50, 75, 90, 95, 99}
+
+// DefaultPriceBuckets is the number of histogram buckets when the options have none
+const DefaultPriceBuckets = 10
+
+// PriceStatsOptions configures the price stats
+type PriceStatsOptions struct {
+	// Percentiles represents the percentiles (0-100) to compute, empty uses DefaultPercentiles
+	Percentiles []float64
+	// Buckets represents the number of equal width histogram buckets, zero uses DefaultPriceBuckets
+	Buckets int
+}
+
+// PriceBucket represents a histogram bucket from From to To (the last one includes To)
+type PriceBucket struct {
+	From  float64 `json:"from"`
+	To    float64 `json:"to"`
+	Count int     `json:"count"`
+}
+
+// PriceStats represents the distribution of the prices of a set of tickets
+type PriceStats struct {
+	// Country represents the destination country, empty for all the tickets
+	Country string  `json:"country,omitempty"`
+	Count   int     `json:"count"`
+	Min     float64 `json:"min"`
+	Max     float64 `json:"max"`
+	Mean    float64 `json:"mean"`
+	Median  float64 `json:"median"`
+	// StdDev represents the population standard deviation
+	StdDev float64 `json:"stddev"`
+	// Percentiles maps "p90", "p99.9"... to the price
+	Percentiles map[string]float64 `json:"percentiles"`
+	Histogram   []PriceBucket      `json:"histogram"`
+}
+
+// PriceReport represents the price stats of all the tickets and of every destination country
+type PriceReport struct {
+	Global    PriceStats   `json:"global"`
+	Countries []PriceStats `json:"countries"`
+}
@@ -0,0 +1,217 @@
+package internal
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+)
+
+// ErrInvalidQuery is returned when a filter, the sorting, the pagination or the grouping of a query is invalid
+var ErrInvalidQuery = errors.New("invalid query")
+
+// Fields used to sort and to group the tickets
+const (
+	FieldId          = "id"
+	FieldName        = "name"
+	FieldEmail       = "email"
+	FieldCountry     = "country"
+	FieldHour        = "hour"
+	FieldPrice       = "price"
+	FieldEmailDomain = "email_domain"
+	FieldPeriod      = "period"
+)
+
+// Pagination limits
+const (
+	// DefaultPageSize is the page size when the query has none
+	DefaultPageSize = 50
+	// MaxPageSize is the largest page size accepted
+	MaxPageSize = 500
+)
+
+// sortFields are the fields accepted by TicketQuery.Sort
+var sortFields = []string{FieldId, FieldName, FieldEmail, FieldCountry, FieldHour, FieldPrice}
+
+// groupFields are the fields accepted by TicketQuery.GroupBy
+var groupFields = []string{FieldCountry, FieldHour, FieldPeriod, FieldPrice, FieldEmailDomain}
+
+// TicketFilter selects tickets, the zero value matches every ticket
+type TicketFilter struct {
+	// Countries represents the accepted destination countries by name, alias or code, empty accepts all
+	Countries []string
+	// HourFrom and HourTo represent the hour range in minutes since midnight, both
+	// inclusive; when HourFrom is after HourTo the range crosses midnight
+	HourFrom *int
+	HourTo   *int
+	// PriceMin and PriceMax represent the price range, both inclusive
+	PriceMin *float64
+	PriceMax *float64
+	// EmailDomain represents the domain of the email, case insensitive
+	EmailDomain string
+}
+
+// Match reports whether the ticket passes every filter
+func (f TicketFilter) Match(t TicketAttributes) bool {
+	if len(f.Countries) > 0 {
+		code := CountryCode(t.Country)
+		if !slices.ContainsFunc(f.Countries, func(c string) bool { return CountryCode(c) == code }) {
+			return false
+		}
+	}
+	if f.PriceMin != nil && t.Price < *f.PriceMin {
+		return false
+	}
+	if f.PriceMax != nil && t.Price > *f.PriceMax {
+		return false
+	}
+	if f.EmailDomain != "" && !strings.EqualFold(EmailDomainOf(t.Email), f.EmailDomain) {
+		return false
+	}
+	if f.HourFrom != nil || f.HourTo != nil {
+		minute, err := MinuteOf(t.Hour)
+		if err != nil {
+			return false
+		}
+		from, to := 0, 24*60-1
+		if f.HourFrom != nil {
+			from = *f.HourFrom
+		}
+		if f.HourTo != nil {
+			to = *f.HourTo
+		}
+		if from <= to && (minute < from || minute > to) {
+			return false
+		}
+		if from > to && minute < from && minute > to {
+			return false
+		}
+	}
+	return true
+}
+
+// TicketQuery represents a search of tickets
+type TicketQuery struct {
+	// Filter selects the tickets
+	Filter TicketFilter
+	// Sort represents the field to sort by, prefixed with - for descending order; empty sorts by id
+	Sort string
+	// Page represents the page number, starting at 1
+	Page int
+	// PageSize represents the amount of tickets per page, zero uses DefaultPageSize
+	PageSize int
+	// GroupBy returns the counts grouped by the field instead of the tickets
+	GroupBy string
+}
+
+// Validate fills the defaults and checks the sorting, the pagination and the grouping
+func (q *TicketQuery) Validate() (err error) {
+	if q.Page == 0 {
+		q.Page = 1
+	}
+	if q.PageSize == 0 {
+		q.PageSize = DefaultPageSize
+	}
+
+	switch {
+	case q.Page < 1:
+		err = fmt.Errorf("%w: page must be greater than 0", ErrInvalidQuery)
+	case q.PageSize < 1 || q.PageSize > MaxPageSize:
+		err = fmt.Errorf("%w: pageSize must be between 1 and %d", ErrInvalidQuery, MaxPageSize)
+	case q.Sort != "" && !slices.Contains(sortFields, strings.TrimPrefix(q.Sort, "-")):
+		err = fmt.Errorf("%w: sort must be one of %s", ErrInvalidQuery, strings.Join(sortFields, ", "))
+	case q.GroupBy != "" && !slices.Contains(groupFields, q.GroupBy):
+		err = fmt.Errorf("%w: groupBy must be one of %s", ErrInvalidQuery, strings.Join(groupFields, ", "))
+	case q.Filter.PriceMin != nil && q.Filter.PriceMax != nil && *q.Filter.PriceMin > *q.Filter.PriceMax:
+		err = fmt.Errorf("%w: priceMin is greater than priceMax", ErrInvalidQuery)
+	}
+	return
+}
+
+// SortTickets orders the tickets by the field of the query, ties are ordered by ascending id
+func (q TicketQuery) SortTickets(tickets []Ticket) {
+	field := strings.TrimPrefix(q.Sort, "-")
+	desc := strings.HasPrefix(q.Sort, "-")
+
+	compare := func(a, b TicketAttributes) int {
+		switch field {
+		case FieldName:
+			return strings.Compare(a.Name, b.Name)
+		case FieldEmail:
+			return strings.Compare(a.Email, b.Email)
+		case FieldCountry:
+			return strings.Compare(CountryCode(a.Country), CountryCode(b.Country))
+		case FieldHour:
+			ma, _ := MinuteOf(a.Hour)
+			mb, _ := MinuteOf(b.Hour)
+			return ma - mb
+		case FieldPrice:
+			switch {
+			case a.Price < b.Price:
+				return -1
+			case a.Price > b.Price:
+				return 1
+			}
+		}
+		return 0
+	}
+
+	sort.Slice(tickets, func(i, j int) bool {
+		c := compare(tickets[i].Attributes, tickets[j].Attributes)
+		if desc {
+			c = -c
+		}
+		if c == 0 {
+			return tickets[i].Id < tickets[j].Id
+		}
+		return c < 0
+	})
+}
+
+// GroupKey returns the value of the ticket in the grouping field of the query;
+// the hour groups by the hour of the day, the period by the periods and the price by bands of 100
+func (q TicketQuery) GroupKey(t TicketAttributes, periods PeriodOptions) (key string) {
+	switch q.GroupBy {
+	case FieldCountry:
+		key = CountryCode(t.Country)
+	case FieldHour:
+		if minute, err := MinuteOf(t.Hour); err == nil {
+			key = fmt.Sprintf("%02d", minute/60)
+		}
+	case FieldPeriod:
+		key, _ = periods.PeriodOf(t)
+	case FieldPrice:
+		band := math.Floor(t.Price/100) * 100
+		key = fmt.Sprintf("%g-%g", band, band+100)
+	case FieldEmailDomain:
+		key = strings.ToLower(EmailDomainOf(t.Email))
+	}
+	return
+}
+
+// TicketPage represents a page of the tickets of a query
+type TicketPage struct {
+	Tickets  []Ticket `json:"tickets"`
+	Total    int      `json:"total"`
+	Page     int      `json:"page"`
+	PageSize int      `json:"pageSize"`
+	Pages    int      `json:"pages"`
+}
+
+// MinuteOf returns the minutes since midnight of an hour in the H:MM format
+func MinuteOf(hour string) (minute int, err error) {
+	t, err := time.Parse("15:04", hour)
+	if err != nil {
+		return
+	}
+	minute = t.Hour()*60 + t.Minute()
+	return
+}
+
+// EmailDomainOf returns the part of the email after the @
+func EmailDomainOf(email string) string {
+	return email[strings.LastIndex(email, "@")+1:]
+}
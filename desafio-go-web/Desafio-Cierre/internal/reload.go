@@ -0,0 +1,71 @@
+package internal
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+// ErrInvalidDataset is returned when a reloaded dataset is rejected and the previous one is kept
+var ErrInvalidDataset = errors.New("invalid dataset")
+
+// ErrSkippedLines is returned when a write would drop the lines skipped by a lenient load
+var ErrSkippedLines = errors.New("the data source has skipped lines, fix them and reload before writing")
+
+// Reload outcomes
+const (
+	// ReloadStatusReloaded means the new dataset replaced the previous one
+	ReloadStatusReloaded = "reloaded"
+	// ReloadStatusFailed means the new dataset was rejected and the previous one is still in use
+	ReloadStatusFailed = "failed"
+)
+
+// LoaderTicket loads all the tickets from a data source
+type LoaderTicket interface {
+	// Load returns the tickets by id
+	Load() (t map[int]TicketAttributes, err error)
+	// Report returns the lines read and skipped by the last Load
+	Report() (report LoadReport)
+	// Changed reports whether the data source changed since it was last loaded or written
+	Changed() (changed bool, err error)
+}
+
+// LineError represents an invalid line of the data source
+type LineError struct {
+	// Line represents the line number, starting at 1
+	Line int `json:"line"`
+	// Err represents the reason the line was skipped
+	Err string `json:"error"`
+}
+
+func (e LineError) String() string {
+	return fmt.Sprintf("line %d: %s", e.Line, e.Err)
+}
+
+// LoadReport represents the outcome of a load in lenient mode
+type LoadReport struct {
+	// Lines represents the amount of data lines read, without the header
+	Lines int `json:"lines"`
+	// Loaded represents the amount of valid tickets
+	Loaded int `json:"loaded"`
+	// Errors represents the skipped lines
+	Errors []LineError `json:"errors,omitempty"`
+}
+
+// ReloadResult represents the outcome of a reload of the tickets
+type ReloadResult struct {
+	// Status represents the outcome, reloaded or failed
+	Status string `json:"status"`
+	// Tickets represents the amount of tickets in use after the reload
+	Tickets int `json:"tickets"`
+	// Previous represents the amount of tickets before the reload
+	Previous int `json:"previous"`
+	// Countries represents the amount of destination countries in use after the reload
+	Countries int `json:"countries"`
+	// Skipped represents the invalid lines skipped by a lenient loader
+	Skipped []LineError `json:"skipped,omitempty"`
+	// Error represents the reason of a failed reload
+	Error string `json:"error,omitempty"`
+	// At represents when the reload finished
+	At time.Time `json:"at"`
+}
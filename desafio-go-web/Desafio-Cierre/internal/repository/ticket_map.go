@@ -2,32 +2,83 @@ package repository
 
 import (
 	"app/internal"
+	"math"
+	"sync"
+	"time"
 )
 
+// priceBandWidth is the width of each price band of the price index
+const priceBandWidth = 100
+
 // NewRepositoryTicketMap creates a new repository for tickets in a map
-func NewRepositoryTicketMap(lastId int, db map[int]internal.TicketAttributes) *RepositoryTicketMap {
-	defaultDb := make(map[int]internal.TicketAttributes)
-	if db != nil {
-		defaultDb = db
+// - wr persists the tickets after each change, nil keeps them only in memory
+func NewRepositoryTicketMap(lastId int, db map[int]internal.TicketAttributes, wr internal.WriterTicket) *RepositoryTicketMap {
+	return NewRepositoryTicketMapWithPeriods(lastId, db, wr, internal.PeriodOptions{})
+}
+
+// NewRepositoryTicketMapWithPeriods creates a new repository for tickets in a map
+// that counts the periods of the day with periods
+// - the day of the hours is fixed when periods.Date is zero, so a ticket never moves
+// to another period between its indexing and its removal
+func NewRepositoryTicketMapWithPeriods(lastId int, db map[int]internal.TicketAttributes, wr internal.WriterTicket, periods internal.PeriodOptions) *RepositoryTicketMap {
+	if periods.Date.IsZero() {
+		periods.Date = time.Now()
+	}
+	r := &RepositoryTicketMap{
+		db:          make(map[int]internal.TicketAttributes, len(db)),
+		lastId:      lastId,
+		wr:          wr,
+		periods:     periods,
+		byCountry:   make(map[string]map[int]struct{}),
+		byPeriod:    make(map[string]map[int]struct{}),
+		byPriceBand: make(map[int]map[int]struct{}),
+		aggregates:  make(map[string]*internal.TicketAggregate),
 	}
-	return &RepositoryTicketMap{
-		lastId: lastId,
-		db:     defaultDb,
+	for id, t := range db {
+		r.put(id, t)
+		// the next id must not collide with the loaded tickets
+		if id > r.lastId {
+			r.lastId = id
+		}
 	}
+	return r
 }
 
-// RepositoryTicketMap implements the repository interface for tickets in a map
+// RepositoryTicketMap implements the repository interface for tickets in a map.
+// Besides the tickets it keeps secondary indexes by country, period of the day and
+// price band, and the totals per country, all updated on every write.
 type RepositoryTicketMap struct {
+	// mu protects the tickets, the indexes and lastId
+	mu sync.RWMutex
+	// wmu serializes the writes and the replaces, a replace holds it while it loads
+	// the new tickets so that no write lands in the dataset it is about to discard
+	wmu sync.Mutex
 	// db represents the database in a map
 	// - key: id of the ticket
 	// - value: ticket
 	db map[int]internal.TicketAttributes
 	// lastId represents the last id of the ticket
 	lastId int
+	// wr represents the writer that persists the tickets
+	wr internal.WriterTicket
+	// periods represents the options the tickets are counted in the periods of the day with
+	periods internal.PeriodOptions
+
+	// byCountry represents the ids of the tickets of each country code
+	byCountry map[string]map[int]struct{}
+	// byPeriod represents the ids of the tickets of each period of the day
+	byPeriod map[string]map[int]struct{}
+	// byPriceBand represents the ids of the tickets of each price band (price / priceBandWidth)
+	byPriceBand map[int]map[int]struct{}
+	// aggregates represents the totals of each country code
+	aggregates map[string]*internal.TicketAggregate
 }
 
 // GetAll returns all the tickets
 func (r *RepositoryTicketMap) Get() (t map[int]internal.TicketAttributes, err error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
 	// create a copy of the map
 	t = make(map[int]internal.TicketAttributes, len(r.db))
 	for k, v := range r.db {
@@ -37,15 +88,339 @@ func (r *RepositoryTicketMap) Get() (t map[int]internal.TicketAttributes, err er
 	return
 }
 
-// GetTicketsByDestinationCountry returns the tickets filtered by destination country
+// GetTicketsByDestinationCountry returns the tickets filtered by destination country, by name, alias or code
 func (r *RepositoryTicketMap) GetTicketsByDestinationCountry(country string) (t map[int]internal.TicketAttributes, err error) {
-	// create a copy of the map
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	t = r.lookup(r.byCountry[internal.CountryCode(country)])
+	return
+}
+
+// GetTicketsByPeriod returns the tickets of a period of the day
+func (r *RepositoryTicketMap) GetTicketsByPeriod(period string) (t map[int]internal.TicketAttributes, err error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	t = r.lookup(r.byPeriod[period])
+	return
+}
+
+// GetPeriods returns the options the tickets are counted in the periods of the day with
+func (r *RepositoryTicketMap) GetPeriods() (p internal.PeriodOptions, err error) {
+	p = r.periods
+	return
+}
+
+// GetTicketsByPriceRange returns the tickets with min <= price <= max, reading only the bands of the range
+func (r *RepositoryTicketMap) GetTicketsByPriceRange(min, max float64) (t map[int]internal.TicketAttributes, err error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
 	t = make(map[int]internal.TicketAttributes)
-	for k, v := range r.db {
-		if v.Country == country {
-			t[k] = v
+	if min > max {
+		return
+	}
+
+	// only the bands that overlap the range are read
+	for band, ids := range r.byPriceBand {
+		if float64(band+1)*priceBandWidth <= min || float64(band)*priceBandWidth > max {
+			continue
+		}
+		for id := range ids {
+			if v := r.db[id]; v.Price >= min && v.Price <= max {
+				t[id] = v
+			}
 		}
 	}
+	return
+}
+
+// Find returns the tickets that match the filter; the countries or the price bands
+// narrow the candidates through the indexes before the filter is applied
+func (r *RepositoryTicketMap) Find(filter internal.TicketFilter) (t map[int]internal.TicketAttributes, err error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	t = make(map[int]internal.TicketAttributes)
+	match := func(id int) {
+		if v := r.db[id]; filter.Match(v) {
+			t[id] = v
+		}
+	}
+
+	switch {
+	case len(filter.Countries) > 0:
+		for _, country := range filter.Countries {
+			for id := range r.byCountry[internal.CountryCode(country)] {
+				match(id)
+			}
+		}
+	case filter.PriceMin != nil || filter.PriceMax != nil:
+		for band, ids := range r.byPriceBand {
+			if filter.PriceMin != nil && float64(band+1)*priceBandWidth <= *filter.PriceMin {
+				continue
+			}
+			if filter.PriceMax != nil && float64(band)*priceBandWidth > *filter.PriceMax {
+				continue
+			}
+			for id := range ids {
+				match(id)
+			}
+		}
+	default:
+		for id := range r.db {
+			match(id)
+		}
+	}
+	return
+}
+
+// Count returns the amount of tickets
+func (r *RepositoryTicketMap) Count() (total int, err error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	total = len(r.db)
+	return
+}
+
+// GetAggregateByDestinationCountry returns the totals of a destination country
+func (r *RepositoryTicketMap) GetAggregateByDestinationCountry(country string) (a internal.TicketAggregate, err error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	if agg, ok := r.aggregates[internal.CountryCode(country)]; ok {
+		a = copyAggregate(agg, r.periods.Names())
+	}
+	return
+}
+
+// GetAggregates returns the totals of every destination country
+func (r *RepositoryTicketMap) GetAggregates() (a map[string]internal.TicketAggregate, err error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	a = make(map[string]internal.TicketAggregate, len(r.aggregates))
+	names := r.periods.Names()
+	for country, agg := range r.aggregates {
+		a[country] = copyAggregate(agg, names)
+	}
+	return
+}
+
+// GetById returns the ticket with the id
+func (r *RepositoryTicketMap) GetById(id int) (t internal.TicketAttributes, err error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	t, ok := r.db[id]
+	if !ok {
+		err = internal.ErrTicketNotFound
+	}
+	return
+}
+
+// Save adds a new ticket with the next id
+func (r *RepositoryTicketMap) Save(t *internal.Ticket) (err error) {
+	r.wmu.Lock()
+	defer r.wmu.Unlock()
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	id := r.lastId + 1
+	r.put(id, t.Attributes)
+	if err = r.persist(); err != nil {
+		r.remove(id)
+		return
+	}
+
+	r.lastId = id
+	t.Id = id
+	return
+}
 
+// Update replaces the attributes of an existing ticket
+func (r *RepositoryTicketMap) Update(t *internal.Ticket) (err error) {
+	r.wmu.Lock()
+	defer r.wmu.Unlock()
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	before, ok := r.db[t.Id]
+	if !ok {
+		err = internal.ErrTicketNotFound
+		return
+	}
+
+	r.put(t.Id, t.Attributes)
+	if err = r.persist(); err != nil {
+		r.put(t.Id, before)
+	}
+	return
+}
+
+// Patch applies the change to a copy of the ticket and stores it when apply succeeds
+func (r *RepositoryTicketMap) Patch(id int, apply func(t *internal.TicketAttributes) error) (t internal.TicketAttributes, err error) {
+	r.wmu.Lock()
+	defer r.wmu.Unlock()
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	before, ok := r.db[id]
+	if !ok {
+		err = internal.ErrTicketNotFound
+		return
+	}
+
+	t = before
+	if err = apply(&t); err != nil {
+		return
+	}
+
+	r.put(id, t)
+	if err = r.persist(); err != nil {
+		r.put(id, before)
+	}
+	return
+}
+
+// Delete removes the ticket with the id
+func (r *RepositoryTicketMap) Delete(id int) (err error) {
+	r.wmu.Lock()
+	defer r.wmu.Unlock()
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	before, ok := r.db[id]
+	if !ok {
+		err = internal.ErrTicketNotFound
+		return
+	}
+
+	r.remove(id)
+	if err = r.persist(); err != nil {
+		r.put(id, before)
+	}
+	return
+}
+
+// Replace loads and indexes the new tickets holding only the write lock, so reads go on,
+// and then swaps them in. Nothing is swapped when load fails. The writer is not called,
+// the new tickets already come from the data source.
+func (r *RepositoryTicketMap) Replace(load func() (db map[int]internal.TicketAttributes, err error)) (err error) {
+	r.wmu.Lock()
+	defer r.wmu.Unlock()
+
+	db, err := load()
+	if err != nil {
+		return
+	}
+	next := NewRepositoryTicketMapWithPeriods(0, db, nil, r.periods)
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.db = next.db
+	r.byCountry = next.byCountry
+	r.byPeriod = next.byPeriod
+	r.byPriceBand = next.byPriceBand
+	r.aggregates = next.aggregates
+	// ids are never reused, even if the new file dropped the last ones
+	r.lastId = max(r.lastId, next.lastId)
+	return
+}
+
+// persist writes the tickets with the writer, the caller must hold the lock
+func (r *RepositoryTicketMap) persist() (err error) {
+	if r.wr == nil {
+		return
+	}
+	err = r.wr.Write(r.db)
+	return
+}
+
+// put stores the ticket and updates the indexes, the caller must hold the lock
+func (r *RepositoryTicketMap) put(id int, t internal.TicketAttributes) {
+	r.remove(id)
+	r.db[id] = t
+
+	code := internal.CountryCode(t.Country)
+	addId(r.byCountry, code, id)
+	addId(r.byPriceBand, priceBandOf(t.Price), id)
+
+	agg, ok := r.aggregates[code]
+	if !ok {
+		agg = &internal.TicketAggregate{Periods: make(map[string]int)}
+		r.aggregates[code] = agg
+	}
+	agg.Total++
+	agg.Revenue += t.Price
+
+	if period, err := r.periods.PeriodOf(t); err == nil {
+		addId(r.byPeriod, period, id)
+		agg.Periods[period]++
+	}
+}
+
+// remove deletes the ticket and its entries in the indexes, the caller must hold the lock
+func (r *RepositoryTicketMap) remove(id int) {
+	t, ok := r.db[id]
+	if !ok {
+		return
+	}
+	delete(r.db, id)
+
+	code := internal.CountryCode(t.Country)
+	removeId(r.byCountry, code, id)
+	removeId(r.byPriceBand, priceBandOf(t.Price), id)
+
+	agg := r.aggregates[code]
+	agg.Total--
+	agg.Revenue -= t.Price
+
+	if period, err := r.periods.PeriodOf(t); err == nil {
+		removeId(r.byPeriod, period, id)
+		agg.Periods[period]--
+	}
+	if agg.Total == 0 {
+		delete(r.aggregates, code)
+	}
+}
+
+// lookup copies the tickets of the ids of an index entry, the caller must hold the lock
+func (r *RepositoryTicketMap) lookup(ids map[int]struct{}) (t map[int]internal.TicketAttributes) {
+	t = make(map[int]internal.TicketAttributes, len(ids))
+	for id := range ids {
+		t[id] = r.db[id]
+	}
+	return
+}
+
+func addId[K comparable](index map[K]map[int]struct{}, key K, id int) {
+	ids, ok := index[key]
+	if !ok {
+		ids = make(map[int]struct{})
+		index[key] = ids
+	}
+	ids[id] = struct{}{}
+}
+
+func removeId[K comparable](index map[K]map[int]struct{}, key K, id int) {
+	delete(index[key], id)
+	if len(index[key]) == 0 {
+		delete(index, key)
+	}
+}
+
+func priceBandOf(price float64) int {
+	return int(math.Floor(price / priceBandWidth))
+}
+
+func copyAggregate(agg *internal.TicketAggregate, periods []string) (a internal.TicketAggregate) {
+	a = internal.TicketAggregate{Total: agg.Total, Revenue: agg.Revenue, Periods: make(map[string]int, len(periods))}
+	for _, period := range periods {
+		a.Periods[period] = agg.Periods[period]
+	}
 	return
 }
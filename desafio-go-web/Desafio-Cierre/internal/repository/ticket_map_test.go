@@ -0,0 +1,146 @@
+package repository_test
+
+import (
+	"app/internal"
+	"app/internal/repository"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+// Tests for the indexes of RepositoryTicketMap
+func TestRepositoryTicketMap_Indexes(t *testing.T) {
+	t.Run("indexes follow the writes", func(t *testing.T) {
+		// arrange
+		rp := repository.NewRepositoryTicketMap(0, map[int]internal.TicketAttributes{
+			1: {Country: "Brazil", Hour: "10:00", Price: 150},
+			2: {Country: "Brazil", Hour: "21:00", Price: 250},
+			3: {Country: "Chile", Hour: "3:15", Price: 99},
+		}, nil)
+
+		// act
+		err := rp.Update(&internal.Ticket{Id: 2, Attributes: internal.TicketAttributes{Country: "Chile", Hour: "22:00", Price: 120}})
+		require.NoError(t, err)
+		err = rp.Delete(1)
+		require.NoError(t, err)
+		err = rp.Save(&internal.Ticket{Attributes: internal.TicketAttributes{Country: "Peru", Hour: "8:00", Price: 199.5}})
+		require.NoError(t, err)
+
+		// assert
+		brazil, _ := rp.GetAggregateByDestinationCountry("Brazil")
+		require.Equal(t, 0, brazil.Total)
+
+		chile, _ := rp.GetAggregateByDestinationCountry("Chile")
+		require.Equal(t, 2, chile.Total)
+		require.Equal(t, 219.0, chile.Revenue)
+		require.Equal(t, 1, chile.Periods[internal.PeriodNoite])
+
+		byCountry, _ := rp.GetTicketsByDestinationCountry("Chile")
+		require.Len(t, byCountry, 2)
+
+		byPeriod, _ := rp.GetTicketsByPeriod(internal.PeriodManha)
+		require.Equal(t, map[int]internal.TicketAttributes{4: {Country: "Peru", Hour: "8:00", Price: 199.5}}, byPeriod)
+
+		byPrice, _ := rp.GetTicketsByPriceRange(100, 199.5)
+		require.Len(t, byPrice, 2)
+
+		total, _ := rp.Count()
+		require.Equal(t, 3, total)
+	})
+}
+
+// Tests for RepositoryTicketMap.Find
+func TestRepositoryTicketMap_Find(t *testing.T) {
+	rp := repository.NewRepositoryTicketMap(0, map[int]internal.TicketAttributes{
+		1: {Email: "ann@gmail.com", Country: "Brazil", Hour: "10:00", Price: 150},
+		2: {Email: "bob@Gmail.com", Country: "Brazil", Hour: "23:30", Price: 250},
+		3: {Email: "cy@yahoo.com", Country: "Chile", Hour: "1:15", Price: 99},
+		4: {Email: "dee@gmail.com", Country: "Peru", Hour: "14:00", Price: 500},
+	}, nil)
+	float := func(v float64) *float64 { return &v }
+	minute := func(v int) *int { return &v }
+
+	cases := []struct {
+		name     string
+		filter   internal.TicketFilter
+		expected []int
+	}{
+		{name: "no filter", filter: internal.TicketFilter{}, expected: []int{1, 2, 3, 4}},
+		{name: "countries", filter: internal.TicketFilter{Countries: []string{"Chile", "Peru"}}, expected: []int{3, 4}},
+		{name: "price range", filter: internal.TicketFilter{PriceMin: float(99), PriceMax: float(250)}, expected: []int{1, 2, 3}},
+		{name: "price min only", filter: internal.TicketFilter{PriceMin: float(251)}, expected: []int{4}},
+		{name: "email domain", filter: internal.TicketFilter{EmailDomain: "GMAIL.com"}, expected: []int{1, 2, 4}},
+		{name: "hour range", filter: internal.TicketFilter{HourFrom: minute(600), HourTo: minute(840)}, expected: []int{1, 4}},
+		{name: "hour range crossing midnight", filter: internal.TicketFilter{HourFrom: minute(1380), HourTo: minute(120)}, expected: []int{2, 3}},
+		{name: "combined", filter: internal.TicketFilter{Countries: []string{"Brazil"}, PriceMin: float(200), EmailDomain: "gmail.com"}, expected: []int{2}},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			// act
+			found, err := rp.Find(c.filter)
+
+			// assert
+			require.NoError(t, err)
+			ids := make([]int, 0, len(found))
+			for id := range found {
+				ids = append(ids, id)
+			}
+			require.ElementsMatch(t, c.expected, ids)
+		})
+	}
+}
+
+// Tests for the Replace method of RepositoryTicketMap
+func TestRepositoryTicketMap_Replace(t *testing.T) {
+	t.Run("a write during the load is applied after the swap", func(t *testing.T) {
+		// arrange
+		rp := repository.NewRepositoryTicketMap(0, map[int]internal.TicketAttributes{
+			1: {Country: "Brazil", Hour: "10:00", Price: 150},
+		}, nil)
+		saved := make(chan error)
+
+		// act
+		err := rp.Replace(func() (db map[int]internal.TicketAttributes, err error) {
+			go func() {
+				saved <- rp.Save(&internal.Ticket{Attributes: internal.TicketAttributes{Country: "Chile", Hour: "3:15", Price: 99}})
+			}()
+			// give the write the chance to land before the swap
+			time.Sleep(20 * time.Millisecond)
+			db = map[int]internal.TicketAttributes{
+				1: {Country: "Brazil", Hour: "10:00", Price: 150},
+				2: {Country: "Peru", Hour: "21:00", Price: 250},
+			}
+			return
+		})
+		require.NoError(t, err)
+		require.NoError(t, <-saved)
+
+		// assert
+		tickets, err := rp.Get()
+		require.NoError(t, err)
+		require.Len(t, tickets, 3)
+		require.Equal(t, "Chile", tickets[3].Country)
+	})
+
+	t.Run("a failed load keeps the tickets", func(t *testing.T) {
+		// arrange
+		rp := repository.NewRepositoryTicketMap(0, map[int]internal.TicketAttributes{
+			1: {Country: "Brazil", Hour: "10:00", Price: 150},
+		}, nil)
+
+		// act
+		err := rp.Replace(func() (db map[int]internal.TicketAttributes, err error) {
+			err = errors.New("invalid file")
+			return
+		})
+
+		// assert
+		require.Error(t, err)
+		total, err := rp.Count()
+		require.NoError(t, err)
+		require.Equal(t, 1, total)
+	})
+}
@@ -15,6 +15,32 @@ type RepositoryTicketMock struct {
 	FuncGet func() (t map[int]internal.TicketAttributes, err error)
 	// FuncGetTicketsByDestinationCountry
 	FuncGetTicketsByDestinationCountry func(country string) (t map[int]internal.TicketAttributes, err error)
+	// FuncGetTicketsByPeriod represents the mock for the GetTicketsByPeriod function
+	FuncGetTicketsByPeriod func(period string) (t map[int]internal.TicketAttributes, err error)
+	// FuncGetPeriods represents the mock for the GetPeriods function
+	FuncGetPeriods func() (p internal.PeriodOptions, err error)
+	// FuncGetTicketsByPriceRange represents the mock for the GetTicketsByPriceRange function
+	FuncGetTicketsByPriceRange func(min, max float64) (t map[int]internal.TicketAttributes, err error)
+	// FuncFind represents the mock for the Find function
+	FuncFind func(filter internal.TicketFilter) (t map[int]internal.TicketAttributes, err error)
+	// FuncCount represents the mock for the Count function
+	FuncCount func() (total int, err error)
+	// FuncGetAggregateByDestinationCountry represents the mock for the GetAggregateByDestinationCountry function
+	FuncGetAggregateByDestinationCountry func(country string) (a internal.TicketAggregate, err error)
+	// FuncGetAggregates represents the mock for the GetAggregates function
+	FuncGetAggregates func() (a map[string]internal.TicketAggregate, err error)
+	// FuncGetById represents the mock for the GetById function
+	FuncGetById func(id int) (t internal.TicketAttributes, err error)
+	// FuncSave represents the mock for the Save function
+	FuncSave func(t *internal.Ticket) (err error)
+	// FuncUpdate represents the mock for the Update function
+	FuncUpdate func(t *internal.Ticket) (err error)
+	// FuncPatch represents the mock for the Patch function
+	FuncPatch func(id int, apply func(t *internal.TicketAttributes) error) (t internal.TicketAttributes, err error)
+	// FuncDelete represents the mock for the Delete function
+	FuncDelete func(id int) (err error)
+	// FuncReplace represents the mock for the Replace function
+	FuncReplace func(load func() (t map[int]internal.TicketAttributes, err error)) (err error)
 
 	// Spy verifies if the methods were called
 	Spy struct {
@@ -22,6 +48,32 @@ type RepositoryTicketMock struct {
 		Get int
 		// GetTicketsByDestinationCountry represents the spy for the GetTicketsByDestinationCountry function
 		GetTicketsByDestinationCountry int
+		// GetTicketsByPeriod represents the spy for the GetTicketsByPeriod function
+		GetTicketsByPeriod int
+		// GetPeriods represents the spy for the GetPeriods function
+		GetPeriods int
+		// GetTicketsByPriceRange represents the spy for the GetTicketsByPriceRange function
+		GetTicketsByPriceRange int
+		// Find represents the spy for the Find function
+		Find int
+		// Count represents the spy for the Count function
+		Count int
+		// GetAggregateByDestinationCountry represents the spy for the GetAggregateByDestinationCountry function
+		GetAggregateByDestinationCountry int
+		// GetAggregates represents the spy for the GetAggregates function
+		GetAggregates int
+		// GetById represents the spy for the GetById function
+		GetById int
+		// Save represents the spy for the Save function
+		Save int
+		// Update represents the spy for the Update function
+		Update int
+		// Patch represents the spy for the Patch function
+		Patch int
+		// Delete represents the spy for the Delete function
+		Delete int
+		// Replace represents the spy for the Replace function
+		Replace int
 	}
 }
 
@@ -44,3 +96,133 @@ func (r *RepositoryTicketMock) GetTicketsByDestinationCountry(country string) (t
 	t, err = r.FuncGetTicketsByDestinationCountry(country)
 	return
 }
+
+// GetTicketsByPeriod returns the tickets of a period of the day
+func (r *RepositoryTicketMock) GetTicketsByPeriod(period string) (t map[int]internal.TicketAttributes, err error) {
+	// spy
+	r.Spy.GetTicketsByPeriod++
+
+	// mock
+	t, err = r.FuncGetTicketsByPeriod(period)
+	return
+}
+
+// GetPeriods returns the options the tickets are counted in the periods of the day with
+func (r *RepositoryTicketMock) GetPeriods() (p internal.PeriodOptions, err error) {
+	// spy
+	r.Spy.GetPeriods++
+
+	// mock
+	p, err = r.FuncGetPeriods()
+	return
+}
+
+// GetTicketsByPriceRange returns the tickets in the price range
+func (r *RepositoryTicketMock) GetTicketsByPriceRange(min, max float64) (t map[int]internal.TicketAttributes, err error) {
+	// spy
+	r.Spy.GetTicketsByPriceRange++
+
+	// mock
+	t, err = r.FuncGetTicketsByPriceRange(min, max)
+	return
+}
+
+// Find returns the tickets that match the filter
+func (r *RepositoryTicketMock) Find(filter internal.TicketFilter) (t map[int]internal.TicketAttributes, err error) {
+	// spy
+	r.Spy.Find++
+
+	// mock
+	t, err = r.FuncFind(filter)
+	return
+}
+
+// Count returns the amount of tickets
+func (r *RepositoryTicketMock) Count() (total int, err error) {
+	// spy
+	r.Spy.Count++
+
+	// mock
+	total, err = r.FuncCount()
+	return
+}
+
+// GetAggregateByDestinationCountry returns the totals of a destination country
+func (r *RepositoryTicketMock) GetAggregateByDestinationCountry(country string) (a internal.TicketAggregate, err error) {
+	// spy
+	r.Spy.GetAggregateByDestinationCountry++
+
+	// mock
+	a, err = r.FuncGetAggregateByDestinationCountry(country)
+	return
+}
+
+// GetAggregates returns the totals of every destination country
+func (r *RepositoryTicketMock) GetAggregates() (a map[string]internal.TicketAggregate, err error) {
+	// spy
+	r.Spy.GetAggregates++
+
+	// mock
+	a, err = r.FuncGetAggregates()
+	return
+}
+
+// GetById returns the ticket with the id
+func (r *RepositoryTicketMock) GetById(id int) (t internal.TicketAttributes, err error) {
+	// spy
+	r.Spy.GetById++
+
+	// mock
+	t, err = r.FuncGetById(id)
+	return
+}
+
+// Save adds a new ticket
+func (r *RepositoryTicketMock) Save(t *internal.Ticket) (err error) {
+	// spy
+	r.Spy.Save++
+
+	// mock
+	err = r.FuncSave(t)
+	return
+}
+
+// Update replaces an existing ticket
+func (r *RepositoryTicketMock) Update(t *internal.Ticket) (err error) {
+	// spy
+	r.Spy.Update++
+
+	// mock
+	err = r.FuncUpdate(t)
+	return
+}
+
+// Patch applies a change to an existing ticket
+func (r *RepositoryTicketMock) Patch(id int, apply func(t *internal.TicketAttributes) error) (t internal.TicketAttributes, err error) {
+	// spy
+	r.Spy.Patch++
+
+	// mock
+	t, err = r.FuncPatch(id, apply)
+	return
+}
+
+// Delete removes the ticket with the id
+func (r *RepositoryTicketMock) Delete(id int) (err error) {
+	// spy
+	r.Spy.Delete++
+
+	// mock
+	err = r.FuncDelete(id)
+	return
+}
+
+// Replace swaps all the tickets
+func (r *RepositoryTicketMock) Replace(load func() (t map[int]internal.TicketAttributes, err error)) (err error) {
+	// spy
+	r.Spy.Replace++
+
+	// mock
+	err = r.FuncReplace(load)
+	return
+}
@@ -0,0 +1,136 @@
+package service
+
+import (
+	"app/internal"
+	"context"
+	"fmt"
+	"log"
+	"sort"
+	"sync"
+	"time"
+)
+
+// ServiceReloadDefault reloads the tickets from the loader into the repository
+type ServiceReloadDefault struct {
+	// ld represents the data source of the tickets
+	ld internal.LoaderTicket
+	// rp represents the repository of the tickets
+	rp internal.RepositoryTicket
+	// mu serializes the reloads and protects last
+	mu sync.Mutex
+	// last represents the outcome of the last reload
+	last *internal.ReloadResult
+}
+
+// NewServiceReloadDefault creates a new default reload service
+func NewServiceReloadDefault(ld internal.LoaderTicket, rp internal.RepositoryTicket) *ServiceReloadDefault {
+	return &ServiceReloadDefault{
+		ld: ld,
+		rp: rp,
+	}
+}
+
+// Reload loads and validates the tickets and swaps them into the repository.
+// When the new dataset is invalid the previous one is kept and ErrInvalidDataset is returned.
+func (s *ServiceReloadDefault) Reload() (result internal.ReloadResult, err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	previous, err := s.rp.Count()
+	if err != nil {
+		return
+	}
+	result = internal.ReloadResult{Previous: previous, Tickets: previous}
+
+	// load inside Replace: the writes wait for the swap instead of being lost by it
+	var tickets map[int]internal.TicketAttributes
+	err = s.rp.Replace(func() (t map[int]internal.TicketAttributes, err error) {
+		t, err = s.ld.Load()
+		result.Skipped = s.ld.Report().Errors
+		if err == nil {
+			err = validateDataset(t)
+		}
+		tickets = t
+		return
+	})
+	if err != nil {
+		err = fmt.Errorf("%w: %v", internal.ErrInvalidDataset, err)
+		result.Status = internal.ReloadStatusFailed
+		result.Error = err.Error()
+		s.finish(&result)
+		return
+	}
+
+	result.Status = internal.ReloadStatusReloaded
+	result.Tickets = len(tickets)
+	s.finish(&result)
+	return
+}
+
+// LastReload returns the outcome of the last reload, false when there was none
+func (s *ServiceReloadDefault) LastReload() (result internal.ReloadResult, ok bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.last == nil {
+		return
+	}
+	result, ok = *s.last, true
+	return
+}
+
+// Watch reloads the tickets every time the data source is changed outside the service,
+// checking it every interval until the context is canceled
+func (s *ServiceReloadDefault) Watch(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if changed, err := s.ld.Changed(); err != nil || !changed {
+				continue
+			}
+
+			result, err := s.Reload()
+			if err != nil {
+				log.Println("reload: keeping the previous tickets:", err)
+				continue
+			}
+			log.Printf("reload: %d tickets (previous %d) in %d countries", result.Tickets, result.Previous, result.Countries)
+		}
+	}
+}
+
+// finish completes the result with the current countries and keeps it as the last one
+func (s *ServiceReloadDefault) finish(result *internal.ReloadResult) {
+	if aggregates, err := s.rp.GetAggregates(); err == nil {
+		result.Countries = len(aggregates)
+	}
+	result.At = time.Now().UTC()
+	s.last = result
+}
+
+// validateDataset rejects an empty dataset or one with an invalid ticket, reporting the lowest invalid id
+func validateDataset(tickets map[int]internal.TicketAttributes) (err error) {
+	if len(tickets) == 0 {
+		err = fmt.Errorf("no tickets found")
+		return
+	}
+
+	ids := make([]int, 0, len(tickets))
+	for id := range tickets {
+		ids = append(ids, id)
+	}
+	sort.Ints(ids)
+
+	for _, id := range ids {
+		if e := tickets[id].Validate(); e != nil {
+			err = fmt.Errorf("ticket %d: %v", id, e)
+			return
+		}
+	}
+	return
+}
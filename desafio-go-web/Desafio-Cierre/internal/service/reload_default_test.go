@@ -0,0 +1,101 @@
+package service_test
+
+import (
+	"app/internal"
+	"app/internal/loader"
+	"app/internal/repository"
+	"app/internal/service"
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+// Tests for ServiceReloadDefault.Reload
+func TestServiceReloadDefault_Reload(t *testing.T) {
+	t.Run("success to swap the tickets", func(t *testing.T) {
+		// arrange
+		// - db file
+		file := filepath.Join(t.TempDir(), "tickets.csv")
+		err := os.WriteFile(file, []byte("1,John,johndoe@gmail.com,Brazil,9:05,100\n2,Jane,janedoe@gmail.com,Chile,21:00,200\n"), 0644)
+		require.NoError(t, err)
+		// - repository
+		rp := repository.NewRepositoryTicketMap(0, map[int]internal.TicketAttributes{
+			1: {Name: "John", Email: "johndoe@gmail.com", Country: "Brazil", Hour: "9:05", Price: 100},
+		}, nil)
+		// - service
+		sv := service.NewServiceReloadDefault(loader.NewLoaderTicketCSV(file), rp)
+
+		// act
+		result, err := sv.Reload()
+
+		// assert
+		require.NoError(t, err)
+		require.Equal(t, internal.ReloadStatusReloaded, result.Status)
+		require.Equal(t, 2, result.Tickets)
+		require.Equal(t, 1, result.Previous)
+		require.Equal(t, 2, result.Countries)
+	})
+
+	t.Run("error keeps the previous tickets when the file is invalid", func(t *testing.T) {
+		// arrange
+		file := filepath.Join(t.TempDir(), "tickets.csv")
+		err := os.WriteFile(file, []byte("1,John,johndoe@gmail.com,Brazil,9:05,100\n2,Jane,not-an-email,Chile,21:00,200\n"), 0644)
+		require.NoError(t, err)
+		rp := repository.NewRepositoryTicketMap(0, map[int]internal.TicketAttributes{
+			7: {Name: "John", Email: "johndoe@gmail.com", Country: "Peru", Hour: "9:05", Price: 100},
+		}, nil)
+		sv := service.NewServiceReloadDefault(loader.NewLoaderTicketCSV(file), rp)
+
+		// act
+		result, err := sv.Reload()
+
+		// assert
+		require.ErrorIs(t, err, internal.ErrInvalidDataset)
+		require.Equal(t, internal.ReloadStatusFailed, result.Status)
+		require.Equal(t, 1, result.Tickets)
+		_, err = rp.GetById(7)
+		require.NoError(t, err)
+		last, ok := sv.LastReload()
+		require.True(t, ok)
+		require.Equal(t, result, last)
+	})
+}
+
+// Tests for ServiceReloadDefault.Watch
+func TestServiceReloadDefault_Watch(t *testing.T) {
+	t.Run("success to reload only the changes made outside the service", func(t *testing.T) {
+		// arrange
+		file := filepath.Join(t.TempDir(), "tickets.csv")
+		err := os.WriteFile(file, []byte("1,John,johndoe@gmail.com,Brazil,9:05,100\n"), 0644)
+		require.NoError(t, err)
+		ld := loader.NewLoaderTicketCSV(file)
+		tickets, err := ld.Load()
+		require.NoError(t, err)
+		rp := repository.NewRepositoryTicketMap(0, tickets, loader.NewWriterTicketCSV(ld))
+		sv := service.NewServiceReloadDefault(ld, rp)
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		go sv.Watch(ctx, 5*time.Millisecond)
+
+		// act
+		err = rp.Save(&internal.Ticket{Attributes: internal.TicketAttributes{Name: "Jane", Email: "janedoe@gmail.com", Country: "Chile", Hour: "21:00", Price: 200}})
+		require.NoError(t, err)
+		time.Sleep(50 * time.Millisecond)
+		_, reloadedAfterWrite := sv.LastReload()
+		err = os.WriteFile(file, []byte("7,Ann,ann@gmail.com,Peru,9:05,100\n"), 0644)
+		require.NoError(t, err)
+
+		// assert
+		require.False(t, reloadedAfterWrite)
+		require.Eventually(t, func() bool {
+			_, err := rp.GetById(7)
+			return err == nil
+		}, time.Second, 5*time.Millisecond)
+		total, _ := rp.Count()
+		require.Equal(t, 1, total)
+	})
+}
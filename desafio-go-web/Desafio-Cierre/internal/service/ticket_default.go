@@ -3,6 +3,13 @@ package service
 import (
 	"app/internal"
 	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/izabelly/go-web/pkg/share"
 )
 
 // ServiceTicketDefault represents the default service of the tickets
@@ -20,8 +27,8 @@ func NewServiceTicketDefault(rp internal.RepositoryTicket) *ServiceTicketDefault
 
 // GetTotalTickets returns the total number of tickets
 func (s *ServiceTicketDefault) GetTotalAmountTickets() (total int, err error) {
-	tickets, err := s.rp.Get()
-	return len(tickets), err
+	total, err = s.rp.Count()
+	return
 }
 
 func (s *ServiceTicketDefault) GetTicketsAmountByDestinationCountry(country string) (t map[int]internal.TicketAttributes, err error) {
@@ -29,24 +36,424 @@ func (s *ServiceTicketDefault) GetTicketsAmountByDestinationCountry(country stri
 	return
 }
 
+// GetTicketsCountByDestinationCountry returns the amount of tickets of the country without copying them
+func (s *ServiceTicketDefault) GetTicketsCountByDestinationCountry(country string) (total int, err error) {
+	agg, err := s.rp.GetAggregateByDestinationCountry(country)
+	total = agg.Total
+	return
+}
+
+// GetAverageCountry returns the share (0-1) of the tickets that go to the country, unrounded
 func (s *ServiceTicketDefault) GetAverageCountry(country string) (average float64, err error) {
-	total, err := s.GetTotalAmountTickets()
+	result, err := s.GetShareByDestinationCountry(country, share.Options{Precision: share.FullPrecision})
+	average = result.Ratio
+	return
+}
+
+// GetPercentageTicketsByDestinationCountry returns the percentage (0-100) of the tickets that go to the country, unrounded
+func (s *ServiceTicketDefault) GetPercentageTicketsByDestinationCountry(country string) (percentage float64, err error) {
+	result, err := s.GetShareByDestinationCountry(country, share.Options{Precision: share.FullPrecision})
+	percentage = result.Percentage
+	return
+}
+
+// GetShareByDestinationCountry returns the ratio and the percentage of the tickets that go to the country,
+// computed by the share package shared with the desafio-go-bases CLI
+func (s *ServiceTicketDefault) GetShareByDestinationCountry(country string, opts share.Options) (result share.Share, err error) {
+	if err = opts.Validate(); err != nil {
+		return
+	}
+	total, err := s.rp.Count()
+	if err != nil {
+		err = fmt.Errorf("failed to retrieve the total amount of tickets: %w", err)
+		return
+	}
+	dest, err := s.rp.GetAggregateByDestinationCountry(country)
+	if err != nil {
+		err = fmt.Errorf("failed to retrieve the tickets of the country: %w", err)
+		return
+	}
+
+	if dest.Total < 1 {
+		err = internal.ErrCountryNotFound
+		return
+	}
+
+	if result, err = share.New(dest.Total, total, opts); err != nil {
+		return
+	}
+	result.Destination = internal.CountryCode(country)
+	return
+}
+
+// GetTicketsAmountByPeriod returns the amount of tickets per period of the day configured in the repository
+func (s *ServiceTicketDefault) GetTicketsAmountByPeriod() (periods map[string]int, err error) {
+	opts, err := s.rp.GetPeriods()
+	if err != nil {
+		err = errors.New("failed to retrieve the periods")
+		return
+	}
+	aggregates, err := s.rp.GetAggregates()
+	if err != nil {
+		err = errors.New("failed to retrieve the tickets")
+		return
+	}
+
+	names := opts.Names()
+	periods = make(map[string]int, len(names))
+	for _, period := range names {
+		periods[period] = 0
+		for _, agg := range aggregates {
+			periods[period] += agg.Periods[period]
+		}
+	}
+	return
+}
+
+// GetTicketsAmountByPeriodName returns the amount of tickets of a single period of the day
+func (s *ServiceTicketDefault) GetTicketsAmountByPeriodName(period string) (total int, err error) {
+	opts, err := s.rp.GetPeriods()
+	if err != nil {
+		err = errors.New("failed to retrieve the periods")
+		return
+	}
+	if !opts.Has(period) {
+		err = fmt.Errorf("%w, use %s", internal.ErrInvalidPeriod, strings.Join(opts.Names(), ", "))
+		return
+	}
+
+	periods, err := s.GetTicketsAmountByPeriod()
+	if err != nil {
+		return
+	}
+	total = periods[period]
+	return
+}
+
+// GetRevenueByDestinationCountry returns the sum of the prices of the tickets of the country
+func (s *ServiceTicketDefault) GetRevenueByDestinationCountry(country string) (revenue float64, err error) {
+	stats, err := s.GetCountryStats(country)
+	revenue = stats.Revenue
+	return
+}
+
+// GetAveragePriceByDestinationCountry returns the average price of the tickets of the country
+func (s *ServiceTicketDefault) GetAveragePriceByDestinationCountry(country string) (average float64, err error) {
+	stats, err := s.GetCountryStats(country)
+	average = stats.AveragePrice
+	return
+}
+
+// GetCountryStats returns the amount, percentage, revenue, average price and periods of the tickets of the country
+func (s *ServiceTicketDefault) GetCountryStats(country string) (stats internal.CountryStats, err error) {
+	total, err := s.rp.Count()
+	if err != nil {
+		err = errors.New("failed to retrieve the total amount of tickets")
+		return
+	}
+	agg, err := s.rp.GetAggregateByDestinationCountry(country)
+	if err != nil {
+		err = errors.New("failed to retrieve the tickets")
+		return
+	}
+
+	if agg.Total < 1 {
+		err = internal.ErrCountryNotFound
+		return
+	}
+
+	stats = statsOf(internal.CountryCode(country), agg, total)
+	return
+}
+
+// GetBreakdown returns the stats of every destination country, ordered by country
+func (s *ServiceTicketDefault) GetBreakdown() (breakdown []internal.CountryStats, err error) {
+	total, err := s.rp.Count()
 	if err != nil {
 		err = errors.New("failed to retrieve the total amount of tickets")
 		return
 	}
-	dest, err := s.rp.GetTicketsByDestinationCountry(country)
+	aggregates, err := s.rp.GetAggregates()
 	if err != nil {
-		err = errors.New("the specified country was not found")
+		err = errors.New("failed to retrieve the tickets")
 		return
 	}
 
-	if len(dest) < 1 {
-		err = errors.New("no tickets available for the specified country")
+	breakdown = make([]internal.CountryStats, 0, len(aggregates))
+	for country, agg := range aggregates {
+		breakdown = append(breakdown, statsOf(country, agg, total))
+	}
+	sort.Slice(breakdown, func(i, j int) bool { return breakdown[i].Country < breakdown[j].Country })
+	return
+}
+
+// statsOf derives the percentage and the average price from the totals of a country code
+func statsOf(code string, agg internal.TicketAggregate, total int) (stats internal.CountryStats) {
+	stats = internal.CountryStats{
+		Country: code,
+		Name:    internal.CountryName(code),
+		Total:   agg.Total,
+		Revenue: agg.Revenue,
+		Periods: agg.Periods,
+	}
+	if total > 0 {
+		stats.Percentage = float64(agg.Total) / float64(total) * 100
+	}
+	if agg.Total > 0 {
+		stats.AveragePrice = agg.Revenue / float64(agg.Total)
+	}
+	return
+}
+
+// GetPriceStatsByDestinationCountry returns the price distribution of the tickets of the country
+func (s *ServiceTicketDefault) GetPriceStatsByDestinationCountry(country string, opts internal.PriceStatsOptions) (stats internal.PriceStats, err error) {
+	if opts, err = priceOptions(opts); err != nil {
+		return
+	}
+	tickets, err := s.rp.GetTicketsByDestinationCountry(country)
+	if err != nil {
+		err = errors.New("failed to retrieve the tickets")
+		return
+	}
+
+	if len(tickets) == 0 {
+		err = internal.ErrCountryNotFound
 		return
 	}
 
-	average = float64(len(dest)) / float64(total)
+	prices := make([]float64, 0, len(tickets))
+	for _, t := range tickets {
+		prices = append(prices, t.Price)
+	}
+	stats = priceStatsOf(prices, opts)
+	stats.Country = internal.CountryCode(country)
+	return
+}
+
+// GetPriceReport returns the price distribution of all the tickets and of every country, ordered by country
+func (s *ServiceTicketDefault) GetPriceReport(opts internal.PriceStatsOptions) (report internal.PriceReport, err error) {
+	if opts, err = priceOptions(opts); err != nil {
+		return
+	}
+	tickets, err := s.rp.Get()
+	if err != nil {
+		err = errors.New("failed to retrieve the tickets")
+		return
+	}
+
+	all := make([]float64, 0, len(tickets))
+	byCountry := make(map[string][]float64)
+	for _, t := range tickets {
+		all = append(all, t.Price)
+		code := internal.CountryCode(t.Country)
+		byCountry[code] = append(byCountry[code], t.Price)
+	}
+
+	report.Global = priceStatsOf(all, opts)
+	report.Countries = make([]internal.PriceStats, 0, len(byCountry))
+	for code, prices := range byCountry {
+		stats := priceStatsOf(prices, opts)
+		stats.Country = code
+		report.Countries = append(report.Countries, stats)
+	}
+	sort.Slice(report.Countries, func(i, j int) bool { return report.Countries[i].Country < report.Countries[j].Country })
+	return
+}
+
+// priceOptions fills the defaults and validates the percentiles and the buckets
+func priceOptions(opts internal.PriceStatsOptions) (valid internal.PriceStatsOptions, err error) {
+	valid = opts
+	if len(valid.Percentiles) == 0 {
+		valid.Percentiles = internal.DefaultPercentiles
+	}
+	if valid.Buckets == 0 {
+		valid.Buckets = internal.DefaultPriceBuckets
+	}
+
+	if valid.Buckets < 0 {
+		err = fmt.Errorf("%w: buckets must be positive", internal.ErrInvalidPriceOptions)
+		return
+	}
+	for _, p := range valid.Percentiles {
+		if p < 0 || p > 100 || math.IsNaN(p) {
+			err = fmt.Errorf("%w: percentile %v out of 0-100", internal.ErrInvalidPriceOptions, p)
+			return
+		}
+	}
+	return
+}
+
+// priceStatsOf computes the distribution of the prices, the percentiles interpolate linearly
+func priceStatsOf(prices []float64, opts internal.PriceStatsOptions) (stats internal.PriceStats) {
+	stats = internal.PriceStats{
+		Count:       len(prices),
+		Percentiles: make(map[string]float64, len(opts.Percentiles)),
+		Histogram:   []internal.PriceBucket{},
+	}
+	if len(prices) == 0 {
+		return
+	}
+
+	sorted := append([]float64(nil), prices...)
+	sort.Float64s(sorted)
+
+	sum := 0.0
+	for _, price := range sorted {
+		sum += price
+	}
+	stats.Min = sorted[0]
+	stats.Max = sorted[len(sorted)-1]
+	stats.Mean = sum / float64(len(sorted))
+	stats.Median = percentile(sorted, 50)
+
+	squares := 0.0
+	for _, price := range sorted {
+		squares += (price - stats.Mean) * (price - stats.Mean)
+	}
+	stats.StdDev = math.Sqrt(squares / float64(len(sorted)))
+
+	for _, p := range opts.Percentiles {
+		stats.Percentiles["p"+strconv.FormatFloat(p, 'f', -1, 64)] = percentile(sorted, p)
+	}
+
+	// equal width buckets between the min and the max
+	if stats.Min == stats.Max {
+		stats.Histogram = []internal.PriceBucket{{From: stats.Min, To: stats.Max, Count: len(sorted)}}
+		return
+	}
+	width := (stats.Max - stats.Min) / float64(opts.Buckets)
+	stats.Histogram = make([]internal.PriceBucket, opts.Buckets)
+	for i := range stats.Histogram {
+		stats.Histogram[i].From = stats.Min + width*float64(i)
+		stats.Histogram[i].To = stats.Min + width*float64(i+1)
+	}
+	stats.Histogram[opts.Buckets-1].To = stats.Max
+	for _, price := range sorted {
+		i := min(int((price-stats.Min)/width), opts.Buckets-1)
+		stats.Histogram[i].Count++
+	}
+	return
+}
+
+// percentile interpolates between the two closest prices of the sorted slice
+func percentile(sorted []float64, p float64) float64 {
+	rank := p / 100 * float64(len(sorted)-1)
+	lower, upper := int(math.Floor(rank)), int(math.Ceil(rank))
+	return sorted[lower] + (sorted[upper]-sorted[lower])*(rank-float64(lower))
+}
+
+// SearchTickets returns a page of the tickets that match the query
+func (s *ServiceTicketDefault) SearchTickets(q internal.TicketQuery) (page internal.TicketPage, err error) {
+	if err = q.Validate(); err != nil {
+		return
+	}
+	found, err := s.rp.Find(q.Filter)
+	if err != nil {
+		err = errors.New("failed to retrieve the tickets")
+		return
+	}
+
+	tickets := make([]internal.Ticket, 0, len(found))
+	for id, attributes := range found {
+		tickets = append(tickets, internal.Ticket{Id: id, Attributes: attributes, CountryCode: internal.CountryCode(attributes.Country)})
+	}
+	q.SortTickets(tickets)
+
+	page = internal.TicketPage{
+		Total:    len(tickets),
+		Page:     q.Page,
+		PageSize: q.PageSize,
+		Pages:    (len(tickets) + q.PageSize - 1) / q.PageSize,
+	}
+	start := min((q.Page-1)*q.PageSize, len(tickets))
+	end := min(start+q.PageSize, len(tickets))
+	page.Tickets = tickets[start:end]
+	return
+}
+
+// CountTicketsBy returns the amount of tickets that match the query grouped by q.GroupBy
+func (s *ServiceTicketDefault) CountTicketsBy(q internal.TicketQuery) (counts map[string]int, err error) {
+	if err = q.Validate(); err != nil {
+		return
+	}
+	if q.GroupBy == "" {
+		err = fmt.Errorf("%w: groupBy is required", internal.ErrInvalidQuery)
+		return
+	}
+	found, err := s.rp.Find(q.Filter)
+	if err != nil {
+		err = errors.New("failed to retrieve the tickets")
+		return
+	}
+
+	periods, err := s.rp.GetPeriods()
+	if err != nil {
+		err = errors.New("failed to retrieve the periods")
+		return
+	}
+
+	counts = make(map[string]int)
+	for _, t := range found {
+		counts[q.GroupKey(t, periods)]++
+	}
+	return
+}
+
+// GetTicketById returns the ticket with the id
+func (s *ServiceTicketDefault) GetTicketById(id int) (t internal.Ticket, err error) {
+	attributes, err := s.rp.GetById(id)
+	if err != nil {
+		return
+	}
+
+	t = internal.Ticket{Id: id, Attributes: attributes, CountryCode: internal.CountryCode(attributes.Country)}
+	return
+}
+
+// CreateTicket validates and adds a new ticket, setting its id
+func (s *ServiceTicketDefault) CreateTicket(t *internal.Ticket) (err error) {
+	if err = t.Attributes.Validate(); err != nil {
+		return
+	}
+
+	if err = s.rp.Save(t); err != nil {
+		return
+	}
+	t.CountryCode = internal.CountryCode(t.Attributes.Country)
+	return
+}
+
+// UpdateTicket validates and replaces an existing ticket
+func (s *ServiceTicketDefault) UpdateTicket(t *internal.Ticket) (err error) {
+	if err = t.Attributes.Validate(); err != nil {
+		return
+	}
+
+	if err = s.rp.Update(t); err != nil {
+		return
+	}
+	t.CountryCode = internal.CountryCode(t.Attributes.Country)
+	return
+}
+
+// PatchTicket applies the change and validates the resulting ticket before storing it
+func (s *ServiceTicketDefault) PatchTicket(id int, apply func(t *internal.TicketAttributes) error) (t internal.Ticket, err error) {
+	attributes, err := s.rp.Patch(id, func(t *internal.TicketAttributes) error {
+		if err := apply(t); err != nil {
+			return err
+		}
+		return t.Validate()
+	})
+	if err != nil {
+		return
+	}
+
+	t = internal.Ticket{Id: id, Attributes: attributes, CountryCode: internal.CountryCode(attributes.Country)}
+	return
+}
 
+// DeleteTicket removes the ticket with the id
+func (s *ServiceTicketDefault) DeleteTicket(id int) (err error) {
+	err = s.rp.Delete(id)
 	return
 }
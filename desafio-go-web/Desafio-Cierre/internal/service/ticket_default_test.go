@@ -4,8 +4,12 @@ import (
 	"app/internal"
 	"app/internal/repository"
 	"app/internal/service"
+	"errors"
 	"testing"
+	"time"
 
+	"github.com/izabelly/go-web/pkg/period"
+	"github.com/izabelly/go-web/pkg/share"
 	"github.com/stretchr/testify/require"
 )
 
@@ -16,16 +20,8 @@ func TestServiceTicketDefault_GetTotalAmountTickets(t *testing.T) {
 		// - repository: mock
 		rp := repository.NewRepositoryTicketMock()
 		// - repository: set-up
-		rp.FuncGet = func() (t map[int]internal.TicketAttributes, err error) {
-			t = map[int]internal.TicketAttributes{
-				1: {
-					Name:    "John",
-					Email:   "johndoe@gmail.com",
-					Country: "USA",
-					Hour:    "10:00",
-					Price:   100,
-				},
-			}
+		rp.FuncCount = func() (total int, err error) {
+			total = 1
 			return
 		}
 
@@ -39,5 +35,539 @@ func TestServiceTicketDefault_GetTotalAmountTickets(t *testing.T) {
 		expectedTotal := 1
 		require.NoError(t, err)
 		require.Equal(t, expectedTotal, total)
+		require.Equal(t, 0, rp.Spy.Get)
+	})
+}
+
+// Tests for ServiceTicketDefault.GetPercentageTicketsByDestinationCountry
+func TestServiceTicketDefault_GetPercentageTicketsByDestinationCountry(t *testing.T) {
+	t.Run("success to get the percentage of a country", func(t *testing.T) {
+		// arrange
+		// - repository: mock
+		rp := repository.NewRepositoryTicketMock()
+		// - repository: set-up
+		rp.FuncCount = func() (total int, err error) {
+			total = 4
+			return
+		}
+		rp.FuncGetAggregateByDestinationCountry = func(country string) (a internal.TicketAggregate, err error) {
+			a = internal.TicketAggregate{Total: 2, Revenue: 300}
+			return
+		}
+
+		// - service
+		sv := service.NewServiceTicketDefault(rp)
+
+		// act
+		percentage, err := sv.GetPercentageTicketsByDestinationCountry("Brazil")
+
+		// assert
+		require.NoError(t, err)
+		require.Equal(t, 50.0, percentage)
+	})
+
+	t.Run("error when the country has no tickets", func(t *testing.T) {
+		// arrange
+		rp := repository.NewRepositoryTicketMock()
+		rp.FuncCount = func() (total int, err error) {
+			total = 1
+			return
+		}
+		rp.FuncGetAggregateByDestinationCountry = func(country string) (a internal.TicketAggregate, err error) {
+			return
+		}
+		sv := service.NewServiceTicketDefault(rp)
+
+		// act
+		_, err := sv.GetPercentageTicketsByDestinationCountry("Brazil")
+
+		// assert
+		require.ErrorIs(t, err, internal.ErrCountryNotFound)
+	})
+}
+
+// Tests for ServiceTicketDefault.GetShareByDestinationCountry
+func TestServiceTicketDefault_GetShareByDestinationCountry(t *testing.T) {
+	t.Run("success to get the rounded ratio and percentage", func(t *testing.T) {
+		// arrange
+		rp := repository.NewRepositoryTicketMock()
+		rp.FuncCount = func() (total int, err error) {
+			total = 2000
+			return
+		}
+		rp.FuncGetAggregateByDestinationCountry = func(country string) (a internal.TicketAggregate, err error) {
+			a = internal.TicketAggregate{Total: 249}
+			return
+		}
+		sv := service.NewServiceTicketDefault(rp)
+
+		// act
+		halfUp, err1 := sv.GetShareByDestinationCountry("brasil", share.Options{Precision: 1})
+		halfEven, err2 := sv.GetShareByDestinationCountry("brasil", share.Options{Precision: 1, Rounding: share.RoundHalfEven})
+		full, err3 := sv.GetShareByDestinationCountry("brasil", share.Options{Precision: share.FullPrecision})
+
+		// assert
+		require.NoError(t, err1)
+		require.NoError(t, err2)
+		require.NoError(t, err3)
+		require.Equal(t, share.Share{Destination: "BR", Count: 249, Total: 2000, Ratio: 0.125, Percentage: 12.5}, halfUp)
+		require.Equal(t, share.Share{Destination: "BR", Count: 249, Total: 2000, Ratio: 0.124, Percentage: 12.4}, halfEven)
+		require.Equal(t, 0.1245, full.Ratio)
+		require.Equal(t, 12.45, full.Percentage)
+	})
+
+	t.Run("error when the repository fails", func(t *testing.T) {
+		// arrange
+		failure := errors.New("storage unavailable")
+		rp := repository.NewRepositoryTicketMock()
+		rp.FuncCount = func() (total int, err error) {
+			err = failure
+			return
+		}
+		sv := service.NewServiceTicketDefault(rp)
+
+		// act
+		_, err := sv.GetAverageCountry("Brazil")
+
+		// assert
+		require.ErrorIs(t, err, failure)
+	})
+
+	t.Run("error when the options are invalid", func(t *testing.T) {
+		// arrange
+		rp := repository.NewRepositoryTicketMock()
+		sv := service.NewServiceTicketDefault(rp)
+
+		// act
+		_, err1 := sv.GetShareByDestinationCountry("Brazil", share.Options{Precision: 11})
+		_, err2 := sv.GetShareByDestinationCountry("Brazil", share.Options{Rounding: "banker"})
+
+		// assert
+		require.ErrorIs(t, err1, share.ErrInvalidOptions)
+		require.ErrorIs(t, err2, share.ErrInvalidOptions)
+		require.Equal(t, 0, rp.Spy.Count)
+	})
+}
+
+// Tests for ServiceTicketDefault.GetTicketsAmountByPeriod
+func TestServiceTicketDefault_GetTicketsAmountByPeriod(t *testing.T) {
+	t.Run("success to count the tickets per period", func(t *testing.T) {
+		// arrange
+		rp := repository.NewRepositoryTicketMock()
+		rp.FuncGetPeriods = func() (p internal.PeriodOptions, err error) {
+			return
+		}
+		rp.FuncGetAggregates = func() (a map[string]internal.TicketAggregate, err error) {
+			a = map[string]internal.TicketAggregate{
+				"Brazil": {Total: 2, Periods: map[string]int{internal.PeriodMadrugada: 2}},
+				"Chile":  {Total: 1, Periods: map[string]int{internal.PeriodManha: 1}},
+				"Peru":   {Total: 2, Periods: map[string]int{internal.PeriodTarde: 1, internal.PeriodNoite: 1}},
+			}
+			return
+		}
+		sv := service.NewServiceTicketDefault(rp)
+
+		// act
+		periods, err := sv.GetTicketsAmountByPeriod()
+
+		// assert
+		expected := map[string]int{
+			internal.PeriodMadrugada: 2,
+			internal.PeriodManha:     1,
+			internal.PeriodTarde:     1,
+			internal.PeriodNoite:     1,
+		}
+		require.NoError(t, err)
+		require.Equal(t, expected, periods)
+	})
+
+	t.Run("success to count the periods in the time zone of each destination", func(t *testing.T) {
+		// arrange
+		// - 02:00 UTC is 23:00 in Sao Paulo, 11:00 in Tokyo and 21:00 in Lima, as in the desafio-go-bases CLI
+		rp := repository.NewRepositoryTicketMapWithPeriods(0, map[int]internal.TicketAttributes{
+			1: {Country: "Brasil", Hour: "2:00", Price: 100},
+			2: {Country: "Japan", Hour: "2:00", Price: 100},
+			3: {Country: "Peru", Hour: "2:00", Price: 100},
+		}, nil, internal.PeriodOptions{
+			Options: period.Options{
+				Location:     time.FixedZone("PET", -5*60*60),
+				Destinations: map[string]*time.Location{"BR": time.FixedZone("BRT", -3*60*60), "Japan": time.FixedZone("JST", 9*60*60)},
+			},
+			Date: time.Date(2024, 1, 15, 0, 0, 0, 0, time.UTC),
+		})
+		sv := service.NewServiceTicketDefault(rp)
+
+		// act
+		periods, err1 := sv.GetTicketsAmountByPeriod()
+		byPeriod, err2 := sv.CountTicketsBy(internal.TicketQuery{GroupBy: internal.FieldPeriod})
+
+		// assert
+		require.NoError(t, err1)
+		require.NoError(t, err2)
+		require.Equal(t, map[string]int{internal.PeriodMadrugada: 0, internal.PeriodManha: 1, internal.PeriodTarde: 0, internal.PeriodNoite: 2}, periods)
+		require.Equal(t, map[string]int{internal.PeriodManha: 1, internal.PeriodNoite: 2}, byPeriod)
+	})
+
+	t.Run("success to count the configured periods", func(t *testing.T) {
+		// arrange
+		custom, err := period.Parse("dia=06:00-18:00,noite=18:00-06:00")
+		require.NoError(t, err)
+		rp := repository.NewRepositoryTicketMapWithPeriods(0, map[int]internal.TicketAttributes{
+			1: {Country: "Brazil", Hour: "5:59", Price: 100},
+			2: {Country: "Brazil", Hour: "6:00", Price: 100},
+			3: {Country: "Chile", Hour: "18:00", Price: 100},
+		}, nil, internal.PeriodOptions{Options: period.Options{Periods: custom}})
+		sv := service.NewServiceTicketDefault(rp)
+
+		// act
+		periods, err1 := sv.GetTicketsAmountByPeriod()
+		night, err2 := sv.GetTicketsAmountByPeriodName("noite")
+		_, err3 := sv.GetTicketsAmountByPeriodName(internal.PeriodManha)
+
+		// assert
+		require.NoError(t, err1)
+		require.NoError(t, err2)
+		require.Equal(t, map[string]int{"dia": 1, "noite": 2}, periods)
+		require.Equal(t, 2, night)
+		require.ErrorIs(t, err3, internal.ErrInvalidPeriod)
+		require.EqualError(t, err3, "invalid period, use dia, noite")
+	})
+
+	t.Run("error when the period is unknown", func(t *testing.T) {
+		// arrange
+		rp := repository.NewRepositoryTicketMock()
+		rp.FuncGetPeriods = func() (p internal.PeriodOptions, err error) {
+			return
+		}
+		sv := service.NewServiceTicketDefault(rp)
+
+		// act
+		_, err := sv.GetTicketsAmountByPeriodName("lunch")
+
+		// assert
+		require.ErrorIs(t, err, internal.ErrInvalidPeriod)
+		require.Equal(t, 0, rp.Spy.GetAggregates)
+	})
+}
+
+// Tests for ServiceTicketDefault.GetBreakdown
+func TestServiceTicketDefault_GetBreakdown(t *testing.T) {
+	t.Run("success to get the stats of every country", func(t *testing.T) {
+		// arrange
+		// - repository: map, so the aggregates come from the indexes
+		rp := repository.NewRepositoryTicketMap(0, map[int]internal.TicketAttributes{
+			1: {Country: "Chile", Hour: "21:00", Price: 300},
+			2: {Country: "Brazil", Hour: "10:00", Price: 100},
+			3: {Country: "Brazil", Hour: "14:00", Price: 200},
+			4: {Country: "Brazil", Hour: "14:30", Price: 300},
+		}, nil)
+		sv := service.NewServiceTicketDefault(rp)
+
+		// act
+		breakdown, err := sv.GetBreakdown()
+
+		// assert
+		expected := []internal.CountryStats{
+			{
+				Country:      "BR",
+				Name:         "Brazil",
+				Total:        3,
+				Percentage:   75,
+				Revenue:      600,
+				AveragePrice: 200,
+				Periods:      map[string]int{internal.PeriodMadrugada: 0, internal.PeriodManha: 1, internal.PeriodTarde: 2, internal.PeriodNoite: 0},
+			},
+			{
+				Country:      "CL",
+				Name:         "Chile",
+				Total:        1,
+				Percentage:   25,
+				Revenue:      300,
+				AveragePrice: 300,
+				Periods:      map[string]int{internal.PeriodMadrugada: 0, internal.PeriodManha: 0, internal.PeriodTarde: 0, internal.PeriodNoite: 1},
+			},
+		}
+		require.NoError(t, err)
+		require.Equal(t, expected, breakdown)
+	})
+}
+
+// Tests for ServiceTicketDefault.GetPriceStatsByDestinationCountry and GetPriceReport
+func TestServiceTicketDefault_GetPriceStats(t *testing.T) {
+	// - repository: map
+	newRepository := func() *repository.RepositoryTicketMap {
+		return repository.NewRepositoryTicketMap(0, map[int]internal.TicketAttributes{
+			1: {Country: "Brazil", Hour: "10:00", Price: 40},
+			2: {Country: "Brazil", Hour: "10:00", Price: 10},
+			3: {Country: "Brazil", Hour: "10:00", Price: 30},
+			4: {Country: "Brazil", Hour: "10:00", Price: 20},
+			5: {Country: "Brazil", Hour: "10:00", Price: 100},
+			6: {Country: "Chile", Hour: "21:00", Price: 500},
+		}, nil)
+	}
+
+	t.Run("success to get the price stats of a country", func(t *testing.T) {
+		// arrange
+		sv := service.NewServiceTicketDefault(newRepository())
+
+		// act
+		stats, err := sv.GetPriceStatsByDestinationCountry("Brazil", internal.PriceStatsOptions{Percentiles: []float64{25, 90}, Buckets: 3})
+
+		// assert
+		require.NoError(t, err)
+		require.Equal(t, "BR", stats.Country)
+		require.Equal(t, 5, stats.Count)
+		require.Equal(t, 10.0, stats.Min)
+		require.Equal(t, 100.0, stats.Max)
+		require.Equal(t, 40.0, stats.Mean)
+		require.Equal(t, 30.0, stats.Median)
+		require.InDelta(t, 31.6228, stats.StdDev, 1e-4)
+		require.Equal(t, map[string]float64{"p25": 20, "p90": 76}, stats.Percentiles)
+		require.Equal(t, []internal.PriceBucket{
+			{From: 10, To: 40, Count: 3},
+			{From: 40, To: 70, Count: 1},
+			{From: 70, To: 100, Count: 1},
+		}, stats.Histogram)
+	})
+
+	t.Run("success to get the global and per country price stats", func(t *testing.T) {
+		// arrange
+		sv := service.NewServiceTicketDefault(newRepository())
+
+		// act
+		report, err := sv.GetPriceReport(internal.PriceStatsOptions{})
+
+		// assert
+		require.NoError(t, err)
+		require.Equal(t, 6, report.Global.Count)
+		require.Equal(t, 500.0, report.Global.Max)
+		require.Len(t, report.Global.Percentiles, len(internal.DefaultPercentiles))
+		require.Len(t, report.Global.Histogram, internal.DefaultPriceBuckets)
+		require.Len(t, report.Countries, 2)
+		require.Equal(t, "BR", report.Countries[0].Country)
+		require.Equal(t, []internal.PriceBucket{{From: 500, To: 500, Count: 1}}, report.Countries[1].Histogram)
+	})
+
+	t.Run("error when the country has no tickets", func(t *testing.T) {
+		// arrange
+		sv := service.NewServiceTicketDefault(newRepository())
+
+		// act
+		_, err := sv.GetPriceStatsByDestinationCountry("Peru", internal.PriceStatsOptions{})
+
+		// assert
+		require.ErrorIs(t, err, internal.ErrCountryNotFound)
+	})
+
+	t.Run("error when a percentile is out of range", func(t *testing.T) {
+		// arrange
+		sv := service.NewServiceTicketDefault(newRepository())
+
+		// act
+		_, err := sv.GetPriceReport(internal.PriceStatsOptions{Percentiles: []float64{101}})
+
+		// assert
+		require.ErrorIs(t, err, internal.ErrInvalidPriceOptions)
+	})
+}
+
+// Tests for ServiceTicketDefault.SearchTickets and CountTicketsBy
+func TestServiceTicketDefault_SearchTickets(t *testing.T) {
+	// - repository: map
+	rp := repository.NewRepositoryTicketMap(0, map[int]internal.TicketAttributes{
+		1: {Email: "a@gmail.com", Country: "Brazil", Hour: "10:00", Price: 300},
+		2: {Email: "b@gmail.com", Country: "Brazil", Hour: "21:00", Price: 100},
+		3: {Email: "c@yahoo.com", Country: "Chile", Hour: "14:00", Price: 200},
+		4: {Email: "d@gmail.com", Country: "Peru", Hour: "14:30", Price: 100},
+		5: {Email: "e@gmail.com", Country: "Brazil", Hour: "3:00", Price: 150},
+	}, nil)
+	sv := service.NewServiceTicketDefault(rp)
+	ids := func(page internal.TicketPage) (ids []int) {
+		for _, t := range page.Tickets {
+			ids = append(ids, t.Id)
+		}
+		return
+	}
+
+	t.Run("success to sort and paginate the matching tickets", func(t *testing.T) {
+		// act
+		page, err := sv.SearchTickets(internal.TicketQuery{
+			Filter:   internal.TicketFilter{Countries: []string{"Brazil", "Peru"}},
+			Sort:     "-price",
+			Page:     2,
+			PageSize: 3,
+		})
+
+		// assert
+		require.NoError(t, err)
+		require.Equal(t, []int{4}, ids(page))
+		require.Equal(t, 4, page.Total)
+		require.Equal(t, 2, page.Pages)
+	})
+
+	t.Run("success to sort by id by default and ties by id", func(t *testing.T) {
+		// act
+		byId, err := sv.SearchTickets(internal.TicketQuery{})
+		require.NoError(t, err)
+		byPrice, err := sv.SearchTickets(internal.TicketQuery{Sort: "price"})
+		require.NoError(t, err)
+
+		// assert
+		require.Equal(t, []int{1, 2, 3, 4, 5}, ids(byId))
+		require.Equal(t, []int{2, 4, 5, 3, 1}, ids(byPrice))
+	})
+
+	t.Run("success to return an empty page past the last one", func(t *testing.T) {
+		// act
+		page, err := sv.SearchTickets(internal.TicketQuery{Page: 10})
+
+		// assert
+		require.NoError(t, err)
+		require.Empty(t, page.Tickets)
+		require.Equal(t, 5, page.Total)
+	})
+
+	t.Run("success to count the tickets grouped by a field", func(t *testing.T) {
+		// act
+		byDomain, err := sv.CountTicketsBy(internal.TicketQuery{Filter: internal.TicketFilter{Countries: []string{"Brazil"}}, GroupBy: internal.FieldEmailDomain})
+		require.NoError(t, err)
+		byPeriod, err := sv.CountTicketsBy(internal.TicketQuery{GroupBy: internal.FieldPeriod})
+		require.NoError(t, err)
+		byPrice, err := sv.CountTicketsBy(internal.TicketQuery{GroupBy: internal.FieldPrice})
+		require.NoError(t, err)
+
+		// assert
+		require.Equal(t, map[string]int{"gmail.com": 3}, byDomain)
+		require.Equal(t, map[string]int{internal.PeriodMadrugada: 1, internal.PeriodManha: 1, internal.PeriodTarde: 2, internal.PeriodNoite: 1}, byPeriod)
+		require.Equal(t, map[string]int{"100-200": 3, "200-300": 1, "300-400": 1}, byPrice)
+	})
+
+	t.Run("error when the query is invalid", func(t *testing.T) {
+		// act
+		_, errSort := sv.SearchTickets(internal.TicketQuery{Sort: "passenger"})
+		_, errSize := sv.SearchTickets(internal.TicketQuery{PageSize: internal.MaxPageSize + 1})
+		_, errGroup := sv.CountTicketsBy(internal.TicketQuery{})
+
+		// assert
+		require.ErrorIs(t, errSort, internal.ErrInvalidQuery)
+		require.ErrorIs(t, errSize, internal.ErrInvalidQuery)
+		require.ErrorIs(t, errGroup, internal.ErrInvalidQuery)
+	})
+}
+
+// Tests for the matching of the countries by name, alias or code
+func TestServiceTicketDefault_CountryNormalization(t *testing.T) {
+	// arrange
+	// - repository: map, with the same country written in different ways
+	rp := repository.NewRepositoryTicketMap(0, map[int]internal.TicketAttributes{
+		1: {Country: "Brazil", Hour: "10:00", Price: 100},
+		2: {Country: "brazil", Hour: "10:00", Price: 100},
+		3: {Country: "Brasil ", Hour: "10:00", Price: 100},
+		4: {Country: "Perú", Hour: "10:00", Price: 100},
+	}, nil)
+	sv := service.NewServiceTicketDefault(rp)
+
+	t.Run("success to count a country by name, alias or code", func(t *testing.T) {
+		for _, name := range []string{"Brazil", "BRASIL", "br", "BRA", " brazil "} {
+			// act
+			total, err := sv.GetTicketsCountByDestinationCountry(name)
+
+			// assert
+			require.NoError(t, err)
+			require.Equal(t, 3, total, name)
+		}
+
+		average, err := sv.GetAverageCountry("peru")
+		require.NoError(t, err)
+		require.Equal(t, 0.25, average)
+	})
+
+	t.Run("success to return the canonical codes", func(t *testing.T) {
+		// act
+		breakdown, err := sv.GetBreakdown()
+		require.NoError(t, err)
+		page, err := sv.SearchTickets(internal.TicketQuery{Filter: internal.TicketFilter{Countries: []string{"PER"}}})
+		require.NoError(t, err)
+
+		// assert
+		require.Len(t, breakdown, 2)
+		require.Equal(t, "BR", breakdown[0].Country)
+		require.Equal(t, 3, breakdown[0].Total)
+		require.Equal(t, "PE", breakdown[1].Country)
+		require.Equal(t, "Peru", breakdown[1].Name)
+		require.Len(t, page.Tickets, 1)
+		require.Equal(t, "PE", page.Tickets[0].CountryCode)
+		require.Equal(t, "Perú", page.Tickets[0].Attributes.Country)
+	})
+}
+
+// Tests for ServiceTicketDefault.CreateTicket
+func TestServiceTicketDefault_CreateTicket(t *testing.T) {
+	t.Run("success to create a ticket", func(t *testing.T) {
+		// arrange
+		rp := repository.NewRepositoryTicketMock()
+		rp.FuncSave = func(t *internal.Ticket) (err error) {
+			t.Id = 1000
+			return
+		}
+		sv := service.NewServiceTicketDefault(rp)
+
+		// act
+		ticket := internal.Ticket{Attributes: internal.TicketAttributes{
+			Name:    "John",
+			Email:   "johndoe@gmail.com",
+			Country: "Brazil",
+			Hour:    "9:05",
+			Price:   100,
+		}}
+		err := sv.CreateTicket(&ticket)
+
+		// assert
+		require.NoError(t, err)
+		require.Equal(t, 1000, ticket.Id)
+		require.Equal(t, 1, rp.Spy.Save)
+	})
+
+	t.Run("error when the fields are invalid", func(t *testing.T) {
+		// arrange
+		rp := repository.NewRepositoryTicketMock()
+		sv := service.NewServiceTicketDefault(rp)
+
+		cases := map[string]internal.TicketAttributes{
+			"email": {Name: "John", Email: "johndoe", Country: "Brazil", Hour: "9:05", Price: 100},
+			"hour":  {Name: "John", Email: "johndoe@gmail.com", Country: "Brazil", Hour: "25:00", Price: 100},
+			"price": {Name: "John", Email: "johndoe@gmail.com", Country: "Brazil", Hour: "9:05", Price: 0},
+		}
+		for name, attributes := range cases {
+			// act
+			ticket := internal.Ticket{Attributes: attributes}
+			err := sv.CreateTicket(&ticket)
+
+			// assert
+			require.ErrorIs(t, err, internal.ErrInvalidTicket, name)
+		}
+		require.Equal(t, 0, rp.Spy.Save)
+	})
+}
+
+// Tests for ServiceTicketDefault.PatchTicket
+func TestServiceTicketDefault_PatchTicket(t *testing.T) {
+	t.Run("error when the patched ticket is invalid", func(t *testing.T) {
+		// arrange
+		rp := repository.NewRepositoryTicketMap(0, map[int]internal.TicketAttributes{
+			1: {Name: "John", Email: "johndoe@gmail.com", Country: "Brazil", Hour: "9:05", Price: 100},
+		}, nil)
+		sv := service.NewServiceTicketDefault(rp)
+
+		// act
+		_, err := sv.PatchTicket(1, func(t *internal.TicketAttributes) error {
+			t.Hour = "9h05"
+			return nil
+		})
+
+		// assert
+		stored, _ := rp.GetById(1)
+		require.ErrorIs(t, err, internal.ErrInvalidTicket)
+		require.Equal(t, "9:05", stored.Hour)
 	})
 }
@@ -1,5 +1,85 @@
 package internal
 
+import (
+	"errors"
+	"fmt"
+	"net/mail"
+	"strings"
+	"time"
+
+	"github.com/izabelly/go-web/pkg/country"
+	"github.com/izabelly/go-web/pkg/period"
+	"github.com/izabelly/go-web/pkg/share"
+)
+
+var (
+	// ErrCountryNotFound is returned when there are no tickets for the destination country
+	ErrCountryNotFound = errors.New("no tickets available for the specified country")
+	// ErrInvalidPeriod is returned when the period of the day is unknown
+	ErrInvalidPeriod = errors.New("invalid period")
+	// ErrTicketNotFound is returned when there is no ticket with the id
+	ErrTicketNotFound = errors.New("ticket not found")
+	// ErrInvalidTicket is returned when a field of the ticket is invalid
+	ErrInvalidTicket = errors.New("invalid ticket")
+)
+
+// Periods of the day of the default ranges, the same used in desafio-go-bases
+const (
+	// PeriodMadrugada represents the tickets from 00:00 to 06:59
+	PeriodMadrugada = period.Madrugada
+	// PeriodManha represents the tickets from 07:00 to 12:59
+	PeriodManha = period.Manha
+	// PeriodTarde represents the tickets from 13:00 to 19:59
+	PeriodTarde = period.Tarde
+	// PeriodNoite represents the tickets from 20:00 to 23:59
+	PeriodNoite = period.Noite
+)
+
+// PeriodOptions represents how the hour of a ticket is counted in a period of the day,
+// the same options as the --periods, --tz, --dest-tz, --source-tz and --date flags of the
+// desafio-go-bases CLI. The zero value uses the default ranges on the clock of the file.
+type PeriodOptions struct {
+	// Options represents the ranges of the day and the time zones they are counted in
+	period.Options
+	// Source represents the time zone the hours of the file were recorded in, nil is UTC
+	Source *time.Location
+	// Date represents the day of the hours, used to convert between time zones; zero is today
+	Date time.Time
+}
+
+// PeriodOf returns the period of the day of the ticket, converting its hour (H:MM)
+// to the time zone of the destination
+func (o PeriodOptions) PeriodOf(t TicketAttributes) (name string, err error) {
+	minute, err := MinuteOf(t.Hour)
+	if err != nil {
+		return
+	}
+
+	source := o.Source
+	if source == nil {
+		source = time.UTC
+	}
+	date := o.Date
+	if date.IsZero() {
+		date = time.Now()
+	}
+	date = date.In(source)
+	at := time.Date(date.Year(), date.Month(), date.Day(), minute/60, minute%60, 0, 0, source)
+	name = o.Of(t.Country, at)
+	return
+}
+
+// CountryCode returns the ISO 3166-1 alpha-2 code of a country name, alias or code,
+// ignoring case and accents; an unknown country is returned trimmed
+func CountryCode(name string) string {
+	return country.Normalize(name)
+}
+
+// CountryName returns the name of a country from its code, name or alias
+func CountryName(code string) string {
+	return country.Name(code)
+}
+
 // TicketAttributes is an struct that represents a ticket
 type TicketAttributes struct {
 	// Name represents the name of the owner of the ticket
@@ -14,12 +94,69 @@ type TicketAttributes struct {
 	Price float64 `json:"price"`
 }
 
+// Validate checks the email, the country, the H:MM hour and the price of the ticket
+func (t TicketAttributes) Validate() (err error) {
+	switch {
+	case strings.TrimSpace(t.Name) == "":
+		err = fmt.Errorf("%w: name is required", ErrInvalidTicket)
+	case !validEmail(t.Email):
+		err = fmt.Errorf("%w: email %q is not valid", ErrInvalidTicket, t.Email)
+	case strings.TrimSpace(t.Country) == "":
+		err = fmt.Errorf("%w: country is required", ErrInvalidTicket)
+	case !validHour(t.Hour):
+		err = fmt.Errorf("%w: hour %q must be in the HH:MM format", ErrInvalidTicket, t.Hour)
+	case t.Price <= 0:
+		err = fmt.Errorf("%w: price must be greater than zero", ErrInvalidTicket)
+	}
+	return
+}
+
+func validEmail(email string) bool {
+	addr, err := mail.ParseAddress(email)
+	return err == nil && addr.Address == email
+}
+
+func validHour(hour string) bool {
+	_, err := time.Parse("15:04", hour)
+	return err == nil
+}
+
 // Ticket represents a ticket
 type Ticket struct {
 	// Id represents the id of the ticket
 	Id int `json:"id"`
 	// Attributes represents the attributes of the ticket
 	Attributes TicketAttributes `json:"attributes"`
+	// CountryCode represents the ISO 3166-1 alpha-2 code of the destination country, set in the responses
+	CountryCode string `json:"country_code,omitempty"`
+}
+
+// CountryStats represents the analytics of the tickets of a destination country
+type CountryStats struct {
+	// Country represents the ISO 3166-1 alpha-2 code of the destination country
+	Country string `json:"country"`
+	// Name represents the name of the destination country
+	Name string `json:"name"`
+	// Total represents the amount of tickets
+	Total int `json:"total"`
+	// Percentage represents the share of the tickets of the country over all tickets (0-100)
+	Percentage float64 `json:"percentage"`
+	// Revenue represents the sum of the prices
+	Revenue float64 `json:"revenue"`
+	// AveragePrice represents the average price of a ticket
+	AveragePrice float64 `json:"average_price"`
+	// Periods represents the amount of tickets per period of the day
+	Periods map[string]int `json:"periods"`
+}
+
+// TicketAggregate represents the totals of a group of tickets kept up to date by the repository
+type TicketAggregate struct {
+	// Total represents the amount of tickets
+	Total int
+	// Revenue represents the sum of the prices
+	Revenue float64
+	// Periods represents the amount of tickets per period of the day
+	Periods map[string]int
 }
 
 // RepositoryTicket represents the repository interface for tickets
@@ -28,6 +165,39 @@ type RepositoryTicket interface {
 	Get() (t map[int]TicketAttributes, err error)
 	// GetTicketByDestinationCountry returns the tickets filtered by destination country
 	GetTicketsByDestinationCountry(country string) (t map[int]TicketAttributes, err error)
+	// GetTicketsByPeriod returns the tickets of a period of the day
+	GetTicketsByPeriod(period string) (t map[int]TicketAttributes, err error)
+	// GetPeriods returns the options the tickets are counted in the periods of the day with
+	GetPeriods() (p PeriodOptions, err error)
+	// GetTicketsByPriceRange returns the tickets with min <= price <= max
+	GetTicketsByPriceRange(min, max float64) (t map[int]TicketAttributes, err error)
+	// Find returns the tickets that match the filter
+	Find(filter TicketFilter) (t map[int]TicketAttributes, err error)
+	// Count returns the amount of tickets
+	Count() (total int, err error)
+	// GetAggregateByDestinationCountry returns the totals of a destination country, zero when it has no tickets
+	GetAggregateByDestinationCountry(country string) (a TicketAggregate, err error)
+	// GetAggregates returns the totals of every destination country by country code
+	GetAggregates() (a map[string]TicketAggregate, err error)
+	// GetById returns the ticket with the id
+	GetById(id int) (t TicketAttributes, err error)
+	// Save adds a new ticket and sets its id
+	Save(t *Ticket) (err error)
+	// Update replaces the attributes of an existing ticket
+	Update(t *Ticket) (err error)
+	// Patch applies a change to an existing ticket while holding the lock of the repository
+	Patch(id int, apply func(t *TicketAttributes) error) (t TicketAttributes, err error)
+	// Delete removes the ticket with the id
+	Delete(id int) (err error)
+	// Replace swaps all the tickets at once for the ones returned by load, readers see either the
+	// old or the new dataset. Writes wait until the swap, so none is lost between the load and it.
+	Replace(load func() (t map[int]TicketAttributes, err error)) (err error)
+}
+
+// WriterTicket persists the tickets after every change of the repository
+type WriterTicket interface {
+	// Write replaces the stored tickets with t
+	Write(t map[int]TicketAttributes) (err error)
 }
 
 type ServiceTicket interface {
@@ -36,7 +206,44 @@ type ServiceTicket interface {
 
 	// GetTicketsAmountByDestinationCountry returns the amount of tickets filtered by destination country
 	GetTicketsAmountByDestinationCountry(country string) (t map[int]TicketAttributes, err error)
+	// GetTicketsCountByDestinationCountry returns the amount of tickets of a destination country
+	GetTicketsCountByDestinationCountry(country string) (total int, err error)
+	// GetAverageCountry returns the share of the tickets of a destination country over all tickets (0-1), unrounded
 	GetAverageCountry(country string) (average float64, err error)
-	// GetPercentageTicketsByDestinationCountry returns the percentage of tickets filtered by destination country
-	GetPercentageTicketsByDestinationCountry(country string)
+	// GetPercentageTicketsByDestinationCountry returns the percentage of tickets filtered by destination country (0-100), unrounded
+	GetPercentageTicketsByDestinationCountry(country string) (percentage float64, err error)
+	// GetShareByDestinationCountry returns the ratio and the percentage of the tickets of a destination country, rounded by opts
+	GetShareByDestinationCountry(country string, opts share.Options) (result share.Share, err error)
+	// GetTicketsAmountByPeriod returns the amount of tickets per period of the day
+	GetTicketsAmountByPeriod() (periods map[string]int, err error)
+	// GetTicketsAmountByPeriodName returns the amount of tickets of a single period of the day
+	GetTicketsAmountByPeriodName(period string) (total int, err error)
+	// GetRevenueByDestinationCountry returns the sum of the prices of the tickets of a destination country
+	GetRevenueByDestinationCountry(country string) (revenue float64, err error)
+	// GetAveragePriceByDestinationCountry returns the average price of the tickets of a destination country
+	GetAveragePriceByDestinationCountry(country string) (average float64, err error)
+	// GetCountryStats returns all the analytics of a destination country
+	GetCountryStats(country string) (stats CountryStats, err error)
+	// GetBreakdown returns the analytics of every destination country, ordered by country
+	GetBreakdown() (breakdown []CountryStats, err error)
+	// GetPriceStatsByDestinationCountry returns the price distribution of the tickets of a destination country
+	GetPriceStatsByDestinationCountry(country string, opts PriceStatsOptions) (stats PriceStats, err error)
+	// GetPriceReport returns the price distribution of all the tickets and of every destination country
+	GetPriceReport(opts PriceStatsOptions) (report PriceReport, err error)
+
+	// SearchTickets returns a page of the tickets that match the query, sorted
+	SearchTickets(q TicketQuery) (page TicketPage, err error)
+	// CountTicketsBy returns the amount of tickets that match the query grouped by q.GroupBy
+	CountTicketsBy(q TicketQuery) (counts map[string]int, err error)
+
+	// GetTicketById returns the ticket with the id
+	GetTicketById(id int) (t Ticket, err error)
+	// CreateTicket validates and adds a new ticket
+	CreateTicket(t *Ticket) (err error)
+	// UpdateTicket validates and replaces an existing ticket
+	UpdateTicket(t *Ticket) (err error)
+	// PatchTicket applies a partial change to a ticket and validates the result
+	PatchTicket(id int, apply func(t *TicketAttributes) error) (t Ticket, err error)
+	// DeleteTicket removes the ticket with the id
+	DeleteTicket(id int) (err error)
 }
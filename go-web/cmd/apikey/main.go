@@ -0,0 +1,54 @@
+package main
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/izabelly/go-web/internal/model"
+	"github.com/izabelly/go-web/pkg/auth"
+)
+
+// gera uma nova API key e imprime a entrada para o arquivo de chaves (API_KEYS_FILE).
+// A chave em texto só aparece aqui, o arquivo guarda apenas o hash.
+//
+//	go run ./cmd/apikey -id partner-x -name "Parceiro X" -scopes products:read -ttl 2160h
+func main() {
+	id := flag.String("id", "", "identificador da chave")
+	name := flag.String("name", "", "nome amigável")
+	scopes := flag.String("scopes", model.ScopeProductsRead, "scopes separados por vírgula")
+	ttl := flag.Duration("ttl", 0, "validade da chave (0 = sem expiração)")
+	flag.Parse()
+
+	if *id == "" {
+		log.Fatal("informe -id")
+	}
+
+	raw := make([]byte, 32)
+	if _, err := rand.Read(raw); err != nil {
+		log.Fatal(err)
+	}
+	key := hex.EncodeToString(raw)
+
+	entry := model.APIKey{
+		ID:      *id,
+		Name:    *name,
+		KeyHash: auth.HashKey(key),
+		Scopes:  strings.Split(*scopes, ","),
+	}
+	if *ttl > 0 {
+		expires := time.Now().Add(*ttl).UTC()
+		entry.ExpiresAt = &expires
+	}
+
+	fmt.Fprintln(os.Stderr, "API key (guarde, não será exibida de novo):", key)
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", " ")
+	enc.Encode(entry)
+}
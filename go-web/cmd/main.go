@@ -1,13 +1,24 @@
 package main
 
 import (
+	"context"
 	"log"
-	"net/http"
+	"os"
+	"strconv"
+	"time"
 
 	"github.com/izabelly/go-web/internal/handler"
 	"github.com/izabelly/go-web/internal/repository"
 	"github.com/izabelly/go-web/internal/routes"
 	"github.com/izabelly/go-web/internal/service"
+	"github.com/izabelly/go-web/pkg/audit"
+	"github.com/izabelly/go-web/pkg/auth"
+	"github.com/izabelly/go-web/pkg/cors"
+	"github.com/izabelly/go-web/pkg/httpcache"
+	"github.com/izabelly/go-web/pkg/idempotency"
+	"github.com/izabelly/go-web/pkg/ratelimit"
+	"github.com/izabelly/go-web/pkg/secure"
+	"github.com/izabelly/go-web/pkg/tlsserver"
 	"github.com/joho/godotenv"
 )
 
@@ -22,9 +33,106 @@ func main() {
 		log.Println("Falha ao carregar as varáveis da .env")
 		return
 	}
-	rt := routes.Routes(handler)
 
-	if err := http.ListenAndServe(":8080", rt); err != nil {
+	// cache das leituras, invalidado a cada gravação do arquivo
+	cache := httpcache.New(durationEnv("CACHE_TTL", 5*time.Minute), 1000)
+	repo.OnWrite = func() { cache.Invalidate("products") }
+
+	// auditoria das mutações
+	auditLog := audit.NewLogger(audit.NewFileStore(stringEnv("AUDIT_FILE", "./docs/audit.log")))
+	service.Audit = auditLog
+
+	// expurgo da lixeira
+	retention := durationEnv("PURGE_RETENTION", 30*24*time.Hour)
+	interval := durationEnv("PURGE_INTERVAL", 24*time.Hour)
+	go service.RunPurgeJob(context.Background(), interval, retention)
+
+	// api keys
+	keysFile := stringEnv("API_KEYS_FILE", "./docs/api_keys.json")
+	keys := repository.NewRepositoryAPIKey(keysFile)
+	if _, err := keys.LoadAPIKeys(); err != nil {
+		log.Println("Falha ao carregar as API keys de", keysFile)
+		return
+	}
+
+	// jwt (opcional)
+	var verifier *auth.JWTVerifier
+	if os.Getenv("JWT_SECRET") != "" || os.Getenv("JWT_JWKS_FILE") != "" {
+		verifier, err = auth.NewJWTVerifier(auth.JWTConfig{
+			Secret:   []byte(os.Getenv("JWT_SECRET")),
+			JWKSFile: os.Getenv("JWT_JWKS_FILE"),
+			Issuer:   os.Getenv("JWT_ISSUER"),
+			Audience: os.Getenv("JWT_AUDIENCE"),
+			Leeway:   durationEnv("JWT_LEEWAY", 30*time.Second),
+		})
+		if err != nil {
+			log.Println("Falha ao configurar JWT:", err)
+			return
+		}
+	}
+
+	// rate limit
+	ipLimit, err := ratelimit.ParseLimit(stringEnv("RATE_LIMIT_IP", "300/m"))
+	if err != nil {
+		log.Println(err)
+		return
+	}
+	keyLimit, err := ratelimit.ParseLimit(stringEnv("RATE_LIMIT_KEY", "120/m"))
+	if err != nil {
+		log.Println(err)
+		return
+	}
+
+	rt := routes.Routes(handler, routes.Config{
+		Keys:            keys,
+		Verifier:        verifier,
+		Limiter:         ratelimit.NewMemory(),
+		IPLimit:         ipLimit,
+		KeyLimit:        keyLimit,
+		Audit:           auditLog,
+		Idempotency:     idempotency.NewMemory(),
+		IdempotencyTTL:  durationEnv("IDEMPOTENCY_TTL", 24*time.Hour),
+		Cache:           cache,
+		CompressMinSize: int(intEnv("COMPRESS_MIN_SIZE", 1024)),
+		BodyLimit:       intEnv("MAX_BODY_BYTES", 64<<10),
+		BulkBodyLimit:   intEnv("MAX_BULK_BODY_BYTES", 4<<20),
+		CORS: cors.Config{
+			AllowedOrigins:   cors.ParseList(os.Getenv("CORS_ALLOWED_ORIGINS")),
+			AllowCredentials: os.Getenv("CORS_ALLOW_CREDENTIALS") == "true",
+			MaxAge:           durationEnv("CORS_MAX_AGE", 10*time.Minute),
+		},
+		Security:     secure.Default,
+		ClientScopes: auth.ParseScopeMap(os.Getenv("TLS_CLIENT_SCOPES")),
+	})
+
+	// https quando TLS_CERT_FILE estiver definido
+	if err := tlsserver.ListenAndServe(context.Background(), ":8080", rt, tlsserver.FromEnv()); err != nil {
 		panic(err)
 	}
 }
+
+// durationEnv lê uma duração (ex: 720h) da env, usando def se estiver vazia ou inválida
+func durationEnv(key string, def time.Duration) time.Duration {
+	value, err := time.ParseDuration(os.Getenv(key))
+	if err != nil || value <= 0 {
+		return def
+	}
+	return value
+}
+
+// intEnv lê um inteiro positivo da env, usando def se estiver vazio ou inválido
+func intEnv(key string, def int64) int64 {
+	value, err := strconv.ParseInt(os.Getenv(key), 10, 64)
+	if err != nil || value <= 0 {
+		return def
+	}
+	return value
+}
+
+// stringEnv lê uma variável da env, usando def se estiver vazia
+func stringEnv(key, def string) string {
+	if value := os.Getenv(key); value != "" {
+		return value
+	}
+	return def
+}
@@ -0,0 +1,42 @@
+package handler
+
+import (
+	"log"
+	"net/http"
+
+	"github.com/izabelly/go-web/internal/model"
+	"github.com/izabelly/go-web/pkg/audit"
+)
+
+type HandlerAudit struct {
+	Audit *audit.Logger
+}
+
+// GetAudit lista os registros de auditoria filtrando por ?entity=&id=&from=&to=
+func (h *HandlerAudit) GetAudit(w http.ResponseWriter, r *http.Request) {
+	filter, err := audit.ParseFilter(r.URL.Query())
+	if err != nil {
+		handleError(w, http.StatusBadRequest, "Invalid audit filter")
+		return
+	}
+
+	entries, err := h.Audit.Query(filter)
+	if err != nil {
+		log.Println("Erro ao consultar auditoria:", err)
+		handleError(w, http.StatusInternalServerError, "Failed to read audit log")
+		return
+	}
+
+	body := model.ResBodyAudit{
+		Message: "Registros de auditoria",
+		Data:    entries,
+		Error:   false,
+	}
+
+	respondJSON(w, http.StatusOK, body)
+}
+
+// Retornar instancia de HandlerAudit com o logger de auditoria
+func NewAuditHandler(logger *audit.Logger) *HandlerAudit {
+	return &HandlerAudit{Audit: logger}
+}
@@ -0,0 +1,65 @@
+package handler
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/izabelly/go-web/internal/repository"
+	"github.com/izabelly/go-web/internal/service"
+	"github.com/izabelly/go-web/pkg/audit"
+	"github.com/izabelly/go-web/pkg/auth"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHandlerAudit_GetAudit(t *testing.T) {
+	// Arrange/Given
+	path := copyProductsFixture(t)
+	logger := audit.NewLogger(audit.NewFileStore(filepath.Join(t.TempDir(), "audit.log")))
+	sv := service.NewServiceProducts(repository.NewRepositoryProduct(path))
+	sv.Audit = logger
+	handlers := NewProductHandler(sv)
+	ha := NewAuditHandler(logger)
+
+	rt := chi.NewRouter()
+	rt.Use(audit.RequestID)
+	rt.Use(func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ctx := auth.NewContext(r.Context(), auth.Principal{ID: "admin-dev"})
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	})
+	rt.Patch("/products/{id}", handlers.PatchProduct)
+	rt.Delete("/products/{id}", handlers.DeleteProduct)
+	rt.Get("/audit", ha.GetAudit)
+
+	// Act/When
+	req := httptest.NewRequest("PATCH", "/products/1", strings.NewReader(`{"price": 99.5}`))
+	req.Header.Set("Content-Type", "application/merge-patch+json")
+	req.Header.Set("X-Request-Id", "req-1")
+	res := httptest.NewRecorder()
+	rt.ServeHTTP(res, req)
+	require.Equal(t, http.StatusOK, res.Code)
+
+	res = httptest.NewRecorder()
+	rt.ServeHTTP(res, httptest.NewRequest("DELETE", "/products/2", nil))
+	require.Equal(t, http.StatusOK, res.Code)
+
+	// Assert/Then
+	res = httptest.NewRecorder()
+	rt.ServeHTTP(res, httptest.NewRequest("GET", "/audit?entity=product&id=1", nil))
+	require.Equal(t, http.StatusOK, res.Code)
+	body := res.Body.String()
+	require.Contains(t, body, `"actor":"admin-dev"`)
+	require.Contains(t, body, `"action":"update"`)
+	require.Contains(t, body, `"request_id":"req-1"`)
+	require.Contains(t, body, `"price":{"before":`)
+	require.NotContains(t, body, `"action":"delete"`)
+
+	res = httptest.NewRecorder()
+	rt.ServeHTTP(res, httptest.NewRequest("GET", "/audit?from=ontem", nil))
+	require.Equal(t, http.StatusBadRequest, res.Code)
+}
@@ -0,0 +1,140 @@
+package handler
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/izabelly/go-web/internal/model"
+	"github.com/izabelly/go-web/internal/repository"
+	"github.com/izabelly/go-web/internal/service"
+	"github.com/izabelly/go-web/pkg/auth"
+	"github.com/stretchr/testify/require"
+)
+
+// copia o arquivo de teste para não alterar o products_test.json
+func copyProductsFixture(t *testing.T) string {
+	t.Helper()
+	data, err := os.ReadFile("../../docs/products_test.json")
+	require.NoError(t, err)
+
+	path := filepath.Join(t.TempDir(), "products.json")
+	require.NoError(t, os.WriteFile(path, data, 0666))
+	return path
+}
+
+func TestHandlerProduct_BulkProducts(t *testing.T) {
+	t.Run("atomic mode rejects everything when one operation is invalid", func(t *testing.T) {
+		// Arrange/Given
+		path := copyProductsFixture(t)
+		before, _ := os.ReadFile(path)
+		repo := repository.NewRepositoryProduct(path)
+		handlers := NewProductHandler(service.NewServiceProducts(repo))
+
+		rt := chi.NewRouter()
+		rt.Post("/products/bulk", handlers.BulkProducts)
+
+		json := `{"mode": "atomic", "operations": [
+			{"op": "create", "product": {"name": "bulk","quantity": 1,"code_value": "BULK1","expiration": "02/10/2021","price": 10}},
+			{"op": "delete", "id": 99}
+		]}`
+
+		// Act/When
+		req := httptest.NewRequest("POST", "/products/bulk", bytes.NewReader([]byte(json)))
+		res := httptest.NewRecorder()
+		rt.ServeHTTP(res, req)
+
+		// Assert/Then
+		after, _ := os.ReadFile(path)
+		expectedBody := `{
+			"message": "Nenhuma operação aplicada",
+			"mode": "atomic",
+			"results": [
+				{"index": 0, "op": "create", "success": false, "message": "Não aplicada: o lote foi rejeitado"},
+				{"index": 1, "op": "delete", "id": 99, "success": false, "message": "Produto não existe: 99"}
+			],
+			"error": true
+		}`
+		require.Equal(t, http.StatusUnprocessableEntity, res.Code)
+		require.JSONEq(t, expectedBody, res.Body.String())
+		require.Equal(t, string(before), string(after)) // nada foi gravado
+	})
+
+	t.Run("best effort mode applies the valid operations", func(t *testing.T) {
+		// Arrange/Given
+		path := copyProductsFixture(t)
+		repo := repository.NewRepositoryProduct(path)
+		handlers := NewProductHandler(service.NewServiceProducts(repo))
+
+		rt := chi.NewRouter()
+		rt.Post("/products/bulk", handlers.BulkProducts)
+
+		json := `{"mode": "best_effort", "operations": [
+			{"op": "create", "product": {"name": "bulk","quantity": 1,"code_value": "BULK1","expiration": "02/10/2021","price": 10}},
+			{"op": "create", "product": {"name": "dup","quantity": 1,"code_value": "BULK1","expiration": "02/10/2021","price": 10}},
+			{"op": "delete", "id": 1}
+		]}`
+
+		// Act/When
+		req := httptest.NewRequest("POST", "/products/bulk", bytes.NewReader([]byte(json)))
+		res := httptest.NewRecorder()
+		rt.ServeHTTP(res, req)
+
+		// Assert/Then
+		expectedBody := `{
+			"message": "Operações processadas",
+			"mode": "best_effort",
+			"results": [
+				{"index": 0, "op": "create", "id": 4, "success": true, "data": {"id": 4, "name": "bulk", "quantity": 1, "code_value": "BULK1", "is_published": false, "expiration": "02/10/2021", "price": 10}},
+				{"index": 1, "op": "create", "success": false, "message": "Produto inválido: {ID:0 Name:dup Quantity:1 CodeValue:BULK1 IsPublished:false Expiration:02/10/2021 Price:10 DeletedAt:<nil>}"},
+				{"index": 2, "op": "delete", "id": 1, "success": true}
+			],
+			"error": false
+		}`
+		require.Equal(t, http.StatusOK, res.Code)
+		require.JSONEq(t, expectedBody, res.Body.String())
+
+		products, err := repo.LoadProducts()
+		require.NoError(t, err)
+		require.Len(t, products, 4)
+		require.NotNil(t, products[0].DeletedAt) // delete manda para a lixeira
+	})
+
+	t.Run("each operation requires the scope of its type", func(t *testing.T) {
+		// Arrange/Given
+		path := copyProductsFixture(t)
+		before, _ := os.ReadFile(path)
+		repo := repository.NewRepositoryProduct(path)
+		handlers := NewProductHandler(service.NewServiceProducts(repo))
+
+		rt := chi.NewRouter()
+		rt.Post("/products/bulk", handlers.BulkProducts)
+		post := func(scopes []string, json string) *httptest.ResponseRecorder {
+			req := httptest.NewRequest("POST", "/products/bulk", bytes.NewReader([]byte(json)))
+			req = req.WithContext(auth.NewContext(req.Context(), auth.Principal{ID: "bulk", Scopes: scopes}))
+			res := httptest.NewRecorder()
+			rt.ServeHTTP(res, req)
+			return res
+		}
+		create := `{"operations": [{"op": "create", "product": {"name": "bulk","quantity": 1,"code_value": "BULK1","expiration": "02/10/2021","price": 10}}]}`
+		mixed := `{"operations": [
+			{"op": "create", "product": {"name": "bulk","quantity": 1,"code_value": "BULK2","expiration": "02/10/2021","price": 10}},
+			{"op": "delete", "id": 1}
+		]}`
+
+		// Act/When
+		forbidden := post([]string{model.ScopeProductsWrite}, mixed)
+		after, _ := os.ReadFile(path)
+		created := post([]string{model.ScopeProductsWrite}, create)
+
+		// Assert/Then
+		require.Equal(t, http.StatusForbidden, forbidden.Code)
+		require.Contains(t, forbidden.Body.String(), model.ScopeProductsDelete)
+		require.Equal(t, string(before), string(after))
+		require.Equal(t, http.StatusOK, created.Code)
+	})
+}
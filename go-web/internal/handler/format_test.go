@@ -0,0 +1,52 @@
+package handler
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/izabelly/go-web/internal/repository"
+	"github.com/izabelly/go-web/internal/service"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHandlerProduct_GetAllProductsFormats(t *testing.T) {
+	// Arrange/Given
+	path := copyProductsFixture(t)
+	handlers := NewProductHandler(service.NewServiceProducts(repository.NewRepositoryProduct(path)))
+
+	t.Run("csv by accept header", func(t *testing.T) {
+		// Act/When
+		req := httptest.NewRequest("GET", "/products", nil)
+		req.Header.Set("Accept", "text/csv")
+		res := httptest.NewRecorder()
+		handlers.GetAllProducts(res, req)
+
+		// Assert/Then
+		require.Equal(t, http.StatusOK, res.Code)
+		require.Equal(t, "text/csv; charset=utf-8", res.Header().Get("Content-Type"))
+		require.True(t, strings.HasPrefix(res.Body.String(), "id,name,quantity,code_value,is_published,expiration,price,deleted_at\n"))
+	})
+
+	t.Run("ndjson by format query", func(t *testing.T) {
+		// Act/When
+		res := httptest.NewRecorder()
+		handlers.GetAllProducts(res, httptest.NewRequest("GET", "/products?format=ndjson", nil))
+
+		// Assert/Then
+		require.Equal(t, http.StatusOK, res.Code)
+		lines := strings.Split(strings.TrimSpace(res.Body.String()), "\n")
+		require.Greater(t, len(lines), 1)
+		require.True(t, strings.HasPrefix(lines[0], `{"id":1,`))
+	})
+
+	t.Run("not acceptable", func(t *testing.T) {
+		// Act/When
+		res := httptest.NewRecorder()
+		handlers.GetAllProducts(res, httptest.NewRequest("GET", "/products?format=pdf", nil))
+
+		// Assert/Then
+		require.Equal(t, http.StatusNotAcceptable, res.Code)
+	})
+}
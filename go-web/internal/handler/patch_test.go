@@ -0,0 +1,130 @@
+package handler
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/izabelly/go-web/internal/repository"
+	"github.com/izabelly/go-web/internal/service"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHandlerProduct_PatchProduct(t *testing.T) {
+	newRouter := func(t *testing.T) http.Handler {
+		path := copyProductsFixture(t)
+		handlers := NewProductHandler(service.NewServiceProducts(repository.NewRepositoryProduct(path)))
+
+		rt := chi.NewRouter()
+		rt.Patch("/products/{id}", handlers.PatchProduct)
+		return rt
+	}
+
+	t.Run("merge patch", func(t *testing.T) {
+		// Arrange/Given
+		rt := newRouter(t)
+		req := httptest.NewRequest("PATCH", "/products/1", bytes.NewReader([]byte(`{"price": 99.5, "id": 50}`)))
+		req.Header.Set("Content-Type", "application/merge-patch+json")
+		res := httptest.NewRecorder()
+
+		// Act/When
+		rt.ServeHTTP(res, req)
+
+		// Assert/Then
+		expectedBody := `{
+			"message": "Produto Alterado",
+			"data": {
+				"id": 1,
+				"name": "Oil - Margarine",
+				"quantity": 439,
+				"code_value": "S82254D",
+				"is_published": true,
+				"expiration": "15/12/2021",
+				"price": 99.5
+			},
+			"error": false
+		}`
+		require.Equal(t, http.StatusOK, res.Code)
+		require.JSONEq(t, expectedBody, res.Body.String())
+	})
+
+	t.Run("json patch", func(t *testing.T) {
+		// Arrange/Given
+		rt := newRouter(t)
+		json := `[
+			{"op": "test", "path": "/name", "value": "Oil - Margarine"},
+			{"op": "replace", "path": "/quantity", "value": 1},
+			{"op": "copy", "from": "/name", "path": "/code_value"}
+		]`
+		req := httptest.NewRequest("PATCH", "/products/1", bytes.NewReader([]byte(json)))
+		req.Header.Set("Content-Type", "application/json-patch+json")
+		res := httptest.NewRecorder()
+
+		// Act/When
+		rt.ServeHTTP(res, req)
+
+		// Assert/Then
+		require.Equal(t, http.StatusOK, res.Code)
+		require.Contains(t, res.Body.String(), `"quantity":1,"code_value":"Oil - Margarine"`)
+	})
+
+	t.Run("json patch test operation fails", func(t *testing.T) {
+		// Arrange/Given
+		rt := newRouter(t)
+		req := httptest.NewRequest("PATCH", "/products/1", bytes.NewReader([]byte(`[{"op": "test", "path": "/price", "value": 1}]`)))
+		req.Header.Set("Content-Type", "application/json-patch+json")
+		res := httptest.NewRecorder()
+
+		// Act/When
+		rt.ServeHTTP(res, req)
+
+		// Assert/Then
+		require.Equal(t, http.StatusConflict, res.Code)
+	})
+
+	t.Run("invalid result", func(t *testing.T) {
+		// Arrange/Given
+		rt := newRouter(t)
+		req := httptest.NewRequest("PATCH", "/products/1", bytes.NewReader([]byte(`{"name": null}`)))
+		req.Header.Set("Content-Type", "application/merge-patch+json")
+		res := httptest.NewRecorder()
+
+		// Act/When
+		rt.ServeHTTP(res, req)
+
+		// Assert/Then
+		require.Equal(t, http.StatusUnprocessableEntity, res.Code)
+	})
+
+	t.Run("not found ID product", func(t *testing.T) {
+		// Arrange/Given
+		rt := newRouter(t)
+		req := httptest.NewRequest("PATCH", "/products/10", bytes.NewReader([]byte(`{"price": 1}`)))
+		req.Header.Set("Content-Type", "application/merge-patch+json")
+		res := httptest.NewRecorder()
+
+		// Act/When
+		rt.ServeHTTP(res, req)
+
+		// Assert/Then
+		expectedBody := `{"message": "Product not found", "error": true}`
+		require.Equal(t, http.StatusNotFound, res.Code)
+		require.JSONEq(t, expectedBody, res.Body.String())
+	})
+
+	t.Run("unsupported content type", func(t *testing.T) {
+		// Arrange/Given
+		rt := newRouter(t)
+		req := httptest.NewRequest("PATCH", "/products/1", bytes.NewReader([]byte(`price=1`)))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		res := httptest.NewRecorder()
+
+		// Act/When
+		rt.ServeHTTP(res, req)
+
+		// Assert/Then
+		require.Equal(t, http.StatusUnsupportedMediaType, res.Code)
+	})
+}
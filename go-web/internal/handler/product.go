@@ -2,12 +2,19 @@ package handler
 
 import (
 	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
 	"net/http"
 	"strconv"
 
 	"github.com/go-chi/chi/v5"
 	"github.com/izabelly/go-web/internal/model"
 	"github.com/izabelly/go-web/internal/service"
+	"github.com/izabelly/go-web/pkg/auth"
+	"github.com/izabelly/go-web/pkg/negotiate"
+	"github.com/izabelly/go-web/pkg/patch"
 )
 
 type HandlerProduct struct {
@@ -38,7 +45,7 @@ func (h *HandlerProduct) CreateProduct(w http.ResponseWriter, r *http.Request) {
 		Price:       reqBody.Price,
 	}
 
-	response, err := h.Service.AddProduct(productBody)
+	response, err := h.Service.AddProduct(r.Context(), productBody)
 	if err != nil {
 		handleError(w, http.StatusBadRequest, "Failed to create product")
 		return
@@ -55,12 +62,27 @@ func (h *HandlerProduct) CreateProduct(w http.ResponseWriter, r *http.Request) {
 
 func (h *HandlerProduct) GetAllProducts(w http.ResponseWriter, r *http.Request) {
 	w.Header().Add("Content-Type", "application/json")
+	// formato pelo Accept ou ?format=json|csv|xml|ndjson
+	format, err := negotiate.Negotiate(r)
+	negotiate.Vary(w)
+	if err != nil {
+		handleError(w, http.StatusNotAcceptable, "Format not acceptable")
+		return
+	}
+
 	listProducts, err := h.Service.GetAllProducts()
 	if err != nil {
 		handleError(w, http.StatusInternalServerError, "Failed to retrieve products")
 		return
 	}
 
+	if format != negotiate.JSON {
+		if err := negotiate.Write(w, format, http.StatusOK, "products", listProducts); err != nil {
+			log.Println("erro ao escrever os produtos:", err)
+		}
+		return
+	}
+
 	w.WriteHeader(http.StatusOK)
 	json.NewEncoder(w).Encode(listProducts)
 }
@@ -136,7 +158,7 @@ func (h *HandlerProduct) UpdateProduct(w http.ResponseWriter, r *http.Request) {
 		Price:       reqBody.Price,
 	}
 
-	products, err := h.Service.UpdateProduct(newProduct, id)
+	products, err := h.Service.UpdateProduct(r.Context(), newProduct, id)
 	if err != nil {
 		handleError(w, http.StatusInternalServerError, "Failed to update product")
 		return
@@ -159,14 +181,18 @@ func (h *HandlerProduct) DeleteProduct(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	err = h.Service.DeleteProduct(id)
+	err = h.Service.DeleteProduct(r.Context(), id)
+	if errors.Is(err, service.ErrProductNotFound) {
+		handleError(w, http.StatusNotFound, "Product not found")
+		return
+	}
 	if err != nil {
 		handleError(w, http.StatusInternalServerError, "Failed to delete product")
 		return
 	}
 
 	resBody := model.ResBodyProduct{
-		Message: "Produto excluido",
+		Message: "Produto enviado para a lixeira",
 		Data:    nil,
 		Error:   false,
 	}
@@ -174,7 +200,19 @@ func (h *HandlerProduct) DeleteProduct(w http.ResponseWriter, r *http.Request) {
 	respondJSON(w, http.StatusOK, resBody)
 }
 
-func (h *HandlerProduct) PatchProduct(w http.ResponseWriter, r *http.Request) {
+func (h *HandlerProduct) GetTrash(w http.ResponseWriter, r *http.Request) {
+	w.Header().Add("Content-Type", "application/json")
+	trash, err := h.Service.GetTrash()
+	if err != nil {
+		handleError(w, http.StatusInternalServerError, "Failed to retrieve trash")
+		return
+	}
+
+	respondJSON(w, http.StatusOK, trash)
+}
+
+func (h *HandlerProduct) RestoreProduct(w http.ResponseWriter, r *http.Request) {
+	w.Header().Add("Content-Type", "application/json")
 	param := chi.URLParam(r, "id")
 	id, err := strconv.Atoi(param)
 	if err != nil {
@@ -182,73 +220,140 @@ func (h *HandlerProduct) PatchProduct(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	var reqBody model.ReqPatchBodyProduct
-	if err := json.NewDecoder(r.Body).Decode(&reqBody); err != nil {
-		handleError(w, http.StatusInternalServerError, "Failed to update product")
+	product, err := h.Service.RestoreProduct(r.Context(), id)
+	if errors.Is(err, service.ErrProductNotFound) {
+		handleError(w, http.StatusNotFound, "Product not found in trash")
 		return
 	}
-
-	productSave, err := h.Service.GetProductByID(id)
 	if err != nil {
-		handleError(w, http.StatusInternalServerError, "Failed to retrieve product")
+		handleError(w, http.StatusInternalServerError, "Failed to restore product")
 		return
 	}
 
-	name := productSave.Name
-	if reqBody.Name != nil {
-		name = *reqBody.Name
+	body := model.ResBodyProduct{
+		Message: "Produto restaurado",
+		Data:    &product,
+		Error:   false,
+	}
+
+	respondJSON(w, http.StatusOK, body)
+}
+
+// PatchProduct aceita application/merge-patch+json (RFC 7386) e
+// application/json-patch+json (RFC 6902); application/json é tratado como merge patch
+func (h *HandlerProduct) PatchProduct(w http.ResponseWriter, r *http.Request) {
+	defer r.Body.Close()
+	w.Header().Add("Content-Type", "application/json")
+	param := chi.URLParam(r, "id")
+	id, err := strconv.Atoi(param)
+	if err != nil {
+		handleError(w, http.StatusBadRequest, "Invalid Id format")
+		return
 	}
 
-	quantity := productSave.Quantity
-	if reqBody.Quantity != nil {
-		quantity = *reqBody.Quantity
+	patchDoc, err := io.ReadAll(r.Body)
+	if err != nil {
+		handleError(w, http.StatusBadRequest, "Invalid request body")
+		return
 	}
 
-	codeValue := productSave.CodeValue
-	if reqBody.CodeValue != nil {
-		codeValue = *reqBody.CodeValue
+	contentType := r.Header.Get("Content-Type")
+	product, err := h.Service.PatchProduct(r.Context(), id, func(p model.Product) (model.Product, error) {
+		return patch.Apply(p, contentType, patchDoc)
+	})
+	switch {
+	case errors.Is(err, service.ErrProductNotFound):
+		handleError(w, http.StatusNotFound, "Product not found")
+		return
+	case errors.Is(err, patch.ErrUnsupportedMediaType):
+		handleError(w, http.StatusUnsupportedMediaType, "Unsupported patch content type")
+		return
+	case errors.Is(err, patch.ErrTestFailed):
+		handleError(w, http.StatusConflict, "Patch test operation failed")
+		return
+	case errors.Is(err, patch.ErrInvalidPatch):
+		handleError(w, http.StatusBadRequest, "Invalid patch document")
+		return
+	case errors.Is(err, service.ErrInvalidProduct):
+		handleError(w, http.StatusUnprocessableEntity, "Invalid product")
+		return
+	case err != nil:
+		handleError(w, http.StatusInternalServerError, "Failed to update product")
+		return
 	}
 
-	isPublished := productSave.IsPublished
-	if reqBody.IsPublished != nil {
-		isPublished = *reqBody.IsPublished
+	body := model.ResBodyProduct{
+		Message: "Produto Alterado",
+		Data:    &product,
+		Error:   false,
 	}
 
-	expiration := productSave.Expiration
-	if reqBody.Expiration != nil {
-		expiration = *reqBody.Expiration
+	respondJSON(w, http.StatusOK, body)
+}
+
+func (h *HandlerProduct) BulkProducts(w http.ResponseWriter, r *http.Request) {
+	defer r.Body.Close()
+	w.Header().Add("Content-Type", "application/json")
+	var reqBody model.ReqBulkProduct
+	if err := json.NewDecoder(r.Body).Decode(&reqBody); err != nil {
+		handleError(w, http.StatusBadRequest, "Invalid request body")
+		return
 	}
 
-	price := productSave.Price
-	if reqBody.Price != nil {
-		price = *reqBody.Price
+	if reqBody.Mode == "" {
+		reqBody.Mode = model.BulkModeAtomic
+	}
+	if reqBody.Mode != model.BulkModeAtomic && reqBody.Mode != model.BulkModeBestEffort {
+		handleError(w, http.StatusBadRequest, "Invalid bulk mode")
+		return
+	}
+	if len(reqBody.Operations) == 0 {
+		handleError(w, http.StatusBadRequest, "No operations informed")
+		return
 	}
 
-	resBody := model.Product{
-		ID:          id,
-		Name:        name,
-		Quantity:    quantity,
-		CodeValue:   codeValue,
-		IsPublished: isPublished,
-		Expiration:  expiration,
-		Price:       price,
+	if principal, ok := auth.FromContext(r.Context()); ok {
+		for i, op := range reqBody.Operations {
+			if scope, known := bulkScopes[op.Op]; known && !principal.HasScope(scope) {
+				handleError(w, http.StatusForbidden, fmt.Sprintf("Missing scope %s for operation %d", scope, i))
+				return
+			}
+		}
 	}
 
-	product, err := h.Service.PatchProduct(resBody, id)
+	results, err := h.Service.BulkProducts(r.Context(), reqBody.Operations, reqBody.Mode)
+	if errors.Is(err, service.ErrBulkRejected) {
+		body := model.ResBulkProduct{
+			Message: "Nenhuma operação aplicada",
+			Mode:    reqBody.Mode,
+			Results: results,
+			Error:   true,
+		}
+		respondJSON(w, http.StatusUnprocessableEntity, body)
+		return
+	}
 	if err != nil {
-		handleError(w, http.StatusInternalServerError, "Failed to update product")
+		handleError(w, http.StatusInternalServerError, "Failed to process bulk operations")
 		return
 	}
 
-	body := model.ResBodyProduct{
-		Message: "Produto Alterado",
-		Data:    &product,
+	body := model.ResBulkProduct{
+		Message: "Operações processadas",
+		Mode:    reqBody.Mode,
+		Results: results,
 		Error:   false,
 	}
 
 	respondJSON(w, http.StatusOK, body)
 }
 
+// bulkScopes são os scopes exigidos por cada tipo de operação do bulk
+var bulkScopes = map[string]string{
+	model.BulkOpCreate: model.ScopeProductsWrite,
+	model.BulkOpUpdate: model.ScopeProductsWrite,
+	model.BulkOpDelete: model.ScopeProductsDelete,
+}
+
 // Retornar instancia de HandlerProduct e inicializando o service com o valor
 func NewProductHandler(service *service.ServiceProduct) *HandlerProduct {
 	return &HandlerProduct{Service: service}
@@ -0,0 +1,88 @@
+package handler
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/izabelly/go-web/internal/repository"
+	"github.com/izabelly/go-web/internal/service"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHandlerProduct_Trash(t *testing.T) {
+	t.Run("delete, list trash and restore product", func(t *testing.T) {
+		// Arrange/Given
+		path := copyProductsFixture(t)
+		repo := repository.NewRepositoryProduct(path)
+		handlers := NewProductHandler(service.NewServiceProducts(repo))
+
+		rt := chi.NewRouter()
+		rt.Get("/products", handlers.GetAllProducts)
+		rt.Get("/products/trash", handlers.GetTrash)
+		rt.Delete("/products/{id}", handlers.DeleteProduct)
+		rt.Post("/products/{id}/restore", handlers.RestoreProduct)
+
+		// Act/When
+		res := httptest.NewRecorder()
+		rt.ServeHTTP(res, httptest.NewRequest("DELETE", "/products/2", nil))
+		require.Equal(t, http.StatusOK, res.Code)
+
+		// Assert/Then
+		res = httptest.NewRecorder()
+		rt.ServeHTTP(res, httptest.NewRequest("GET", "/products", nil))
+		require.NotContains(t, res.Body.String(), `"id":2`) // não aparece na listagem normal
+
+		res = httptest.NewRecorder()
+		rt.ServeHTTP(res, httptest.NewRequest("GET", "/products/trash", nil))
+		require.Contains(t, res.Body.String(), `"id":2`)
+		require.Contains(t, res.Body.String(), `"deleted_at"`)
+
+		res = httptest.NewRecorder()
+		rt.ServeHTTP(res, httptest.NewRequest("POST", "/products/2/restore", nil))
+		require.Equal(t, http.StatusOK, res.Code)
+
+		res = httptest.NewRecorder()
+		rt.ServeHTTP(res, httptest.NewRequest("POST", "/products/2/restore", nil))
+		require.Equal(t, http.StatusNotFound, res.Code) // já foi restaurado
+	})
+
+	t.Run("delete not found product", func(t *testing.T) {
+		// Arrange/Given
+		path := copyProductsFixture(t)
+		handlers := NewProductHandler(service.NewServiceProducts(repository.NewRepositoryProduct(path)))
+
+		rt := chi.NewRouter()
+		rt.Delete("/products/{id}", handlers.DeleteProduct)
+
+		// Act/When
+		res := httptest.NewRecorder()
+		rt.ServeHTTP(res, httptest.NewRequest("DELETE", "/products/10", nil))
+
+		// Assert/Then
+		require.Equal(t, http.StatusNotFound, res.Code)
+	})
+
+	t.Run("purge removes products older than the retention", func(t *testing.T) {
+		// Arrange/Given
+		path := copyProductsFixture(t)
+		repo := repository.NewRepositoryProduct(path)
+		sv := service.NewServiceProducts(repo)
+		require.NoError(t, sv.DeleteProduct(context.Background(), 1))
+
+		// Act/When
+		kept, err := sv.PurgeProducts(context.Background(), time.Hour)
+		require.NoError(t, err)
+		purged, err := sv.PurgeProducts(context.Background(), 0)
+		require.NoError(t, err)
+
+		// Assert/Then
+		products, _ := repo.LoadProducts()
+		require.Equal(t, 0, kept)
+		require.Equal(t, 1, purged)
+		require.Len(t, products, 2)
+	})
+}
@@ -1,34 +1,140 @@
 package middlewares
 
 import (
+	"crypto/subtle"
 	"encoding/json"
 	"net/http"
 	"os"
+	"time"
+
+	"github.com/izabelly/go-web/internal/model"
+	"github.com/izabelly/go-web/pkg/auth"
 )
 
+// KeyStore é a origem das API keys (arquivo, banco, ...)
+type KeyStore interface {
+	// FindAPIKey retorna a chave cujo hash confere com keyHash
+	FindAPIKey(keyHash string) (model.APIKey, error)
+}
+
+// APIKey autentica o header API_TOKEN contra o KeyStore e coloca o principal no context.
+// A autorização de cada rota fica com RequireScope.
+func APIKey(store KeyStore) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			token := r.Header.Get("API_TOKEN")
+			if token == "" {
+				RespondError(w, http.StatusUnauthorized, "unauthorized")
+				return
+			}
+
+			key, err := store.FindAPIKey(auth.HashKey(token))
+			if err != nil || !key.Active(time.Now()) {
+				RespondError(w, http.StatusUnauthorized, "unauthorized")
+				return
+			}
+
+			principal := auth.Principal{ID: key.ID, Name: key.Name, Scopes: key.Scopes}
+			next.ServeHTTP(w, r.WithContext(auth.NewContext(r.Context(), principal)))
+		})
+	}
+}
+
+// Authenticate aceita Authorization: Bearer <jwt> quando há verifier e,
+// sem bearer, cai para o header API_TOKEN. Quem já chega autenticado pelo
+// certificado de cliente (auth.ClientCert) e não envia credenciais passa direto.
+func Authenticate(keys KeyStore, verifier *auth.JWTVerifier) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		byKey := APIKey(keys)(next)
+		var byToken http.Handler
+		if verifier != nil {
+			byToken = auth.Bearer(verifier, RespondError)(next)
+		}
+
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			_, hasBearer := auth.BearerToken(r)
+			if _, ok := auth.FromContext(r.Context()); ok && !hasBearer && r.Header.Get("API_TOKEN") == "" {
+				next.ServeHTTP(w, r)
+				return
+			}
+			if byToken != nil && hasBearer {
+				byToken.ServeHTTP(w, r)
+				return
+			}
+			byKey.ServeHTTP(w, r)
+		})
+	}
+}
+
+// RequireScope bloqueia com 403 quem não tem o scope informado
+func RequireScope(scope string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			principal, ok := auth.FromContext(r.Context())
+			if !ok {
+				RespondError(w, http.StatusUnauthorized, "unauthorized")
+				return
+			}
+
+			if !principal.HasScope(scope) {
+				RespondError(w, http.StatusForbidden, "forbidden")
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// RequireAnyScope bloqueia com 403 quem não tem nenhum dos scopes informados; o
+// handler confere o scope exato de cada operação
+func RequireAnyScope(scopes ...string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			principal, ok := auth.FromContext(r.Context())
+			if !ok {
+				RespondError(w, http.StatusUnauthorized, "unauthorized")
+				return
+			}
+
+			for _, scope := range scopes {
+				if principal.HasScope(scope) {
+					next.ServeHTTP(w, r)
+					return
+				}
+			}
+			RespondError(w, http.StatusForbidden, "forbidden")
+		})
+	}
+}
+
+// Auth compara o header API_TOKEN com a variável de ambiente API_TOKEN e
+// concede todos os scopes de produtos.
+//
+// Deprecated: use APIKey com um KeyStore e RequireScope por rota.
 func Auth(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		token := r.Header.Get("API_TOKEN")
-		if token == "" {
-			w.Header().Set("Content-Type", "application/json")
-			w.WriteHeader(http.StatusUnauthorized)
-			json.NewEncoder(w).Encode(map[string]interface{}{
-				"message": "unauthorized",
-				"error":   true,
-			})
+		expected := os.Getenv("API_TOKEN")
+		if token == "" || subtle.ConstantTimeCompare([]byte(token), []byte(expected)) != 1 {
+			RespondError(w, http.StatusUnauthorized, "unauthorized")
 			return
 		}
 
-		if token != os.Getenv("API_TOKEN") {
-			w.Header().Set("Content-Type", "application/json")
-			w.WriteHeader(http.StatusUnauthorized)
-			json.NewEncoder(w).Encode(map[string]interface{}{
-				"message": "unauthorized",
-				"error":   true,
-			})
-			return
+		principal := auth.Principal{
+			ID:     "API_TOKEN",
+			Scopes: []string{model.ScopeProductsRead, model.ScopeProductsWrite, model.ScopeProductsDelete},
 		}
+		next.ServeHTTP(w, r.WithContext(auth.NewContext(r.Context(), principal)))
+	})
+}
 
-		next.ServeHTTP(w, r)
+// RespondError escreve erros no formato do go-web: {"message": "...", "error": true}
+func RespondError(w http.ResponseWriter, status int, message string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"message": message,
+		"error":   true,
 	})
 }
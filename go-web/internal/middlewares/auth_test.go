@@ -0,0 +1,78 @@
+package middlewares
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/izabelly/go-web/internal/model"
+	"github.com/izabelly/go-web/internal/repository"
+	"github.com/izabelly/go-web/pkg/auth"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAPIKey(t *testing.T) {
+	// Arrange/Given
+	expired := time.Now().Add(-time.Hour)
+	keys := []model.APIKey{
+		{ID: "admin", KeyHash: auth.HashKey("admin-key"), Scopes: []string{model.ScopeProductsRead, model.ScopeProductsDelete}},
+		{ID: "partner", KeyHash: auth.HashKey("partner-key"), Scopes: []string{model.ScopeProductsRead}},
+		{ID: "old", KeyHash: auth.HashKey("old-key"), Scopes: []string{model.ScopeProductsRead}, ExpiresAt: &expired},
+		{ID: "revoked", KeyHash: auth.HashKey("revoked-key"), Scopes: []string{model.ScopeProductsRead}, Revoked: true},
+	}
+	data, _ := json.Marshal(keys)
+	path := filepath.Join(t.TempDir(), "api_keys.json")
+	require.NoError(t, os.WriteFile(path, data, 0666))
+
+	ok := func(w http.ResponseWriter, r *http.Request) {
+		principal, _ := auth.FromContext(r.Context())
+		w.Write([]byte(principal.ID))
+	}
+	rt := chi.NewRouter()
+	rt.Use(APIKey(repository.NewRepositoryAPIKey(path)))
+	rt.With(RequireScope(model.ScopeProductsRead)).Get("/products", ok)
+	rt.With(RequireScope(model.ScopeProductsDelete)).Delete("/products/1", ok)
+	rt.With(RequireAnyScope(model.ScopeProductsWrite, model.ScopeProductsDelete)).Post("/products/bulk", ok)
+
+	cases := []struct {
+		name         string
+		method       string
+		token        string
+		expectedCode int
+	}{
+		{"read with partner key", "GET", "partner-key", http.StatusOK},
+		{"delete with partner key", "DELETE", "partner-key", http.StatusForbidden},
+		{"delete with admin key", "DELETE", "admin-key", http.StatusOK},
+		{"bulk with partner key", "POST", "partner-key", http.StatusForbidden},
+		{"bulk with admin key", "POST", "admin-key", http.StatusOK},
+		{"expired key", "GET", "old-key", http.StatusUnauthorized},
+		{"revoked key", "GET", "revoked-key", http.StatusUnauthorized},
+		{"unknown key", "GET", "1", http.StatusUnauthorized},
+		{"missing key", "GET", "", http.StatusUnauthorized},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			// Act/When
+			path := "/products"
+			switch c.method {
+			case "DELETE":
+				path = "/products/1"
+			case "POST":
+				path = "/products/bulk"
+			}
+			req := httptest.NewRequest(c.method, path, nil)
+			req.Header.Set("API_TOKEN", c.token)
+			res := httptest.NewRecorder()
+			rt.ServeHTTP(res, req)
+
+			// Assert/Then
+			require.Equal(t, c.expectedCode, res.Code)
+		})
+	}
+}
@@ -0,0 +1,28 @@
+package model
+
+import "time"
+
+// Scopes aceitos pelas API keys
+const (
+	ScopeProductsRead   = "products:read"
+	ScopeProductsWrite  = "products:write"
+	ScopeProductsDelete = "products:delete"
+	ScopeAuditRead      = "audit:read"
+)
+
+type APIKey struct {
+	ID        string     `json:"id"`
+	Name      string     `json:"name"`
+	KeyHash   string     `json:"key_hash"` // sha256 em hex, nunca a chave original
+	Scopes    []string   `json:"scopes"`
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`
+	Revoked   bool       `json:"revoked"`
+}
+
+// Active informa se a chave não foi revogada nem expirou
+func (k APIKey) Active(now time.Time) bool {
+	if k.Revoked {
+		return false
+	}
+	return k.ExpiresAt == nil || now.Before(*k.ExpiresAt)
+}
@@ -0,0 +1,9 @@
+package model
+
+import "github.com/izabelly/go-web/pkg/audit"
+
+type ResBodyAudit struct {
+	Message string        `json:"message"`
+	Data    []audit.Entry `json:"data"`
+	Error   bool          `json:"error"`
+}
@@ -1,13 +1,16 @@
 package model
 
+import "time"
+
 type Product struct {
-	ID          int     `json:"id"`
-	Name        string  `json:"name"`
-	Quantity    int     `json:"quantity"`
-	CodeValue   string  `json:"code_value"`
-	IsPublished bool    `json:"is_published"`
-	Expiration  string  `json:"expiration"`
-	Price       float64 `json:"price"`
+	ID          int        `json:"id"`
+	Name        string     `json:"name"`
+	Quantity    int        `json:"quantity"`
+	CodeValue   string     `json:"code_value"`
+	IsPublished bool       `json:"is_published"`
+	Expiration  string     `json:"expiration"`
+	Price       float64    `json:"price"`
+	DeletedAt   *time.Time `json:"deleted_at,omitempty"` // preenchido quando o produto vai para a lixeira
 }
 
 type ReqBodyProduct struct {
@@ -25,11 +28,42 @@ type ResBodyProduct struct {
 	Error   bool     `json:"error"`
 }
 
-type ReqPatchBodyProduct struct {
-	Name        *string  `json:"name"`
-	Quantity    *int     `json:"quantity"`
-	CodeValue   *string  `json:"code_value"`
-	IsPublished *bool    `json:"is_published,omitempty"`
-	Expiration  *string  `json:"expiration"`
-	Price       *float64 `json:"price"`
+// Operações aceitas pelo endpoint POST /products/bulk
+const (
+	BulkOpCreate = "create"
+	BulkOpUpdate = "update"
+	BulkOpDelete = "delete"
+)
+
+// Modos do bulk: atomic (tudo ou nada) e best_effort (aplica o que for válido)
+const (
+	BulkModeAtomic     = "atomic"
+	BulkModeBestEffort = "best_effort"
+)
+
+type ReqBulkOperation struct {
+	Op      string          `json:"op"`
+	ID      int             `json:"id,omitempty"`
+	Product *ReqBodyProduct `json:"product,omitempty"`
+}
+
+type ReqBulkProduct struct {
+	Mode       string             `json:"mode"`
+	Operations []ReqBulkOperation `json:"operations"`
+}
+
+type ResBulkOperation struct {
+	Index   int      `json:"index"`
+	Op      string   `json:"op"`
+	ID      int      `json:"id,omitempty"`
+	Success bool     `json:"success"`
+	Message string   `json:"message,omitempty"`
+	Data    *Product `json:"data,omitempty"`
+}
+
+type ResBulkProduct struct {
+	Message string             `json:"message"`
+	Mode    string             `json:"mode"`
+	Results []ResBulkOperation `json:"results"`
+	Error   bool               `json:"error"`
 }
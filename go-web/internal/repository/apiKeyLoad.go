@@ -0,0 +1,94 @@
+package repository
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"log"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/izabelly/go-web/internal/model"
+)
+
+// ErrAPIKeyNotFound indica que nenhuma chave tem o hash informado
+var ErrAPIKeyNotFound = errors.New("api key não encontrada")
+
+// RepositoryAPIKey lê as API keys de um arquivo JSON. O arquivo é relido quando
+// muda, então revogar uma chave não exige reiniciar o servidor.
+type RepositoryAPIKey struct {
+	FilePath string
+
+	mu      sync.RWMutex
+	keys    []model.APIKey
+	modTime time.Time
+}
+
+func NewRepositoryAPIKey(filePath string) *RepositoryAPIKey {
+	return &RepositoryAPIKey{FilePath: filePath}
+}
+
+func (r *RepositoryAPIKey) LoadAPIKeys() ([]model.APIKey, error) {
+	file, err := os.Open(r.FilePath)
+	if err != nil {
+		log.Println("Erro ao abrir arquivo", err)
+		return nil, err
+	}
+
+	defer file.Close()
+
+	var keys []model.APIKey
+	err = json.NewDecoder(file).Decode(&keys)
+	if err != nil {
+		return nil, err
+	}
+
+	return keys, nil
+}
+
+// FindAPIKey procura a chave pelo hash comparando todas em tempo constante
+func (r *RepositoryAPIKey) FindAPIKey(keyHash string) (model.APIKey, error) {
+	keys, err := r.cachedKeys()
+	if err != nil {
+		return model.APIKey{}, err
+	}
+
+	var found model.APIKey
+	match := 0
+	for _, key := range keys {
+		if subtle.ConstantTimeCompare([]byte(key.KeyHash), []byte(keyHash)) == 1 {
+			found = key
+			match = 1
+		}
+	}
+
+	if match == 0 {
+		return model.APIKey{}, ErrAPIKeyNotFound
+	}
+	return found, nil
+}
+
+func (r *RepositoryAPIKey) cachedKeys() ([]model.APIKey, error) {
+	info, err := os.Stat(r.FilePath)
+	if err != nil {
+		return nil, err
+	}
+
+	r.mu.RLock()
+	keys, fresh := r.keys, r.keys != nil && info.ModTime().Equal(r.modTime)
+	r.mu.RUnlock()
+	if fresh {
+		return keys, nil
+	}
+
+	keys, err = r.LoadAPIKeys()
+	if err != nil {
+		return nil, err
+	}
+
+	r.mu.Lock()
+	r.keys, r.modTime = keys, info.ModTime()
+	r.mu.Unlock()
+	return keys, nil
+}
@@ -10,6 +10,8 @@ import (
 
 type RepositoryProduct struct {
 	FilePath string
+	// OnWrite é chamado depois de cada gravação com sucesso (ex: invalidar cache)
+	OnWrite func()
 }
 
 func NewRepositoryProduct(filePath string) *RepositoryProduct {
@@ -48,5 +50,8 @@ func (r *RepositoryProduct) AddProduct(products []model.Product) error {
 		log.Println("Erro ao gravar no arquivo", err)
 		return err
 	}
+	if r.OnWrite != nil {
+		r.OnWrite()
+	}
 	return nil
 }
@@ -2,28 +2,117 @@ package routes
 
 import (
 	"net/http"
+	"time"
 
-	"github.com/go-chi/chi/middleware"
 	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
 	"github.com/izabelly/go-web/internal/handler"
 	"github.com/izabelly/go-web/internal/middlewares"
+	"github.com/izabelly/go-web/internal/model"
+	"github.com/izabelly/go-web/pkg/audit"
+	"github.com/izabelly/go-web/pkg/auth"
+	"github.com/izabelly/go-web/pkg/bodylimit"
+	"github.com/izabelly/go-web/pkg/compress"
+	"github.com/izabelly/go-web/pkg/cors"
+	"github.com/izabelly/go-web/pkg/httpcache"
+	"github.com/izabelly/go-web/pkg/idempotency"
+	"github.com/izabelly/go-web/pkg/ratelimit"
+	"github.com/izabelly/go-web/pkg/secure"
 )
 
-func Routes(h *handler.HandlerProduct) http.Handler {
+// Config reúne as dependências dos middlewares do roteador
+type Config struct {
+	// Keys é a origem das API keys
+	Keys middlewares.KeyStore
+	// Verifier valida tokens JWT; nil desliga o Authorization: Bearer
+	Verifier *auth.JWTVerifier
+	// Limiter guarda os buckets do rate limit; nil desliga o rate limit
+	Limiter ratelimit.Store
+	// IPLimit é o limite por IP em todas as rotas
+	IPLimit ratelimit.Limit
+	// KeyLimit é o limite por API key/subject nas rotas de /products
+	KeyLimit ratelimit.Limit
+	// Audit expõe GET /audit; nil deixa a rota de fora
+	Audit *audit.Logger
+	// Idempotency guarda as respostas do header Idempotency-Key; nil desliga
+	Idempotency idempotency.Store
+	// IdempotencyTTL é por quanto tempo uma resposta pode ser repetida
+	IdempotencyTTL time.Duration
+	// Cache guarda as leituras de /products e expõe GET /cache/stats (scope audit:read); nil desliga
+	Cache *httpcache.Cache
+	// CompressMinSize é o menor corpo de resposta comprimido com gzip
+	CompressMinSize int
+	// BodyLimit é o tamanho máximo do corpo em POST/PUT/PATCH de /products
+	BodyLimit int64
+	// BulkBodyLimit é o tamanho máximo do corpo em POST /products/bulk
+	BulkBodyLimit int64
+	// CORS libera o acesso do navegador para as origens configuradas
+	CORS cors.Config
+	// Security são os headers de segurança de todas as respostas
+	Security secure.Config
+	// ClientScopes são os scopes de cada CN de certificado de cliente (mTLS)
+	ClientScopes map[string][]string
+}
+
+func Routes(h *handler.HandlerProduct, cfg Config) http.Handler {
 	rt := chi.NewRouter()
+	rt.Use(audit.RequestID)
 	rt.Use(middleware.Logger)
 	rt.Use(middleware.Recoverer)
+	rt.Use(secure.Headers(cfg.Security))
+	rt.Use(cors.Middleware(cfg.CORS))
+	rt.Use(auth.ClientCert(cfg.ClientScopes))
+	rt.Use(compress.Middleware(compress.Config{MinSize: cfg.CompressMinSize}))
+	if cfg.Limiter != nil {
+		rt.Use(ratelimit.Middleware(cfg.Limiter, "ip", cfg.IPLimit, ratelimit.ByIP, middlewares.RespondError))
+	}
+
+	read := middlewares.RequireScope(model.ScopeProductsRead)
+	write := middlewares.RequireScope(model.ScopeProductsWrite)
+	remove := middlewares.RequireScope(model.ScopeProductsDelete)
+	limit := bodylimit.Middleware(cfg.BodyLimit)
+	bulkLimit := bodylimit.Middleware(cfg.BulkBodyLimit)
+	// idempotency fica dentro do compress: guarda o corpo sem codificação e cada
+	// repetição é comprimida conforme o Accept-Encoding da nova requisição
+	idempotent := func(next http.Handler) http.Handler { return next }
+	if cfg.Idempotency != nil {
+		idempotent = idempotency.Middleware(cfg.Idempotency, cfg.IdempotencyTTL, middlewares.RespondError)
+	}
+	cached := func(next http.Handler) http.Handler { return next }
+	if cfg.Cache != nil {
+		cached = cfg.Cache.Middleware("products")
+		// - as estatísticas mostram as chaves lidas, então exigem o mesmo scope do /audit
+		rt.Route("/cache", func(rt chi.Router) {
+			rt.Use(middlewares.Authenticate(cfg.Keys, cfg.Verifier))
+			rt.With(middlewares.RequireScope(model.ScopeAuditRead)).Get("/stats", cfg.Cache.StatsHandler)
+		})
+	}
 
 	rt.Route("/products", func(rt chi.Router) {
-		rt.Use(middlewares.Auth)
-		rt.Get("/", h.GetAllProducts)
-		rt.Get("/{id}", h.GetProductByID)
-		rt.Get("/search", h.SearchProduct)
-		rt.Post("/", h.CreateProduct)
-		rt.Put("/{id}", h.UpdateProduct)
-		rt.Delete("/{id}", h.DeleteProduct)
-		rt.Patch("/{id}", h.PatchProduct)
+		rt.Use(middlewares.Authenticate(cfg.Keys, cfg.Verifier))
+		if cfg.Limiter != nil {
+			rt.Use(ratelimit.Middleware(cfg.Limiter, "products", cfg.KeyLimit, ratelimit.ByPrincipal, middlewares.RespondError))
+		}
+		rt.With(read, cached).Get("/", h.GetAllProducts)
+		rt.With(read, cached).Get("/{id}", h.GetProductByID)
+		rt.With(read, cached).Get("/search", h.SearchProduct)
+		rt.With(remove).Get("/trash", h.GetTrash)
+		rt.With(write, limit, idempotent).Post("/", h.CreateProduct)
+		// - o scope de cada operação (write para create/update, delete para delete) é conferido no handler
+		rt.With(middlewares.RequireAnyScope(model.ScopeProductsWrite, model.ScopeProductsDelete), bulkLimit, idempotent).Post("/bulk", h.BulkProducts)
+		rt.With(write, limit).Put("/{id}", h.UpdateProduct)
+		rt.With(remove).Delete("/{id}", h.DeleteProduct)
+		rt.With(write, limit).Patch("/{id}", h.PatchProduct)
+		rt.With(remove).Post("/{id}/restore", h.RestoreProduct)
 	})
 
+	if cfg.Audit != nil {
+		ha := handler.NewAuditHandler(cfg.Audit)
+		rt.Route("/audit", func(rt chi.Router) {
+			rt.Use(middlewares.Authenticate(cfg.Keys, cfg.Verifier))
+			rt.With(middlewares.RequireScope(model.ScopeAuditRead)).Get("/", ha.GetAudit)
+		})
+	}
+
 	return rt
 }
@@ -0,0 +1,59 @@
+package routes
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/izabelly/go-web/internal/handler"
+	"github.com/izabelly/go-web/internal/model"
+	"github.com/izabelly/go-web/internal/repository"
+	"github.com/izabelly/go-web/internal/service"
+	"github.com/izabelly/go-web/pkg/auth"
+	"github.com/izabelly/go-web/pkg/httpcache"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRoutes_CacheStats(t *testing.T) {
+	// Arrange/Given
+	keys := []model.APIKey{
+		{ID: "admin", KeyHash: auth.HashKey("admin-key"), Scopes: []string{model.ScopeProductsRead, model.ScopeAuditRead}},
+		{ID: "partner", KeyHash: auth.HashKey("partner-key"), Scopes: []string{model.ScopeProductsRead}},
+	}
+	data, _ := json.Marshal(keys)
+	path := filepath.Join(t.TempDir(), "api_keys.json")
+	require.NoError(t, os.WriteFile(path, data, 0666))
+
+	h := handler.NewProductHandler(service.NewServiceProducts(repository.NewRepositoryProduct("../../docs/products_test.json")))
+	rt := Routes(h, Config{
+		Keys:  repository.NewRepositoryAPIKey(path),
+		Cache: httpcache.New(time.Minute, 10),
+	})
+
+	cases := []struct {
+		name         string
+		token        string
+		expectedCode int
+	}{
+		{"missing key", "", http.StatusUnauthorized},
+		{"key without audit:read", "partner-key", http.StatusForbidden},
+		{"key with audit:read", "admin-key", http.StatusOK},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			// Act/When
+			req := httptest.NewRequest("GET", "/cache/stats", nil)
+			req.Header.Set("API_TOKEN", c.token)
+			res := httptest.NewRecorder()
+			rt.ServeHTTP(res, req)
+
+			// Assert/Then
+			require.Equal(t, c.expectedCode, res.Code)
+		})
+	}
+}
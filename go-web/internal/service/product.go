@@ -1,18 +1,35 @@
 package service
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"log"
+	"time"
 
 	"github.com/izabelly/go-web/internal/model"
 	"github.com/izabelly/go-web/internal/repository"
+	"github.com/izabelly/go-web/pkg/audit"
+	"github.com/izabelly/go-web/pkg/auth"
 	"github.com/izabelly/go-web/pkg/validations"
 )
 
+// ErrProductNotFound indica que o produto não existe ou está na lixeira
+var ErrProductNotFound = errors.New("Produto não existe")
+
+// ErrInvalidProduct indica que o produto não passou nas validações
+var ErrInvalidProduct = errors.New("Produto inválido")
+
+// entityProduct é o nome da entidade nos registros de auditoria
+const entityProduct = "product"
+
 type ServiceProduct struct {
 	Repository *repository.RepositoryProduct
+	// Audit registra as mutações; nil desliga a auditoria
+	Audit *audit.Logger
 }
 
-func (s *ServiceProduct) AddProduct(product model.Product) (model.Product, error) {
+func (s *ServiceProduct) AddProduct(ctx context.Context, product model.Product) (model.Product, error) {
 	listProduct, err := s.Repository.LoadProducts()
 
 	validCodeValue := validations.ValidCodeValue(product.CodeValue, s.Repository)
@@ -25,13 +42,14 @@ func (s *ServiceProduct) AddProduct(product model.Product) (model.Product, error
 		return model.Product{}, fmt.Errorf("Produto inválido: %+v", product)
 	}
 
-	product.ID = len(listProduct) + 1
+	product.ID = nextProductID(listProduct)
 
 	err = s.Repository.AddProduct(append(listProduct, product))
 	if err != nil {
 		return model.Product{}, err
 	}
 
+	s.record(ctx, audit.ActionCreate, product.ID, nil, product)
 	return product, nil
 }
 
@@ -41,7 +59,7 @@ func (s *ServiceProduct) GetAllProducts() ([]model.Product, error) {
 		return nil, err
 	}
 
-	return listProduct, nil
+	return activeProducts(listProduct), nil
 }
 
 func (s *ServiceProduct) GetProductByID(id int) (model.Product, error) {
@@ -51,7 +69,7 @@ func (s *ServiceProduct) GetProductByID(id int) (model.Product, error) {
 	}
 
 	var getProduct model.Product
-	for _, prod := range listProduct {
+	for _, prod := range activeProducts(listProduct) {
 		if prod.ID == id {
 			getProduct = prod
 		}
@@ -65,7 +83,7 @@ func (s *ServiceProduct) GetProductsPrice(price float64) ([]model.Product, error
 		return nil, err
 	}
 	var getProduct []model.Product
-	for _, prod := range listProduct {
+	for _, prod := range activeProducts(listProduct) {
 		if prod.Price > price {
 			getProduct = append(getProduct, prod)
 		}
@@ -73,7 +91,7 @@ func (s *ServiceProduct) GetProductsPrice(price float64) ([]model.Product, error
 	return getProduct, nil
 }
 
-func (s *ServiceProduct) UpdateProduct(newProduct model.Product, id int) (model.Product, error) {
+func (s *ServiceProduct) UpdateProduct(ctx context.Context, newProduct model.Product, id int) (model.Product, error) {
 	listProduct, err := s.Repository.LoadProducts()
 	if err != nil {
 		return newProduct, err
@@ -84,10 +102,11 @@ func (s *ServiceProduct) UpdateProduct(newProduct model.Product, id int) (model.
 	}
 
 	var updatedList []model.Product
-	var updatedProd model.Product
+	var updatedProd, oldProd model.Product
 
 	for _, prod := range listProduct {
-		if prod.ID == id {
+		if prod.ID == id && prod.DeletedAt == nil {
+			oldProd = prod
 			validDate := validations.ValidDate(newProduct.Expiration)
 			validName := validations.ValidName(newProduct.Name)
 			validPrice := validations.ValidPrice(newProduct.Price)
@@ -117,10 +136,14 @@ func (s *ServiceProduct) UpdateProduct(newProduct model.Product, id int) (model.
 		return model.Product{}, err
 	}
 
+	if updatedProd.ID != 0 {
+		s.record(ctx, audit.ActionUpdate, id, oldProd, updatedProd)
+	}
 	return updatedProd, nil
 }
 
-func (s *ServiceProduct) DeleteProduct(id int) error {
+// DeleteProduct faz a exclusão lógica: o produto vai para a lixeira com deleted_at preenchido
+func (s *ServiceProduct) DeleteProduct(ctx context.Context, id int) error {
 	listProduct, err := s.Repository.LoadProducts()
 	if err != nil {
 		return err
@@ -130,37 +153,178 @@ func (s *ServiceProduct) DeleteProduct(id int) error {
 		return fmt.Errorf("Id inválido")
 	}
 
-	var updatedList []model.Product
+	i := indexOfProduct(listProduct, id)
+	if i < 0 {
+		return ErrProductNotFound
+	}
+
+	before := listProduct[i]
+	now := time.Now().UTC()
+	listProduct[i].DeletedAt = &now
+
+	err = s.Repository.AddProduct(listProduct)
+	if err != nil {
+		return err
+	}
+
+	s.record(ctx, audit.ActionDelete, id, before, listProduct[i])
+	return nil
+}
+
+// GetTrash retorna os produtos excluídos que ainda não foram expurgados
+func (s *ServiceProduct) GetTrash() ([]model.Product, error) {
+	listProduct, err := s.Repository.LoadProducts()
+	if err != nil {
+		return nil, err
+	}
+
+	trash := []model.Product{}
+	for _, prod := range listProduct {
+		if prod.DeletedAt != nil {
+			trash = append(trash, prod)
+		}
+	}
+	return trash, nil
+}
+
+// RestoreProduct tira o produto da lixeira
+func (s *ServiceProduct) RestoreProduct(ctx context.Context, id int) (model.Product, error) {
+	listProduct, err := s.Repository.LoadProducts()
+	if err != nil {
+		return model.Product{}, err
+	}
+
 	for i, prod := range listProduct {
-		if prod.ID == id {
-			updatedList = append(updatedList[:i], listProduct[i+1:]...)
+		if prod.ID == id && prod.DeletedAt != nil {
+			listProduct[i].DeletedAt = nil
+
+			err = s.Repository.AddProduct(listProduct)
+			if err != nil {
+				return model.Product{}, err
+			}
+			s.record(ctx, audit.ActionRestore, id, prod, listProduct[i])
+			return listProduct[i], nil
+		}
+	}
+
+	return model.Product{}, ErrProductNotFound
+}
+
+// PurgeProducts remove definitivamente os produtos que estão na lixeira há mais tempo que retention
+func (s *ServiceProduct) PurgeProducts(ctx context.Context, retention time.Duration) (int, error) {
+	listProduct, err := s.Repository.LoadProducts()
+	if err != nil {
+		return 0, err
+	}
+
+	limit := time.Now().Add(-retention)
+	keep := make([]model.Product, 0, len(listProduct))
+	var purged []model.Product
+	for _, prod := range listProduct {
+		if prod.DeletedAt == nil || prod.DeletedAt.After(limit) {
+			keep = append(keep, prod)
 		} else {
-			updatedList = append(updatedList, prod)
+			purged = append(purged, prod)
 		}
 	}
 
-	err = s.Repository.AddProduct(updatedList)
+	if len(purged) == 0 {
+		return 0, nil
+	}
+
+	err = s.Repository.AddProduct(keep)
 	if err != nil {
-		return err
+		return 0, err
 	}
 
-	return nil
+	for _, prod := range purged {
+		s.record(ctx, audit.ActionPurge, prod.ID, prod, nil)
+	}
+	return len(purged), nil
 }
 
-func (s *ServiceProduct) PatchProduct(product model.Product, id int) (model.Product, error) {
+// RunPurgeJob executa PurgeProducts a cada interval até o ctx ser cancelado
+func (s *ServiceProduct) RunPurgeJob(ctx context.Context, interval, retention time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	// o job aparece na auditoria como ator "system:purge"
+	ctx = auth.NewContext(ctx, auth.Principal{ID: "system:purge"})
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			purged, err := s.PurgeProducts(ctx, retention)
+			if err != nil {
+				log.Println("Erro ao expurgar produtos:", err)
+				continue
+			}
+			if purged > 0 {
+				log.Printf("%d produtos expurgados da lixeira", purged)
+			}
+		}
+	}
+}
+
+// PatchProduct carrega o produto, aplica a função de patch e valida o resultado uma única vez.
+// O id e o deleted_at não podem ser alterados pelo patch.
+func (s *ServiceProduct) PatchProduct(ctx context.Context, id int, apply func(model.Product) (model.Product, error)) (model.Product, error) {
 	if id == 0 {
 		return model.Product{}, fmt.Errorf("Id inválido")
 	}
 
-	prod, err := s.UpdateProduct(product, id)
+	listProduct, err := s.Repository.LoadProducts()
 	if err != nil {
 		return model.Product{}, err
 	}
 
-	return prod, nil
+	i := indexOfProduct(listProduct, id)
+	if i < 0 {
+		return model.Product{}, ErrProductNotFound
+	}
+
+	product, err := apply(listProduct[i])
+	if err != nil {
+		return model.Product{}, err
+	}
+	product.ID = id
+	product.DeletedAt = nil
+
+	if !validProduct(product) || !validations.ValidCodeValueList(product.CodeValue, listProduct, id) {
+		return model.Product{}, fmt.Errorf("%w: %+v", ErrInvalidProduct, product)
+	}
+
+	before := listProduct[i]
+	listProduct[i] = product
+	err = s.Repository.AddProduct(listProduct)
+	if err != nil {
+		return model.Product{}, err
+	}
+
+	s.record(ctx, audit.ActionUpdate, id, before, product)
+	return product, nil
 }
 
 // Retornar instancia de ServiceProduct e inicializando o FilePath com o valor
 func NewServiceProducts(repo *repository.RepositoryProduct) *ServiceProduct {
 	return &ServiceProduct{Repository: repo}
 }
+
+// record grava a auditoria sem desfazer a mutação se o store falhar
+func (s *ServiceProduct) record(ctx context.Context, action string, id int, before, after any) {
+	if err := s.Audit.Record(ctx, action, entityProduct, id, before, after); err != nil {
+		log.Println("Erro ao gravar auditoria:", err)
+	}
+}
+
+func activeProducts(listProduct []model.Product) []model.Product {
+	active := make([]model.Product, 0, len(listProduct))
+	for _, prod := range listProduct {
+		if prod.DeletedAt == nil {
+			active = append(active, prod)
+		}
+	}
+	return active
+}
@@ -0,0 +1,174 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/izabelly/go-web/internal/model"
+	"github.com/izabelly/go-web/pkg/audit"
+	"github.com/izabelly/go-web/pkg/validations"
+)
+
+// ErrBulkRejected indica que um bulk em modo atomic tinha operações inválidas e nada foi gravado
+var ErrBulkRejected = errors.New("bulk rejeitado: existem operações inválidas")
+
+// BulkProducts aplica as operações em memória sobre a lista carregada uma única vez
+// e grava o arquivo apenas no final. No modo atomic qualquer falha cancela o lote inteiro.
+func (s *ServiceProduct) BulkProducts(ctx context.Context, operations []model.ReqBulkOperation, mode string) ([]model.ResBulkOperation, error) {
+	listProduct, err := s.Repository.LoadProducts()
+	if err != nil {
+		return nil, err
+	}
+
+	// auditoria de cada operação aplicada, gravada só depois do arquivo
+	type change struct {
+		action        string
+		before, after any
+		id            int
+	}
+	var changes []change
+
+	results := make([]model.ResBulkOperation, len(operations))
+	failed, changed := false, false
+	for i, op := range operations {
+		var before any
+		if j := indexOfProduct(listProduct, op.ID); j >= 0 && op.Op != model.BulkOpCreate {
+			before = listProduct[j]
+		}
+		updatedList, product, err := applyBulkOperation(listProduct, op)
+
+		results[i] = model.ResBulkOperation{Index: i, Op: op.Op, ID: op.ID}
+		if err != nil {
+			failed = true
+			results[i].Message = err.Error()
+			continue
+		}
+
+		listProduct = updatedList
+		changed = true
+		results[i].Success = true
+		results[i].ID = product.ID
+		if op.Op != model.BulkOpDelete {
+			results[i].Data = &product
+		}
+		changes = append(changes, change{action: op.Op, before: before, after: product, id: product.ID})
+	}
+
+	if failed && mode == model.BulkModeAtomic {
+		// nada foi gravado: as operações válidas também não foram aplicadas e os ids
+		// atribuídos aos creates não existem
+		for i := range results {
+			if results[i].Success {
+				results[i] = model.ResBulkOperation{Index: i, Op: operations[i].Op, ID: operations[i].ID, Message: "Não aplicada: o lote foi rejeitado"}
+			}
+		}
+		return results, ErrBulkRejected
+	}
+
+	if changed {
+		err = s.Repository.AddProduct(listProduct)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	for _, c := range changes {
+		s.record(ctx, bulkAuditAction[c.action], c.id, c.before, c.after)
+	}
+	return results, nil
+}
+
+var bulkAuditAction = map[string]string{
+	model.BulkOpCreate: audit.ActionCreate,
+	model.BulkOpUpdate: audit.ActionUpdate,
+	model.BulkOpDelete: audit.ActionDelete,
+}
+
+func applyBulkOperation(listProduct []model.Product, op model.ReqBulkOperation) ([]model.Product, model.Product, error) {
+	switch op.Op {
+	case model.BulkOpCreate:
+		if op.Product == nil {
+			return listProduct, model.Product{}, fmt.Errorf("Produto obrigatório para create")
+		}
+
+		product := productFromBody(0, *op.Product)
+		if !validProduct(product) || !validations.ValidCodeValueList(product.CodeValue, listProduct, 0) {
+			return listProduct, model.Product{}, fmt.Errorf("Produto inválido: %+v", product)
+		}
+
+		product.ID = nextProductID(listProduct)
+		return append(listProduct, product), product, nil
+
+	case model.BulkOpUpdate:
+		if op.Product == nil {
+			return listProduct, model.Product{}, fmt.Errorf("Produto obrigatório para update")
+		}
+
+		i := indexOfProduct(listProduct, op.ID)
+		if i < 0 {
+			return listProduct, model.Product{}, fmt.Errorf("Produto não existe: %d", op.ID)
+		}
+
+		product := productFromBody(op.ID, *op.Product)
+		if !validProduct(product) || !validations.ValidCodeValueList(product.CodeValue, listProduct, op.ID) {
+			return listProduct, model.Product{}, fmt.Errorf("Produto inválido: %+v", product)
+		}
+
+		listProduct[i] = product
+		return listProduct, product, nil
+
+	case model.BulkOpDelete:
+		i := indexOfProduct(listProduct, op.ID)
+		if i < 0 {
+			return listProduct, model.Product{}, fmt.Errorf("Produto não existe: %d", op.ID)
+		}
+
+		now := time.Now().UTC()
+		listProduct[i].DeletedAt = &now
+		return listProduct, listProduct[i], nil
+	}
+
+	return listProduct, model.Product{}, fmt.Errorf("Operação inválida: %q", op.Op)
+}
+
+func productFromBody(id int, reqBody model.ReqBodyProduct) model.Product {
+	return model.Product{
+		ID:          id,
+		Name:        reqBody.Name,
+		Quantity:    reqBody.Quantity,
+		CodeValue:   reqBody.CodeValue,
+		IsPublished: reqBody.IsPublished,
+		Expiration:  reqBody.Expiration,
+		Price:       reqBody.Price,
+	}
+}
+
+func validProduct(product model.Product) bool {
+	return validations.ValidName(product.Name) &&
+		validations.ValidDate(product.Expiration) &&
+		validations.ValidPrice(product.Price) &&
+		validations.ValidQuantity(product.Quantity)
+}
+
+// indexOfProduct ignora produtos que estão na lixeira
+func indexOfProduct(listProduct []model.Product, id int) int {
+	for i, prod := range listProduct {
+		if prod.ID == id && prod.DeletedAt == nil {
+			return i
+		}
+	}
+	return -1
+}
+
+// nextProductID usa o maior id + 1 para não repetir ids depois de exclusões
+func nextProductID(listProduct []model.Product) int {
+	next := 1
+	for _, prod := range listProduct {
+		if prod.ID >= next {
+			next = prod.ID + 1
+		}
+	}
+	return next
+}
@@ -0,0 +1,204 @@
+// Package audit registra quem alterou o quê: ator, ação, entidade, diff de campos
+// e request id de cada mutação, em um store somente de inclusão.
+package audit
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"reflect"
+	"time"
+
+	"github.com/izabelly/go-web/pkg/auth"
+)
+
+// Ações registradas
+const (
+	ActionCreate  = "create"
+	ActionUpdate  = "update"
+	ActionDelete  = "delete"
+	ActionRestore = "restore"
+	ActionPurge   = "purge"
+)
+
+// Change é o valor de um campo antes e depois da mutação
+type Change struct {
+	Before any `json:"before"`
+	After  any `json:"after"`
+}
+
+// Entry é um registro de auditoria
+type Entry struct {
+	Timestamp time.Time         `json:"timestamp"`
+	Actor     string            `json:"actor"`
+	Action    string            `json:"action"`
+	Entity    string            `json:"entity"`
+	EntityID  string            `json:"entity_id"`
+	RequestID string            `json:"request_id,omitempty"`
+	Diff      map[string]Change `json:"diff,omitempty"`
+}
+
+// Filter seleciona registros; campos vazios não filtram
+type Filter struct {
+	Entity   string
+	EntityID string
+	From     time.Time
+	To       time.Time
+}
+
+// Match informa se o registro atende o filtro
+func (f Filter) Match(e Entry) bool {
+	if f.Entity != "" && e.Entity != f.Entity {
+		return false
+	}
+	if f.EntityID != "" && e.EntityID != f.EntityID {
+		return false
+	}
+	if !f.From.IsZero() && e.Timestamp.Before(f.From) {
+		return false
+	}
+	if !f.To.IsZero() && e.Timestamp.After(f.To) {
+		return false
+	}
+	return true
+}
+
+// ParseFilter lê ?entity=&id=&from=&to= (from e to em RFC 3339)
+func ParseFilter(query url.Values) (f Filter, err error) {
+	f.Entity = query.Get("entity")
+	f.EntityID = query.Get("id")
+	if from := query.Get("from"); from != "" {
+		if f.From, err = time.Parse(time.RFC3339, from); err != nil {
+			return Filter{}, fmt.Errorf("audit: from inválido: %w", err)
+		}
+	}
+	if to := query.Get("to"); to != "" {
+		if f.To, err = time.Parse(time.RFC3339, to); err != nil {
+			return Filter{}, fmt.Errorf("audit: to inválido: %w", err)
+		}
+	}
+	return f, nil
+}
+
+// Store é um armazenamento somente de inclusão
+type Store interface {
+	// Append grava um novo registro; registros nunca são alterados
+	Append(e Entry) error
+	// Query retorna os registros do filtro em ordem cronológica
+	Query(f Filter) ([]Entry, error)
+}
+
+// Logger monta os registros a partir do context e grava no Store.
+// Um Logger nil não registra nada, o que deixa a auditoria opcional.
+type Logger struct {
+	store Store
+	now   func() time.Time
+}
+
+func NewLogger(store Store) *Logger {
+	return &Logger{store: store, now: time.Now}
+}
+
+// Record registra a mutação de entity/id. before é nil na criação e after é nil na remoção.
+func (l *Logger) Record(ctx context.Context, action, entity string, id any, before, after any) error {
+	if l == nil {
+		return nil
+	}
+
+	diff, err := Diff(before, after)
+	if err != nil {
+		return err
+	}
+
+	actor := "anonymous"
+	if principal, ok := auth.FromContext(ctx); ok && principal.ID != "" {
+		actor = principal.ID
+	}
+	requestID, _ := RequestIDFromContext(ctx)
+
+	return l.store.Append(Entry{
+		Timestamp: l.now().UTC(),
+		Actor:     actor,
+		Action:    action,
+		Entity:    entity,
+		EntityID:  fmt.Sprint(id),
+		RequestID: requestID,
+		Diff:      diff,
+	})
+}
+
+// Query repassa a consulta para o Store
+func (l *Logger) Query(f Filter) ([]Entry, error) {
+	return l.store.Query(f)
+}
+
+// Diff compara os campos JSON de before e after e retorna apenas os que mudaram
+func Diff(before, after any) (map[string]Change, error) {
+	b, err := fields(before)
+	if err != nil {
+		return nil, err
+	}
+	a, err := fields(after)
+	if err != nil {
+		return nil, err
+	}
+
+	diff := map[string]Change{}
+	for key, value := range b {
+		if !reflect.DeepEqual(value, a[key]) {
+			diff[key] = Change{Before: value, After: a[key]}
+		}
+	}
+	for key, value := range a {
+		if _, ok := b[key]; !ok {
+			diff[key] = Change{Before: nil, After: value}
+		}
+	}
+	return diff, nil
+}
+
+func fields(v any) (map[string]any, error) {
+	out := map[string]any{}
+	if v == nil || (reflect.ValueOf(v).Kind() == reflect.Pointer && reflect.ValueOf(v).IsNil()) {
+		return out, nil
+	}
+
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	err = json.Unmarshal(data, &out)
+	return out, err
+}
+
+type requestIDKey struct{}
+
+// WithRequestID guarda o request id no context
+func WithRequestID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, requestIDKey{}, id)
+}
+
+// RequestIDFromContext retorna o request id guardado por RequestID
+func RequestIDFromContext(ctx context.Context) (string, bool) {
+	id, ok := ctx.Value(requestIDKey{}).(string)
+	return id, ok
+}
+
+// RequestID usa o header X-Request-Id (ou gera um) e o devolve na resposta
+func RequestID(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id := r.Header.Get("X-Request-Id")
+		if id == "" {
+			raw := make([]byte, 8)
+			rand.Read(raw)
+			id = hex.EncodeToString(raw)
+		}
+
+		w.Header().Set("X-Request-Id", id)
+		next.ServeHTTP(w, r.WithContext(WithRequestID(r.Context(), id)))
+	})
+}
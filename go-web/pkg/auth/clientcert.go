@@ -0,0 +1,42 @@
+package auth
+
+import (
+	"net/http"
+	"strings"
+)
+
+// ClientCert coloca no context o principal do certificado de cliente já
+// validado no handshake (mTLS). O ID é o CN do subject e os scopes vêm de
+// scopes[CN]. Requisições sem certificado seguem sem principal.
+func ClientCert(scopes map[string][]string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			// VerifiedChains só é preenchido quando o certificado foi validado contra as CAs
+			if r.TLS == nil || len(r.TLS.VerifiedChains) == 0 {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			subject := r.TLS.VerifiedChains[0][0].Subject
+			principal := Principal{
+				ID:     subject.CommonName,
+				Name:   subject.String(),
+				Scopes: scopes[subject.CommonName],
+			}
+			next.ServeHTTP(w, r.WithContext(NewContext(r.Context(), principal)))
+		})
+	}
+}
+
+// ParseScopeMap lê "cn=scope1 scope2;outro-cn=scope1" (formato das envs)
+func ParseScopeMap(value string) map[string][]string {
+	scopes := map[string][]string{}
+	for _, entry := range strings.Split(value, ";") {
+		cn, list, ok := strings.Cut(entry, "=")
+		if !ok || strings.TrimSpace(cn) == "" {
+			continue
+		}
+		scopes[strings.TrimSpace(cn)] = strings.Fields(list)
+	}
+	return scopes
+}
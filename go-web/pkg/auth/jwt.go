@@ -0,0 +1,323 @@
+package auth
+
+import (
+	"crypto"
+	"crypto/ecdh"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/hmac"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+	"strings"
+	"time"
+)
+
+var (
+	// ErrInvalidToken indica um token mal formado ou com assinatura inválida
+	ErrInvalidToken = errors.New("token inválido")
+	// ErrTokenExpired indica que o exp já passou ou o nbf ainda não chegou
+	ErrTokenExpired = errors.New("token expirado ou ainda não válido")
+	// ErrInvalidClaims indica iss ou aud diferentes do configurado
+	ErrInvalidClaims = errors.New("claims inválidas")
+)
+
+// JWTConfig configura a verificação local de tokens. Basta um entre Secret (HS256)
+// e JWKSFile (RS256/ES256); com os dois, o alg do token decide qual é usado.
+type JWTConfig struct {
+	// Secret é o segredo compartilhado para HS256
+	Secret []byte
+	// JWKSFile é o caminho de um arquivo JWKS com as chaves públicas
+	JWKSFile string
+	// Issuer, se preenchido, precisa ser igual ao claim iss
+	Issuer string
+	// Audience, se preenchido, precisa estar no claim aud
+	Audience string
+	// Leeway é a tolerância de relógio para exp e nbf
+	Leeway time.Duration
+}
+
+// Claims são as claims do payload do token
+type Claims map[string]any
+
+// Subject retorna o claim sub
+func (c Claims) Subject() string {
+	sub, _ := c["sub"].(string)
+	return sub
+}
+
+// Scopes lê o claim scope (separado por espaço) ou scopes (lista)
+func (c Claims) Scopes() []string {
+	if scope, ok := c["scope"].(string); ok {
+		return strings.Fields(scope)
+	}
+
+	var scopes []string
+	if list, ok := c["scopes"].([]any); ok {
+		for _, s := range list {
+			if str, ok := s.(string); ok {
+				scopes = append(scopes, str)
+			}
+		}
+	}
+	return scopes
+}
+
+// Principal converte as claims no principal usado pela autorização
+func (c Claims) Principal() Principal {
+	name, _ := c["name"].(string)
+	return Principal{ID: c.Subject(), Name: name, Scopes: c.Scopes()}
+}
+
+// JWTVerifier valida tokens JWT sem chamadas externas
+type JWTVerifier struct {
+	cfg  JWTConfig
+	keys map[string]crypto.PublicKey
+	now  func() time.Time
+}
+
+// NewJWTVerifier cria o verificador carregando o JWKS, se configurado
+func NewJWTVerifier(cfg JWTConfig) (*JWTVerifier, error) {
+	if len(cfg.Secret) == 0 && cfg.JWKSFile == "" {
+		return nil, errors.New("jwt: informe Secret ou JWKSFile")
+	}
+
+	v := &JWTVerifier{cfg: cfg, keys: map[string]crypto.PublicKey{}, now: time.Now}
+	if cfg.JWKSFile != "" {
+		data, err := os.ReadFile(cfg.JWKSFile)
+		if err != nil {
+			return nil, fmt.Errorf("jwt: erro ao ler jwks: %w", err)
+		}
+		v.keys, err = parseJWKS(data)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return v, nil
+}
+
+// Verify confere assinatura, exp, nbf, iss e aud e retorna as claims
+func (v *JWTVerifier) Verify(token string) (Claims, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return nil, ErrInvalidToken
+	}
+
+	var header struct {
+		Alg string `json:"alg"`
+		Kid string `json:"kid"`
+	}
+	if err := decodeSegment(parts[0], &header); err != nil {
+		return nil, ErrInvalidToken
+	}
+
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return nil, ErrInvalidToken
+	}
+	if err := v.verifySignature(header.Alg, header.Kid, parts[0]+"."+parts[1], signature); err != nil {
+		return nil, err
+	}
+
+	var claims Claims
+	if err := decodeSegment(parts[1], &claims); err != nil {
+		return nil, ErrInvalidToken
+	}
+	if err := v.validateClaims(claims); err != nil {
+		return nil, err
+	}
+	return claims, nil
+}
+
+func (v *JWTVerifier) verifySignature(alg, kid, signingInput string, signature []byte) error {
+	digest := sha256.Sum256([]byte(signingInput))
+
+	switch alg {
+	case "HS256":
+		if len(v.cfg.Secret) == 0 {
+			return ErrInvalidToken
+		}
+		mac := hmac.New(sha256.New, v.cfg.Secret)
+		mac.Write([]byte(signingInput))
+		if !hmac.Equal(mac.Sum(nil), signature) {
+			return ErrInvalidToken
+		}
+		return nil
+
+	case "RS256":
+		key, ok := v.publicKey(kid).(*rsa.PublicKey)
+		if !ok || rsa.VerifyPKCS1v15(key, crypto.SHA256, digest[:], signature) != nil {
+			return ErrInvalidToken
+		}
+		return nil
+
+	case "ES256":
+		key, ok := v.publicKey(kid).(*ecdsa.PublicKey)
+		if !ok || len(signature) != 64 {
+			return ErrInvalidToken
+		}
+		r := new(big.Int).SetBytes(signature[:32])
+		s := new(big.Int).SetBytes(signature[32:])
+		if !ecdsa.Verify(key, digest[:], r, s) {
+			return ErrInvalidToken
+		}
+		return nil
+	}
+
+	// "none" e qualquer outro alg são recusados
+	return ErrInvalidToken
+}
+
+// publicKey busca pelo kid; sem kid, só aceita se o JWKS tiver uma única chave
+func (v *JWTVerifier) publicKey(kid string) crypto.PublicKey {
+	if kid != "" {
+		return v.keys[kid]
+	}
+	if len(v.keys) == 1 {
+		for _, key := range v.keys {
+			return key
+		}
+	}
+	return nil
+}
+
+func (v *JWTVerifier) validateClaims(claims Claims) error {
+	now := v.now()
+
+	exp, ok := claims["exp"].(float64)
+	if !ok {
+		return fmt.Errorf("%w: exp obrigatório", ErrInvalidClaims)
+	}
+	if now.After(time.Unix(int64(exp), 0).Add(v.cfg.Leeway)) {
+		return ErrTokenExpired
+	}
+
+	if nbf, ok := claims["nbf"].(float64); ok {
+		if now.Add(v.cfg.Leeway).Before(time.Unix(int64(nbf), 0)) {
+			return ErrTokenExpired
+		}
+	}
+
+	if v.cfg.Issuer != "" {
+		if iss, _ := claims["iss"].(string); iss != v.cfg.Issuer {
+			return fmt.Errorf("%w: iss", ErrInvalidClaims)
+		}
+	}
+
+	if v.cfg.Audience != "" && !hasAudience(claims["aud"], v.cfg.Audience) {
+		return fmt.Errorf("%w: aud", ErrInvalidClaims)
+	}
+	return nil
+}
+
+func hasAudience(aud any, expected string) bool {
+	switch value := aud.(type) {
+	case string:
+		return value == expected
+	case []any:
+		for _, a := range value {
+			if a == expected {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func decodeSegment(segment string, v any) error {
+	data, err := base64.RawURLEncoding.DecodeString(segment)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, v)
+}
+
+type jwk struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Crv string `json:"crv"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+}
+
+// parseJWKS lê as chaves RSA e EC (P-256) de um documento JWKS
+func parseJWKS(data []byte) (map[string]crypto.PublicKey, error) {
+	var set struct {
+		Keys []jwk `json:"keys"`
+	}
+	if err := json.Unmarshal(data, &set); err != nil {
+		return nil, fmt.Errorf("jwt: jwks inválido: %w", err)
+	}
+
+	keys := make(map[string]crypto.PublicKey, len(set.Keys))
+	for i, k := range set.Keys {
+		var key crypto.PublicKey
+		var err error
+		switch k.Kty {
+		case "RSA":
+			key, err = rsaKey(k)
+		case "EC":
+			key, err = ecKey(k)
+		default:
+			err = fmt.Errorf("kty %q não suportado", k.Kty)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("jwt: chave %d do jwks: %w", i, err)
+		}
+		keys[k.Kid] = key
+	}
+	return keys, nil
+}
+
+func rsaKey(k jwk) (*rsa.PublicKey, error) {
+	n, err := base64.RawURLEncoding.DecodeString(k.N)
+	if err != nil {
+		return nil, err
+	}
+	e, err := base64.RawURLEncoding.DecodeString(k.E)
+	if err != nil {
+		return nil, err
+	}
+
+	exponent := new(big.Int).SetBytes(e)
+	if !exponent.IsInt64() || exponent.Int64() < 3 {
+		return nil, errors.New("expoente RSA inválido")
+	}
+	return &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: int(exponent.Int64())}, nil
+}
+
+func ecKey(k jwk) (*ecdsa.PublicKey, error) {
+	if k.Crv != "P-256" {
+		return nil, fmt.Errorf("curva %q não suportada", k.Crv)
+	}
+	x, err := base64.RawURLEncoding.DecodeString(k.X)
+	if err != nil {
+		return nil, err
+	}
+	y, err := base64.RawURLEncoding.DecodeString(k.Y)
+	if err != nil {
+		return nil, err
+	}
+	if len(x) != 32 || len(y) != 32 {
+		return nil, errors.New("coordenadas EC inválidas")
+	}
+
+	// valida que o ponto está na curva
+	point := append(append([]byte{4}, x...), y...)
+	if _, err := ecdh.P256().NewPublicKey(point); err != nil {
+		return nil, err
+	}
+
+	return &ecdsa.PublicKey{
+		Curve: elliptic.P256(),
+		X:     new(big.Int).SetBytes(x),
+		Y:     new(big.Int).SetBytes(y),
+	}, nil
+}
@@ -0,0 +1,187 @@
+package auth
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func b64(data []byte) string {
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+func signToken(t *testing.T, alg, kid string, claims map[string]any, key any) string {
+	t.Helper()
+	header, _ := json.Marshal(map[string]string{"alg": alg, "kid": kid, "typ": "JWT"})
+	payload, _ := json.Marshal(claims)
+	input := b64(header) + "." + b64(payload)
+	digest := sha256.Sum256([]byte(input))
+
+	var signature []byte
+	switch k := key.(type) {
+	case []byte:
+		mac := hmac.New(sha256.New, k)
+		mac.Write([]byte(input))
+		signature = mac.Sum(nil)
+	case *rsa.PrivateKey:
+		var err error
+		signature, err = rsa.SignPKCS1v15(rand.Reader, k, crypto.SHA256, digest[:])
+		require.NoError(t, err)
+	case *ecdsa.PrivateKey:
+		r, s, err := ecdsa.Sign(rand.Reader, k, digest[:])
+		require.NoError(t, err)
+		signature = make([]byte, 64)
+		r.FillBytes(signature[:32])
+		s.FillBytes(signature[32:])
+	}
+	return input + "." + b64(signature)
+}
+
+func TestJWTVerifier(t *testing.T) {
+	// Arrange/Given
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	secret := []byte("segredo-de-teste")
+
+	jwks := map[string]any{"keys": []map[string]string{
+		{"kty": "RSA", "kid": "rsa-1", "n": b64(rsaKey.N.Bytes()), "e": b64(big.NewInt(int64(rsaKey.E)).Bytes())},
+		{"kty": "EC", "kid": "ec-1", "crv": "P-256", "x": b64(ecKey.X.FillBytes(make([]byte, 32))), "y": b64(ecKey.Y.FillBytes(make([]byte, 32)))},
+	}}
+	data, _ := json.Marshal(jwks)
+	path := filepath.Join(t.TempDir(), "jwks.json")
+	require.NoError(t, os.WriteFile(path, data, 0666))
+
+	v, err := NewJWTVerifier(JWTConfig{Secret: secret, JWKSFile: path, Issuer: "gateway", Audience: "go-web"})
+	require.NoError(t, err)
+
+	now := time.Now().Unix()
+	valid := map[string]any{"sub": "user-1", "iss": "gateway", "aud": []string{"go-web"}, "exp": now + 60, "scope": "products:read"}
+	with := func(key string, value any) map[string]any {
+		claims := map[string]any{}
+		for k, v := range valid {
+			claims[k] = v
+		}
+		claims[key] = value
+		return claims
+	}
+
+	cases := []struct {
+		name        string
+		token       string
+		expectedErr error
+	}{
+		{"HS256", signToken(t, "HS256", "", valid, secret), nil},
+		{"RS256", signToken(t, "RS256", "rsa-1", valid, rsaKey), nil},
+		{"ES256", signToken(t, "ES256", "ec-1", valid, ecKey), nil},
+		{"wrong secret", signToken(t, "HS256", "", valid, []byte("outro")), ErrInvalidToken},
+		{"wrong kid", signToken(t, "RS256", "ec-1", valid, rsaKey), ErrInvalidToken},
+		{"alg none", signToken(t, "none", "", valid, []byte{}), ErrInvalidToken},
+		{"expired", signToken(t, "HS256", "", with("exp", now-60), secret), ErrTokenExpired},
+		{"not before", signToken(t, "HS256", "", with("nbf", now+60), secret), ErrTokenExpired},
+		{"wrong issuer", signToken(t, "HS256", "", with("iss", "other"), secret), ErrInvalidClaims},
+		{"wrong audience", signToken(t, "HS256", "", with("aud", "other"), secret), ErrInvalidClaims},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			// Act/When
+			claims, err := v.Verify(c.token)
+
+			// Assert/Then
+			if c.expectedErr != nil {
+				require.ErrorIs(t, err, c.expectedErr)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, "user-1", claims.Subject())
+			require.Equal(t, []string{"products:read"}, claims.Scopes())
+		})
+	}
+}
+
+func TestBearer(t *testing.T) {
+	// Arrange/Given
+	secret := []byte("segredo-de-teste")
+	v, err := NewJWTVerifier(JWTConfig{Secret: secret})
+	require.NoError(t, err)
+
+	rt := Bearer(v, nil)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		claims, _ := ClaimsFromContext(r.Context())
+		w.Write([]byte(claims.Subject()))
+	}))
+
+	t.Run("valid token", func(t *testing.T) {
+		// Act/When
+		req := httptest.NewRequest("GET", "/", nil)
+		req.Header.Set("Authorization", "Bearer "+signToken(t, "HS256", "", map[string]any{"sub": "user-1", "exp": time.Now().Unix() + 60}, secret))
+		res := httptest.NewRecorder()
+		rt.ServeHTTP(res, req)
+
+		// Assert/Then
+		require.Equal(t, http.StatusOK, res.Code)
+		require.Equal(t, "user-1", res.Body.String())
+	})
+
+	t.Run("missing token", func(t *testing.T) {
+		// Act/When
+		res := httptest.NewRecorder()
+		rt.ServeHTTP(res, httptest.NewRequest("GET", "/", nil))
+
+		// Assert/Then
+		require.Equal(t, http.StatusUnauthorized, res.Code)
+		require.JSONEq(t, `{"status": "Unauthorized", "message": "missing bearer token"}`, res.Body.String())
+	})
+}
+
+func TestRequireScope(t *testing.T) {
+	// Arrange/Given
+	rt := RequireScope("tickets:reload", nil)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}))
+
+	cases := []struct {
+		name            string
+		principal       *Principal
+		expectedCode    int
+		expectedMessage string
+	}{
+		{"without principal", nil, http.StatusUnauthorized, "authentication required"},
+		{"principal without the scope", &Principal{ID: "user-1", Scopes: []string{"audit:read"}}, http.StatusForbidden, "missing scope tickets:reload"},
+		{"principal with the scope", &Principal{ID: "user-1", Scopes: []string{"tickets:reload"}}, http.StatusNoContent, ""},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			// Act/When
+			req := httptest.NewRequest("POST", "/", nil)
+			if c.principal != nil {
+				req = req.WithContext(NewContext(req.Context(), *c.principal))
+			}
+			res := httptest.NewRecorder()
+			rt.ServeHTTP(res, req)
+
+			// Assert/Then
+			require.Equal(t, c.expectedCode, res.Code)
+			if c.expectedMessage != "" {
+				require.JSONEq(t, `{"status": "`+http.StatusText(c.expectedCode)+`", "message": "`+c.expectedMessage+`"}`, res.Body.String())
+			}
+		})
+	}
+}
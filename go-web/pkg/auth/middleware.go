@@ -0,0 +1,102 @@
+package auth
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strings"
+)
+
+// ErrorFunc escreve a resposta de erro no formato de cada serviço.
+// A assinatura é a mesma de response.Error do bootcamp-go/web.
+type ErrorFunc func(w http.ResponseWriter, statusCode int, message string)
+
+type claimsKey struct{}
+
+// ClaimsFromContext retorna as claims do token validado por Bearer
+func ClaimsFromContext(ctx context.Context) (Claims, bool) {
+	claims, ok := ctx.Value(claimsKey{}).(Claims)
+	return claims, ok
+}
+
+// BearerToken extrai o token do header Authorization: Bearer <token>
+func BearerToken(r *http.Request) (string, bool) {
+	header := r.Header.Get("Authorization")
+	scheme, token, ok := strings.Cut(header, " ")
+	if !ok || !strings.EqualFold(scheme, "Bearer") || strings.TrimSpace(token) == "" {
+		return "", false
+	}
+	return strings.TrimSpace(token), true
+}
+
+// Bearer exige um JWT válido no header Authorization e coloca as claims e o
+// principal no context. Requisições já autenticadas por certificado (ClientCert)
+// e sem Authorization passam direto. Com onError nil o erro é escrito como {"status", "message"}.
+func Bearer(v *JWTVerifier, onError ErrorFunc) func(http.Handler) http.Handler {
+	if onError == nil {
+		onError = WriteError
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			token, ok := BearerToken(r)
+			if _, authenticated := FromContext(r.Context()); !ok && authenticated {
+				next.ServeHTTP(w, r)
+				return
+			}
+			if !ok {
+				w.Header().Set("WWW-Authenticate", "Bearer")
+				onError(w, http.StatusUnauthorized, "missing bearer token")
+				return
+			}
+
+			claims, err := v.Verify(token)
+			if err != nil {
+				w.Header().Set("WWW-Authenticate", `Bearer error="invalid_token"`)
+				onError(w, http.StatusUnauthorized, "invalid bearer token")
+				return
+			}
+
+			next.ServeHTTP(w, r.WithContext(WithClaims(r.Context(), claims)))
+		})
+	}
+}
+
+// RequireScope responde 401 sem principal no context (jwt ou certificado) e 403
+// quando o principal não tem o scope. Com onError nil o erro é escrito como {"status", "message"}.
+func RequireScope(scope string, onError ErrorFunc) func(http.Handler) http.Handler {
+	if onError == nil {
+		onError = WriteError
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			principal, ok := FromContext(r.Context())
+			if !ok {
+				onError(w, http.StatusUnauthorized, "authentication required")
+				return
+			}
+			if !principal.HasScope(scope) {
+				onError(w, http.StatusForbidden, "missing scope "+scope)
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// WithClaims guarda as claims e o principal derivado delas no context
+func WithClaims(ctx context.Context, claims Claims) context.Context {
+	ctx = context.WithValue(ctx, claimsKey{}, claims)
+	return NewContext(ctx, claims.Principal())
+}
+
+// WriteError é o ErrorFunc padrão: {"status": "...", "message": "..."}
+func WriteError(w http.ResponseWriter, statusCode int, message string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(statusCode)
+	json.NewEncoder(w).Encode(map[string]string{
+		"status":  http.StatusText(statusCode),
+		"message": message,
+	})
+}
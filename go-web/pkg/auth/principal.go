@@ -0,0 +1,48 @@
+// Package auth guarda quem está fazendo a requisição (principal) no context,
+// para que middlewares de autenticação e handlers falem a mesma língua.
+package auth
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+)
+
+// Principal representa o chamador autenticado
+type Principal struct {
+	// ID identifica a chave, o subject do token ou o certificado
+	ID string `json:"id"`
+	// Name é um nome amigável para logs
+	Name string `json:"name,omitempty"`
+	// Scopes são as permissões concedidas (ex: products:read)
+	Scopes []string `json:"scopes,omitempty"`
+}
+
+// HasScope informa se o principal possui o scope
+func (p Principal) HasScope(scope string) bool {
+	for _, s := range p.Scopes {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
+
+type principalKey struct{}
+
+// NewContext retorna uma cópia do ctx carregando o principal
+func NewContext(ctx context.Context, p Principal) context.Context {
+	return context.WithValue(ctx, principalKey{}, p)
+}
+
+// FromContext retorna o principal autenticado, se houver
+func FromContext(ctx context.Context) (Principal, bool) {
+	p, ok := ctx.Value(principalKey{}).(Principal)
+	return p, ok
+}
+
+// HashKey gera o hash (sha256 em hex) usado para guardar API keys sem o valor original
+func HashKey(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,53 @@
+package bodylimit
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestMiddleware(t *testing.T) {
+	// Arrange/Given
+	rt := Middleware(10)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		w.Write(body)
+	}))
+
+	t.Run("body within the limit", func(t *testing.T) {
+		// Act/When
+		res := httptest.NewRecorder()
+		rt.ServeHTTP(res, httptest.NewRequest("POST", "/", strings.NewReader("0123456789")))
+
+		// Assert/Then
+		require.Equal(t, http.StatusOK, res.Code)
+		require.Equal(t, "0123456789", res.Body.String())
+	})
+
+	t.Run("content-length over the limit", func(t *testing.T) {
+		// Act/When
+		res := httptest.NewRecorder()
+		rt.ServeHTTP(res, httptest.NewRequest("POST", "/", strings.NewReader("0123456789A")))
+
+		// Assert/Then
+		require.Equal(t, http.StatusRequestEntityTooLarge, res.Code)
+		require.Equal(t, "application/problem+json", res.Header().Get("Content-Type"))
+		require.JSONEq(t, `{"type":"about:blank","title":"Request Entity Too Large","status":413,"detail":"request body exceeds 10 bytes"}`, res.Body.String())
+	})
+
+	t.Run("chunked body over the limit", func(t *testing.T) {
+		// Arrange/Given
+		req := httptest.NewRequest("POST", "/", io.NopCloser(strings.NewReader("0123456789A")))
+		req.ContentLength = -1
+
+		// Act/When
+		res := httptest.NewRecorder()
+		rt.ServeHTTP(res, req)
+
+		// Assert/Then
+		require.Equal(t, http.StatusRequestEntityTooLarge, res.Code)
+	})
+}
@@ -0,0 +1,199 @@
+// Package compress comprime as respostas conforme o Accept-Encoding do cliente.
+// gzip vem embutido; brotli (ou outro algoritmo) pode ser plugado via Encoder
+// sem que este pacote dependa de bibliotecas externas.
+package compress
+
+import (
+	"bufio"
+	"compress/gzip"
+	"io"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// Encoder é um algoritmo de compressão negociável
+type Encoder struct {
+	// Name é o token do Content-Encoding (ex: "gzip", "br")
+	Name string
+	// New cria o writer que comprime para w
+	New func(w io.Writer) io.WriteCloser
+}
+
+// Gzip é o Encoder padrão
+var Gzip = Encoder{
+	Name: "gzip",
+	New: func(w io.Writer) io.WriteCloser {
+		return gzip.NewWriter(w)
+	},
+}
+
+// Config define o middleware de compressão
+type Config struct {
+	// MinSize é o tamanho mínimo do corpo para comprimir; respostas menores vão sem compressão
+	MinSize int
+	// Encoders em ordem de preferência do servidor; vazio usa apenas Gzip
+	Encoders []Encoder
+}
+
+// Middleware comprime a resposta com o melhor Encoder aceito pelo cliente
+func Middleware(cfg Config) func(http.Handler) http.Handler {
+	if len(cfg.Encoders) == 0 {
+		cfg.Encoders = []Encoder{Gzip}
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Add("Vary", "Accept-Encoding")
+
+			encoder, ok := negotiate(r.Header.Get("Accept-Encoding"), cfg.Encoders)
+			if !ok || r.Method == http.MethodHead {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			cw := &writer{ResponseWriter: w, encoder: encoder, minSize: cfg.MinSize, status: http.StatusOK}
+			defer cw.Close()
+			next.ServeHTTP(cw, r)
+		})
+	}
+}
+
+// negotiate escolhe o encoder de maior q; em empate vale a ordem do servidor
+func negotiate(acceptEncoding string, encoders []Encoder) (Encoder, bool) {
+	if acceptEncoding == "" {
+		return Encoder{}, false
+	}
+
+	accepted := map[string]float64{}
+	for _, part := range strings.Split(acceptEncoding, ",") {
+		name, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		q := 1.0
+		if value, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			parsed, err := strconv.ParseFloat(value, 64)
+			if err != nil {
+				continue
+			}
+			q = parsed
+		}
+		accepted[strings.ToLower(strings.TrimSpace(name))] = q
+	}
+
+	var best Encoder
+	bestQ := 0.0
+	for _, e := range encoders {
+		q, ok := accepted[e.Name]
+		if !ok {
+			q, ok = accepted["*"]
+		}
+		if ok && q > bestQ {
+			best, bestQ = e, q
+		}
+	}
+	return best, bestQ > 0
+}
+
+// writer guarda o início do corpo até atingir minSize e só então decide se comprime
+type writer struct {
+	http.ResponseWriter
+	encoder     Encoder
+	minSize     int
+	status      int
+	wroteHeader bool
+	buf         []byte
+	zw          io.WriteCloser
+	passthrough bool
+}
+
+func (cw *writer) WriteHeader(status int) {
+	if cw.wroteHeader {
+		return
+	}
+	cw.wroteHeader = true
+	cw.status = status
+
+	// sem corpo ou já codificada: não comprime
+	if status < http.StatusOK || status == http.StatusNoContent || status == http.StatusNotModified ||
+		cw.Header().Get("Content-Encoding") != "" {
+		cw.passthrough = true
+		cw.ResponseWriter.WriteHeader(status)
+	}
+}
+
+func (cw *writer) Write(data []byte) (int, error) {
+	if !cw.wroteHeader {
+		cw.WriteHeader(http.StatusOK)
+	}
+	if cw.passthrough {
+		return cw.ResponseWriter.Write(data)
+	}
+	if cw.zw != nil {
+		return cw.zw.Write(data)
+	}
+
+	cw.buf = append(cw.buf, data...)
+	if len(cw.buf) >= cw.minSize {
+		if err := cw.start(); err != nil {
+			return 0, err
+		}
+	}
+	return len(data), nil
+}
+
+// start envia os headers comprimidos e o que estava no buffer
+func (cw *writer) start() error {
+	h := cw.Header()
+	h.Set("Content-Encoding", cw.encoder.Name)
+	h.Del("Content-Length")
+	// o corpo comprimido não é byte a byte igual ao original
+	if etag := h.Get("ETag"); strings.HasPrefix(etag, `"`) {
+		h.Set("ETag", "W/"+etag)
+	}
+
+	cw.ResponseWriter.WriteHeader(cw.status)
+	cw.zw = cw.encoder.New(cw.ResponseWriter)
+	_, err := cw.zw.Write(cw.buf)
+	cw.buf = nil
+	return err
+}
+
+// Close finaliza a compressão ou envia sem compressão o corpo menor que minSize
+func (cw *writer) Close() error {
+	if cw.passthrough {
+		return nil
+	}
+	if cw.zw != nil {
+		return cw.zw.Close()
+	}
+	if !cw.wroteHeader {
+		return nil
+	}
+
+	cw.ResponseWriter.WriteHeader(cw.status)
+	_, err := cw.ResponseWriter.Write(cw.buf)
+	return err
+}
+
+// Flush permite streaming (ex: NDJSON): força a compressão do que já foi escrito
+func (cw *writer) Flush() {
+	if !cw.passthrough && cw.zw == nil && cw.wroteHeader {
+		if cw.start() != nil {
+			return
+		}
+	}
+	if f, ok := cw.zw.(interface{ Flush() error }); ok {
+		f.Flush()
+	}
+	if f, ok := cw.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// Hijack repassa para o ResponseWriter original (websockets)
+func (cw *writer) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	if h, ok := cw.ResponseWriter.(http.Hijacker); ok {
+		return h.Hijack()
+	}
+	return nil, nil, http.ErrNotSupported
+}
@@ -0,0 +1,70 @@
+package compress
+
+import (
+	"compress/gzip"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestMiddleware(t *testing.T) {
+	// Arrange/Given
+	body := ""
+	rt := Middleware(Config{MinSize: 100})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("ETag", `"abc"`)
+		w.Write([]byte(body))
+	}))
+
+	get := func(acceptEncoding string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("GET", "/", nil)
+		req.Header.Set("Accept-Encoding", acceptEncoding)
+		res := httptest.NewRecorder()
+		rt.ServeHTTP(res, req)
+		return res
+	}
+
+	t.Run("large body is compressed", func(t *testing.T) {
+		// Arrange/Given
+		body = strings.Repeat("produto ", 50)
+
+		// Act/When
+		res := get("br;q=1, gzip;q=0.8")
+
+		// Assert/Then
+		require.Equal(t, "gzip", res.Header().Get("Content-Encoding"))
+		require.Equal(t, `W/"abc"`, res.Header().Get("ETag"))
+		zr, err := gzip.NewReader(res.Body)
+		require.NoError(t, err)
+		plain, err := io.ReadAll(zr)
+		require.NoError(t, err)
+		require.Equal(t, body, string(plain))
+	})
+
+	t.Run("small body is not compressed", func(t *testing.T) {
+		// Arrange/Given
+		body = "pong"
+
+		// Act/When
+		res := get("gzip")
+
+		// Assert/Then
+		require.Empty(t, res.Header().Get("Content-Encoding"))
+		require.Equal(t, "pong", res.Body.String())
+	})
+
+	t.Run("gzip refused by the client", func(t *testing.T) {
+		// Arrange/Given
+		body = strings.Repeat("produto ", 50)
+
+		// Act/When
+		res := get("gzip;q=0, identity")
+
+		// Assert/Then
+		require.Empty(t, res.Header().Get("Content-Encoding"))
+		require.Equal(t, body, res.Body.String())
+	})
+}
@@ -0,0 +1,146 @@
+// Package cors responde preflights e adiciona os headers Access-Control-* para
+// as origens permitidas.
+package cors
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Config define as regras de CORS de um serviço
+type Config struct {
+	// AllowedOrigins aceita origens exatas, "*" ou curingas de subdomínio ("https://*.exemplo.com")
+	AllowedOrigins []string
+	// AllowedMethods são os métodos aceitos no preflight
+	AllowedMethods []string
+	// AllowedHeaders são os headers que o navegador pode enviar
+	AllowedHeaders []string
+	// ExposedHeaders são os headers da resposta visíveis para o JavaScript
+	ExposedHeaders []string
+	// AllowCredentials permite cookies/Authorization; nesse caso a origem é sempre ecoada, nunca "*"
+	AllowCredentials bool
+	// MaxAge é por quanto tempo o navegador pode guardar o preflight
+	MaxAge time.Duration
+}
+
+// Valores padrão usados quando o campo da Config está vazio
+var (
+	DefaultMethods = []string{http.MethodGet, http.MethodHead, http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete}
+	DefaultHeaders = []string{"Accept", "Authorization", "Content-Type", "API_TOKEN", "Idempotency-Key", "If-None-Match", "If-Modified-Since", "X-Request-Id"}
+	DefaultExposed = []string{"ETag", "Last-Modified", "Retry-After", "RateLimit-Limit", "RateLimit-Remaining", "RateLimit-Reset", "X-Request-Id", "Idempotent-Replayed"}
+)
+
+// ParseList separa uma lista de env em vírgulas, ignorando itens vazios
+func ParseList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// Middleware aplica a Config. Origens não permitidas seguem sem headers de CORS
+// (o navegador bloqueia); preflights são respondidos aqui com 204.
+func Middleware(cfg Config) func(http.Handler) http.Handler {
+	if len(cfg.AllowedMethods) == 0 {
+		cfg.AllowedMethods = DefaultMethods
+	}
+	if len(cfg.AllowedHeaders) == 0 {
+		cfg.AllowedHeaders = DefaultHeaders
+	}
+	if len(cfg.ExposedHeaders) == 0 {
+		cfg.ExposedHeaders = DefaultExposed
+	}
+
+	methods := strings.Join(cfg.AllowedMethods, ", ")
+	headers := strings.Join(cfg.AllowedHeaders, ", ")
+	exposed := strings.Join(cfg.ExposedHeaders, ", ")
+	allowedHeaders := map[string]bool{}
+	for _, h := range cfg.AllowedHeaders {
+		allowedHeaders[strings.ToLower(h)] = true
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			origin := r.Header.Get("Origin")
+			preflight := r.Method == http.MethodOptions && r.Header.Get("Access-Control-Request-Method") != ""
+
+			w.Header().Add("Vary", "Origin")
+			if origin == "" || !cfg.allowed(origin) {
+				if preflight {
+					w.WriteHeader(http.StatusNoContent)
+					return
+				}
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			if cfg.AllowCredentials || !cfg.wildcard() {
+				w.Header().Set("Access-Control-Allow-Origin", origin)
+			} else {
+				w.Header().Set("Access-Control-Allow-Origin", "*")
+			}
+			if cfg.AllowCredentials {
+				w.Header().Set("Access-Control-Allow-Credentials", "true")
+			}
+
+			if !preflight {
+				w.Header().Set("Access-Control-Expose-Headers", exposed)
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			w.Header().Add("Vary", "Access-Control-Request-Method")
+			w.Header().Add("Vary", "Access-Control-Request-Headers")
+			if !contains(cfg.AllowedMethods, r.Header.Get("Access-Control-Request-Method")) {
+				w.WriteHeader(http.StatusNoContent)
+				return
+			}
+			for _, h := range ParseList(r.Header.Get("Access-Control-Request-Headers")) {
+				if !allowedHeaders[strings.ToLower(h)] {
+					w.WriteHeader(http.StatusNoContent)
+					return
+				}
+			}
+
+			w.Header().Set("Access-Control-Allow-Methods", methods)
+			w.Header().Set("Access-Control-Allow-Headers", headers)
+			if cfg.MaxAge > 0 {
+				w.Header().Set("Access-Control-Max-Age", strconv.Itoa(int(cfg.MaxAge.Seconds())))
+			}
+			w.WriteHeader(http.StatusNoContent)
+		})
+	}
+}
+
+func (cfg Config) wildcard() bool {
+	return contains(cfg.AllowedOrigins, "*")
+}
+
+func (cfg Config) allowed(origin string) bool {
+	for _, allowed := range cfg.AllowedOrigins {
+		if allowed == "*" || strings.EqualFold(allowed, origin) {
+			return true
+		}
+		// https://*.exemplo.com aceita https://admin.exemplo.com
+		if prefix, suffix, ok := strings.Cut(allowed, "*"); ok &&
+			strings.HasPrefix(origin, prefix) && strings.HasSuffix(origin, suffix) &&
+			len(origin) > len(prefix)+len(suffix) && !strings.Contains(origin[len(prefix):len(origin)-len(suffix)], "/") {
+			return true
+		}
+	}
+	return false
+}
+
+func contains(list []string, value string) bool {
+	for _, item := range list {
+		if item == value {
+			return true
+		}
+	}
+	return false
+}
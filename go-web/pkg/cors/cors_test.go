@@ -0,0 +1,78 @@
+package cors
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestMiddleware(t *testing.T) {
+	// Arrange/Given
+	rt := Middleware(Config{
+		AllowedOrigins:   []string{"https://admin.exemplo.com", "https://*.preview.exemplo.com"},
+		AllowCredentials: true,
+		MaxAge:           10 * time.Minute,
+	})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("ok"))
+	}))
+
+	request := func(method, origin string, headers map[string]string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, "/products", nil)
+		req.Header.Set("Origin", origin)
+		for k, v := range headers {
+			req.Header.Set(k, v)
+		}
+		res := httptest.NewRecorder()
+		rt.ServeHTTP(res, req)
+		return res
+	}
+
+	t.Run("preflight from an allowed origin", func(t *testing.T) {
+		// Act/When
+		res := request("OPTIONS", "https://admin.exemplo.com", map[string]string{
+			"Access-Control-Request-Method":  "PATCH",
+			"Access-Control-Request-Headers": "Content-Type, Idempotency-Key",
+		})
+
+		// Assert/Then
+		require.Equal(t, http.StatusNoContent, res.Code)
+		require.Equal(t, "https://admin.exemplo.com", res.Header().Get("Access-Control-Allow-Origin"))
+		require.Equal(t, "true", res.Header().Get("Access-Control-Allow-Credentials"))
+		require.Contains(t, res.Header().Get("Access-Control-Allow-Methods"), "PATCH")
+		require.Equal(t, "600", res.Header().Get("Access-Control-Max-Age"))
+	})
+
+	t.Run("preflight with a header that is not allowed", func(t *testing.T) {
+		// Act/When
+		res := request("OPTIONS", "https://admin.exemplo.com", map[string]string{
+			"Access-Control-Request-Method":  "GET",
+			"Access-Control-Request-Headers": "X-Outro",
+		})
+
+		// Assert/Then
+		require.Equal(t, http.StatusNoContent, res.Code)
+		require.Empty(t, res.Header().Get("Access-Control-Allow-Methods"))
+	})
+
+	t.Run("subdomain wildcard", func(t *testing.T) {
+		// Act/When
+		res := request("GET", "https://pr-12.preview.exemplo.com", nil)
+
+		// Assert/Then
+		require.Equal(t, "https://pr-12.preview.exemplo.com", res.Header().Get("Access-Control-Allow-Origin"))
+		require.Contains(t, res.Header().Get("Access-Control-Expose-Headers"), "ETag")
+	})
+
+	t.Run("origin not allowed", func(t *testing.T) {
+		// Act/When
+		res := request("GET", "https://evil.com", nil)
+
+		// Assert/Then
+		require.Equal(t, http.StatusOK, res.Code)
+		require.Empty(t, res.Header().Get("Access-Control-Allow-Origin"))
+		require.Equal(t, "Origin", res.Header().Get("Vary"))
+	})
+}
@@ -0,0 +1,121 @@
+// Package country converte nomes, apelidos e códigos ISO 3166-1 de países no
+// código alpha-2 canônico, ignorando maiúsculas, acentos, pontuação e espaços.
+// É a única tabela de países: o CLI e o serviço HTTP dos desafios importam este pacote.
+package country
+
+import (
+	_ "embed"
+	"encoding/csv"
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+// countriesCSV é a tabela ISO 3166-1: alpha2,alpha3,nome,apelidos (separados por |)
+//
+//go:embed countries.csv
+var countriesCSV string
+
+// Country é um país da tabela ISO 3166-1
+type Country struct {
+	// Code é o código alpha-2, a forma canônica usada nas saídas
+	Code string `json:"code"`
+	// Alpha3 é o código alpha-3
+	Alpha3 string `json:"alpha3"`
+	// Name é o nome curto em inglês
+	Name string `json:"name"`
+}
+
+var (
+	// countries mapeia o código alpha-2 para o país
+	countries = map[string]Country{}
+	// keys mapeia códigos, nomes e apelidos (já normalizados por Fold) para o código alpha-2
+	keys = map[string]string{}
+)
+
+func init() {
+	records, err := csv.NewReader(strings.NewReader(countriesCSV)).ReadAll()
+	if err != nil {
+		panic(fmt.Sprintf("country: tabela inválida: %v", err))
+	}
+
+	for _, record := range records[1:] {
+		c := Country{Code: record[0], Alpha3: record[1], Name: record[2]}
+		countries[c.Code] = c
+
+		names := append([]string{c.Code, c.Alpha3, c.Name}, strings.Split(record[3], "|")...)
+		for _, name := range names {
+			key := Fold(name)
+			if key == "" {
+				continue
+			}
+			if code, ok := keys[key]; ok && code != c.Code {
+				panic(fmt.Sprintf("country: %q é usado por %s e %s", name, code, c.Code))
+			}
+			keys[key] = c.Code
+		}
+	}
+}
+
+// Lookup retorna o país de um nome, apelido ou código
+func Lookup(value string) (Country, bool) {
+	code, ok := keys[Fold(value)]
+	if !ok {
+		return Country{}, false
+	}
+	return countries[code], true
+}
+
+// Normalize retorna o código alpha-2 do país, ou o valor sem espaços nas pontas se o país não for conhecido
+func Normalize(value string) string {
+	if c, ok := Lookup(value); ok {
+		return c.Code
+	}
+	return strings.TrimSpace(value)
+}
+
+// Name retorna o nome do país de um código, ou o próprio valor se o país não for conhecido
+func Name(value string) string {
+	if c, ok := Lookup(value); ok {
+		return c.Name
+	}
+	return strings.TrimSpace(value)
+}
+
+// accents mapeia as letras acentuadas para a letra base
+var accents = map[rune]string{
+	'à': "a", 'á': "a", 'â': "a", 'ã': "a", 'ä': "a", 'å': "a", 'æ': "ae",
+	'ç': "c", 'è': "e", 'é': "e", 'ê': "e", 'ë': "e",
+	'ì': "i", 'í': "i", 'î': "i", 'ï': "i", 'ñ': "n",
+	'ò': "o", 'ó': "o", 'ô': "o", 'õ': "o", 'ö': "o", 'ø': "o", 'œ': "oe",
+	'ù': "u", 'ú': "u", 'û': "u", 'ü': "u", 'ý': "y", 'ÿ': "y", 'ß': "ss",
+	'ā': "a", 'ă': "a", 'ą': "a", 'ć': "c", 'č': "c", 'ď': "d", 'đ': "d",
+	'ē': "e", 'ė': "e", 'ę': "e", 'ě': "e", 'ğ': "g", 'ī': "i", 'į': "i", 'ı': "i",
+	'ł': "l", 'ń': "n", 'ň': "n", 'ō': "o", 'ő': "o", 'ř': "r",
+	'ś': "s", 'ş': "s", 'š': "s", 'ţ': "t", 'ť': "t", 'ū': "u", 'ů': "u", 'ű': "u", 'ų': "u",
+	'ź': "z", 'ż': "z", 'ž': "z",
+}
+
+// Fold passa o valor para minúsculas, remove os acentos, troca a pontuação por
+// espaço e junta os espaços, então "Côte d’Ivoire " e "cote d'ivoire" são iguais
+func Fold(value string) string {
+	var sb strings.Builder
+	space := false
+	for _, r := range strings.ToLower(value) {
+		if base, ok := accents[r]; ok {
+			sb.WriteString(base)
+			space = false
+			continue
+		}
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			sb.WriteRune(r)
+			space = false
+			continue
+		}
+		if !space && sb.Len() > 0 {
+			sb.WriteByte(' ')
+			space = true
+		}
+	}
+	return strings.TrimPrefix(strings.TrimSpace(sb.String()), "the ")
+}
@@ -0,0 +1,30 @@
+package country
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestLookup(t *testing.T) {
+	t.Run("nomes, apelidos e códigos sem diferenciar maiúsculas, acentos e espaços", func(t *testing.T) {
+		for _, value := range []string{"Brazil", "brazil", "Brazil ", "BRASIL", "Brasil", "br", "BRA", " bra "} {
+			c, ok := Lookup(value)
+			require.True(t, ok, value)
+			require.Equal(t, Country{Code: "BR", Alpha3: "BRA", Name: "Brazil"}, c, value)
+		}
+		require.Equal(t, "CI", Normalize("Côte d’Ivoire"))
+		require.Equal(t, "CI", Normalize("ivory coast"))
+		require.Equal(t, "DE", Normalize("alemanha"))
+		require.Equal(t, "NL", Normalize("The Netherlands"))
+		require.Equal(t, "PE", Normalize("PERÚ"))
+		require.Equal(t, "Czechia", Name("czech republic"))
+	})
+
+	t.Run("país desconhecido mantém o valor", func(t *testing.T) {
+		_, ok := Lookup("Atlantis")
+		require.False(t, ok)
+		require.Equal(t, "Atlantis", Normalize(" Atlantis "))
+		require.Equal(t, "Atlantis", Name(" Atlantis "))
+	})
+}
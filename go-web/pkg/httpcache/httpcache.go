@@ -0,0 +1,276 @@
+// Package httpcache guarda respostas GET no servidor, emite ETag forte e
+// Last-Modified e responde 304 para If-None-Match/If-Modified-Since. As entradas
+// são agrupadas por tags (ex: "products") e invalidadas quando o repositório grava.
+package httpcache
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Stats são os contadores expostos do cache
+type Stats struct {
+	Hits          int64 `json:"hits"`
+	Misses        int64 `json:"misses"`
+	Entries       int   `json:"entries"`
+	Invalidations int64 `json:"invalidations"`
+}
+
+type response struct {
+	status   int
+	header   http.Header
+	body     []byte
+	etag     string
+	modified time.Time
+	tags     []string
+	expires  time.Time
+}
+
+type tagState struct {
+	version  uint64
+	modified time.Time
+}
+
+// Cache é um cache de respostas em memória, seguro para uso concorrente
+type Cache struct {
+	mu         sync.Mutex
+	entries    map[string]*response
+	tags       map[string]tagState
+	ttl        time.Duration
+	maxEntries int
+	started    time.Time
+	now        func() time.Time
+
+	hits, misses, invalidations atomic.Int64
+}
+
+// New cria um cache cujas entradas expiram em ttl mesmo sem invalidação
+// (proteção contra alterações feitas fora da aplicação)
+func New(ttl time.Duration, maxEntries int) *Cache {
+	return &Cache{
+		entries:    map[string]*response{},
+		tags:       map[string]tagState{},
+		ttl:        ttl,
+		maxEntries: maxEntries,
+		started:    time.Now().UTC(),
+		now:        time.Now,
+	}
+}
+
+// Invalidate descarta as entradas das tags e atualiza o Last-Modified delas.
+// Um Cache nil não faz nada, o que permite usar Invalidate como hook opcional.
+func (c *Cache) Invalidate(tags ...string) {
+	if c == nil {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now := c.now().UTC()
+	for _, tag := range tags {
+		state := c.tags[tag]
+		c.tags[tag] = tagState{version: state.version + 1, modified: now}
+	}
+	for key, entry := range c.entries {
+		if hasAny(entry.tags, tags) {
+			delete(c.entries, key)
+		}
+	}
+	c.invalidations.Add(1)
+}
+
+// Stats retorna os contadores de hit/miss
+func (c *Cache) Stats() Stats {
+	c.mu.Lock()
+	entries := len(c.entries)
+	c.mu.Unlock()
+
+	return Stats{
+		Hits:          c.hits.Load(),
+		Misses:        c.misses.Load(),
+		Entries:       entries,
+		Invalidations: c.invalidations.Load(),
+	}
+}
+
+// StatsHandler responde os contadores em JSON
+func (c *Cache) StatsHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	json.NewEncoder(w).Encode(c.Stats())
+}
+
+// Middleware serve GET/HEAD do cache das tags informadas. Respostas 200 são
+// guardadas; as demais passam direto.
+func (c *Cache) Middleware(tags ...string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.Method != http.MethodGet && r.Method != http.MethodHead {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			key := strings.Join(tags, ",") + "|" + r.URL.RequestURI() + "|" + r.Header.Get("Accept") + "|" + r.Header.Get("Accept-Encoding")
+			if entry, ok := c.get(key); ok {
+				c.hits.Add(1)
+				write(w, r, entry, "HIT")
+				return
+			}
+			c.misses.Add(1)
+
+			versions, modified := c.snapshot(tags)
+			rec := &recorder{header: http.Header{}, status: http.StatusOK}
+			next.ServeHTTP(rec, r)
+
+			sum := sha256.Sum256(rec.body.Bytes())
+			entry := &response{
+				status:   rec.status,
+				header:   rec.header,
+				body:     rec.body.Bytes(),
+				etag:     `"` + hex.EncodeToString(sum[:16]) + `"`,
+				modified: modified,
+				tags:     tags,
+			}
+			if rec.status == http.StatusOK {
+				c.put(key, entry, versions)
+			}
+			write(w, r, entry, "MISS")
+		})
+	}
+}
+
+func (c *Cache) get(key string) (*response, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	entry, ok := c.entries[key]
+	if !ok || !c.now().Before(entry.expires) {
+		return nil, false
+	}
+	return entry, true
+}
+
+// snapshot retorna a versão das tags antes de executar o handler e o
+// Last-Modified mais recente entre elas
+func (c *Cache) snapshot(tags []string) ([]uint64, time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	versions := make([]uint64, len(tags))
+	modified := c.started
+	for i, tag := range tags {
+		state := c.tags[tag]
+		versions[i] = state.version
+		if state.modified.After(modified) {
+			modified = state.modified
+		}
+	}
+	return versions, modified
+}
+
+// put só guarda se nenhuma tag foi invalidada enquanto o handler executava
+func (c *Cache) put(key string, entry *response, versions []uint64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for i, tag := range entry.tags {
+		if c.tags[tag].version != versions[i] {
+			return
+		}
+	}
+
+	now := c.now()
+	if c.maxEntries > 0 && len(c.entries) >= c.maxEntries {
+		for k, e := range c.entries {
+			if !now.Before(e.expires) || len(c.entries) >= c.maxEntries {
+				delete(c.entries, k)
+			}
+		}
+	}
+
+	entry.expires = now.Add(c.ttl)
+	c.entries[key] = entry
+}
+
+func write(w http.ResponseWriter, r *http.Request, entry *response, status string) {
+	for name, values := range entry.header {
+		w.Header()[name] = values
+	}
+	w.Header().Set("ETag", entry.etag)
+	w.Header().Set("Last-Modified", entry.modified.UTC().Format(http.TimeFormat))
+	w.Header().Set("X-Cache", status)
+
+	if entry.status == http.StatusOK && notModified(r, entry) {
+		w.Header().Del("Content-Length")
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+
+	w.WriteHeader(entry.status)
+	if r.Method != http.MethodHead {
+		w.Write(entry.body)
+	}
+}
+
+// notModified segue a RFC 9110: If-None-Match tem precedência sobre If-Modified-Since
+func notModified(r *http.Request, entry *response) bool {
+	if inm := r.Header.Get("If-None-Match"); inm != "" {
+		for _, tag := range strings.Split(inm, ",") {
+			tag = strings.TrimSpace(tag)
+			if tag == "*" || tag == entry.etag || tag == "W/"+entry.etag {
+				return true
+			}
+		}
+		return false
+	}
+
+	if ims := r.Header.Get("If-Modified-Since"); ims != "" {
+		since, err := http.ParseTime(ims)
+		return err == nil && !entry.modified.Truncate(time.Second).After(since)
+	}
+	return false
+}
+
+func hasAny(tags, wanted []string) bool {
+	for _, tag := range tags {
+		for _, w := range wanted {
+			if tag == w {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// recorder guarda a resposta inteira para calcular o ETag antes de enviar
+type recorder struct {
+	header      http.Header
+	status      int
+	wroteHeader bool
+	body        bytes.Buffer
+}
+
+func (r *recorder) Header() http.Header {
+	return r.header
+}
+
+func (r *recorder) WriteHeader(status int) {
+	if r.wroteHeader {
+		return
+	}
+	r.wroteHeader = true
+	r.status = status
+}
+
+func (r *recorder) Write(data []byte) (int, error) {
+	r.wroteHeader = true
+	return r.body.Write(data)
+}
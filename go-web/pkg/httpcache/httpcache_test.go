@@ -0,0 +1,109 @@
+package httpcache
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestCache(t *testing.T) {
+	// Arrange/Given
+	c := New(time.Minute, 10)
+	calls := 0
+	body := `{"data":[1,2,3]}`
+	rt := c.Middleware("products")(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(body))
+	}))
+
+	get := func(header, value string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("GET", "/products", nil)
+		if header != "" {
+			req.Header.Set(header, value)
+		}
+		res := httptest.NewRecorder()
+		rt.ServeHTTP(res, req)
+		return res
+	}
+
+	t.Run("miss then hit with the same etag", func(t *testing.T) {
+		// Act/When
+		first := get("", "")
+		second := get("", "")
+
+		// Assert/Then
+		require.Equal(t, "MISS", first.Header().Get("X-Cache"))
+		require.Equal(t, "HIT", second.Header().Get("X-Cache"))
+		require.Equal(t, body, second.Body.String())
+		require.NotEmpty(t, first.Header().Get("ETag"))
+		require.Equal(t, first.Header().Get("ETag"), second.Header().Get("ETag"))
+		require.NotEmpty(t, first.Header().Get("Last-Modified"))
+		require.Equal(t, 1, calls)
+	})
+
+	t.Run("if-none-match returns 304", func(t *testing.T) {
+		// Act/When
+		etag := get("", "").Header().Get("ETag")
+		res := get("If-None-Match", etag)
+
+		// Assert/Then
+		require.Equal(t, http.StatusNotModified, res.Code)
+		require.Empty(t, res.Body.String())
+	})
+
+	t.Run("if-modified-since returns 304", func(t *testing.T) {
+		// Act/When
+		res := get("If-Modified-Since", time.Now().Add(time.Hour).UTC().Format(http.TimeFormat))
+
+		// Assert/Then
+		require.Equal(t, http.StatusNotModified, res.Code)
+	})
+
+	t.Run("invalidate changes etag", func(t *testing.T) {
+		// Arrange/Given
+		etag := get("", "").Header().Get("ETag")
+
+		// Act/When
+		c.Invalidate("products")
+		body = `{"data":[1,2,3,4]}`
+		res := get("If-None-Match", etag)
+
+		// Assert/Then
+		require.Equal(t, http.StatusOK, res.Code)
+		require.Equal(t, "MISS", res.Header().Get("X-Cache"))
+		require.NotEqual(t, etag, res.Header().Get("ETag"))
+		require.Equal(t, 2, calls)
+	})
+
+	t.Run("stats", func(t *testing.T) {
+		// Act/When
+		stats := c.Stats()
+
+		// Assert/Then
+		require.EqualValues(t, 2, stats.Misses)
+		require.EqualValues(t, 5, stats.Hits)
+		require.Equal(t, 1, stats.Entries)
+		require.EqualValues(t, 1, stats.Invalidations)
+	})
+}
+
+func TestCache_DoesNotStoreErrors(t *testing.T) {
+	// Arrange/Given
+	c := New(time.Minute, 10)
+	rt := c.Middleware("products")(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+
+	// Act/When
+	rt.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/products", nil))
+	res := httptest.NewRecorder()
+	rt.ServeHTTP(res, httptest.NewRequest("GET", "/products", nil))
+
+	// Assert/Then
+	require.Equal(t, http.StatusInternalServerError, res.Code)
+	require.Equal(t, 0, c.Stats().Entries)
+}
@@ -0,0 +1,246 @@
+// Package idempotency implementa o header Idempotency-Key: a primeira resposta de
+// cada chave (por principal) é guardada por um TTL e repetida nas novas tentativas.
+package idempotency
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"net"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/izabelly/go-web/pkg/auth"
+)
+
+// Header é o header enviado pelo cliente
+const Header = "Idempotency-Key"
+
+// Record é a resposta guardada de uma chave
+type Record struct {
+	// Fingerprint é o hash de método, rota e corpo da requisição original
+	Fingerprint string
+	// Done é falso enquanto a primeira requisição ainda está em andamento
+	Done   bool
+	Status int
+	// Header são só os headers escritos pelo handler protegido
+	Header http.Header
+	Body   []byte
+}
+
+// Store guarda as respostas. A implementação em memória atende uma instância;
+// um store compartilhado pode implementar a mesma interface.
+type Store interface {
+	// Reserve marca a chave como em andamento. Se a chave já existe, retorna o
+	// registro existente e false.
+	Reserve(key, fingerprint string, ttl time.Duration) (Record, bool)
+	// Complete guarda a resposta da chave reservada
+	Complete(key string, rec Record, ttl time.Duration)
+	// Release libera a chave para uma nova tentativa
+	Release(key string)
+}
+
+type entry struct {
+	record  Record
+	expires time.Time
+}
+
+// Memory é um Store em memória, seguro para uso concorrente
+type Memory struct {
+	mu        sync.Mutex
+	entries   map[string]entry
+	lastSweep time.Time
+	now       func() time.Time
+}
+
+func NewMemory() *Memory {
+	return &Memory{entries: map[string]entry{}, now: time.Now}
+}
+
+func (m *Memory) Reserve(key, fingerprint string, ttl time.Duration) (Record, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := m.now()
+	m.sweep(now)
+
+	if e, ok := m.entries[key]; ok && now.Before(e.expires) {
+		return e.record, false
+	}
+
+	m.entries[key] = entry{record: Record{Fingerprint: fingerprint}, expires: now.Add(ttl)}
+	return Record{}, true
+}
+
+func (m *Memory) Complete(key string, rec Record, ttl time.Duration) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	rec.Done = true
+	m.entries[key] = entry{record: rec, expires: m.now().Add(ttl)}
+}
+
+func (m *Memory) Release(key string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	delete(m.entries, key)
+}
+
+// sweep remove, no máximo uma vez por minuto, as chaves expiradas
+func (m *Memory) sweep(now time.Time) {
+	if now.Sub(m.lastSweep) < time.Minute {
+		return
+	}
+	m.lastSweep = now
+
+	for key, e := range m.entries {
+		if !now.Before(e.expires) {
+			delete(m.entries, key)
+		}
+	}
+}
+
+// Middleware repete a resposta guardada quando a mesma Idempotency-Key chega de
+// novo com o mesmo corpo, responde 422 quando o corpo é outro e 409 enquanto a
+// primeira requisição não terminou. Respostas 5xx não são guardadas.
+func Middleware(store Store, ttl time.Duration, onError auth.ErrorFunc) func(http.Handler) http.Handler {
+	if onError == nil {
+		onError = auth.WriteError
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			idemKey := r.Header.Get(Header)
+			if idemKey == "" || r.Method != http.MethodPost {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			body, err := io.ReadAll(r.Body)
+			r.Body.Close()
+			if err != nil {
+				onError(w, http.StatusBadRequest, "error reading request body")
+				return
+			}
+			r.Body = io.NopCloser(bytes.NewReader(body))
+
+			key := principalKey(r) + "|" + idemKey
+			fingerprint := fingerprintOf(r, body)
+
+			rec, reserved := store.Reserve(key, fingerprint, ttl)
+			if !reserved {
+				switch {
+				case rec.Fingerprint != fingerprint:
+					onError(w, http.StatusUnprocessableEntity, "idempotency key reused with a different request")
+				case !rec.Done:
+					onError(w, http.StatusConflict, "request with this idempotency key is in progress")
+				default:
+					replay(w, rec)
+				}
+				return
+			}
+
+			rw := &recorder{ResponseWriter: w, header: http.Header{}, status: http.StatusOK}
+			defer func() {
+				if p := recover(); p != nil {
+					store.Release(key)
+					panic(p)
+				}
+				// handler que não escreveu nada ainda precisa enviar os headers que definiu
+				if !rw.wroteHeader {
+					rw.WriteHeader(http.StatusOK)
+				}
+				if rw.status >= http.StatusInternalServerError {
+					store.Release(key)
+					return
+				}
+				store.Complete(key, Record{
+					Fingerprint: fingerprint,
+					Status:      rw.status,
+					Header:      rw.sent,
+					Body:        rw.body.Bytes(),
+				}, ttl)
+			}()
+			next.ServeHTTP(rw, r)
+		})
+	}
+}
+
+// replay repete a resposta guardada. O corpo guardado é o que o handler escreveu,
+// então a codificação (Content-Encoding do compress) é negociada de novo pelos
+// middlewares de fora, e o Vary deles é mantido.
+func replay(w http.ResponseWriter, rec Record) {
+	for name, values := range rec.Header {
+		if name == "Vary" {
+			for _, value := range values {
+				w.Header().Add(name, value)
+			}
+			continue
+		}
+		w.Header()[name] = values
+	}
+	w.Header().Set("Idempotent-Replayed", "true")
+	w.WriteHeader(rec.Status)
+	w.Write(rec.Body)
+}
+
+// principalKey separa as chaves por principal autenticado e, sem ele, por IP
+func principalKey(r *http.Request) string {
+	if principal, ok := auth.FromContext(r.Context()); ok && principal.ID != "" {
+		return "principal:" + principal.ID
+	}
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+	return "ip:" + host
+}
+
+func fingerprintOf(r *http.Request, body []byte) string {
+	h := sha256.New()
+	h.Write([]byte(r.Method + " " + r.URL.Path + "\n"))
+	h.Write(body)
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// recorder repassa a resposta ao cliente e guarda uma cópia. O handler escreve num
+// mapa de headers próprio, para que só os headers dele sejam guardados: os definidos
+// pelos middlewares de fora (X-Request-Id, RateLimit-*) valem só para a requisição atual.
+type recorder struct {
+	http.ResponseWriter
+	header      http.Header
+	sent        http.Header
+	status      int
+	wroteHeader bool
+	body        bytes.Buffer
+}
+
+func (r *recorder) Header() http.Header {
+	return r.header
+}
+
+func (r *recorder) WriteHeader(status int) {
+	if r.wroteHeader {
+		return
+	}
+	r.wroteHeader = true
+	r.status = status
+	r.sent = r.header.Clone()
+
+	dst := r.ResponseWriter.Header()
+	for name, values := range r.sent {
+		dst[name] = values
+	}
+	r.ResponseWriter.WriteHeader(status)
+}
+
+func (r *recorder) Write(data []byte) (int, error) {
+	if !r.wroteHeader {
+		r.WriteHeader(http.StatusOK)
+	}
+	r.body.Write(data)
+	return r.ResponseWriter.Write(data)
+}
@@ -0,0 +1,175 @@
+package idempotency
+
+import (
+	"compress/gzip"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/izabelly/go-web/pkg/compress"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMiddleware(t *testing.T) {
+	// Arrange/Given
+	var calls int32
+	rt := Middleware(NewMemory(), time.Hour, nil)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := atomic.AddInt32(&calls, 1)
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusCreated)
+		w.Write([]byte(`{"id":` + string('0'+rune(n)) + `}`))
+	}))
+
+	post := func(key, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("POST", "/products", strings.NewReader(body))
+		if key != "" {
+			req.Header.Set(Header, key)
+		}
+		res := httptest.NewRecorder()
+		rt.ServeHTTP(res, req)
+		return res
+	}
+
+	t.Run("retry replays the first response", func(t *testing.T) {
+		// Act/When
+		first := post("key-1", `{"name":"a"}`)
+		retry := post("key-1", `{"name":"a"}`)
+
+		// Assert/Then
+		require.Equal(t, http.StatusCreated, first.Code)
+		require.Equal(t, http.StatusCreated, retry.Code)
+		require.Equal(t, first.Body.String(), retry.Body.String())
+		require.Equal(t, "true", retry.Header().Get("Idempotent-Replayed"))
+		require.Equal(t, "application/json", retry.Header().Get("Content-Type"))
+		require.EqualValues(t, 1, atomic.LoadInt32(&calls))
+	})
+
+	t.Run("same key with another body", func(t *testing.T) {
+		// Act/When
+		res := post("key-1", `{"name":"b"}`)
+
+		// Assert/Then
+		require.Equal(t, http.StatusUnprocessableEntity, res.Code)
+		require.EqualValues(t, 1, atomic.LoadInt32(&calls))
+	})
+
+	t.Run("without key every request runs", func(t *testing.T) {
+		// Act/When
+		post("", `{"name":"a"}`)
+		post("", `{"name":"a"}`)
+
+		// Assert/Then
+		require.EqualValues(t, 3, atomic.LoadInt32(&calls))
+	})
+}
+
+func TestMiddleware_Headers(t *testing.T) {
+	// Arrange/Given: um middleware de fora define headers da requisição atual
+	var requests int32
+	outer := func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			n := atomic.AddInt32(&requests, 1)
+			w.Header().Set("X-Request-Id", "req-"+string('0'+rune(n)))
+			next.ServeHTTP(w, r)
+		})
+	}
+	rt := outer(Middleware(NewMemory(), time.Hour, nil)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Location", "/products/1")
+		w.WriteHeader(http.StatusCreated)
+	})))
+
+	post := func() *httptest.ResponseRecorder {
+		req := httptest.NewRequest("POST", "/products", strings.NewReader(`{"name":"a"}`))
+		req.Header.Set(Header, "key-1")
+		res := httptest.NewRecorder()
+		rt.ServeHTTP(res, req)
+		return res
+	}
+
+	// Act/When
+	first := post()
+	retry := post()
+
+	// Assert/Then
+	require.Equal(t, "req-1", first.Header().Get("X-Request-Id"))
+	require.Equal(t, "/products/1", first.Header().Get("Location"))
+	require.Equal(t, "true", retry.Header().Get("Idempotent-Replayed"))
+	require.Equal(t, "req-2", retry.Header().Get("X-Request-Id"))
+	require.Equal(t, "/products/1", retry.Header().Get("Location"))
+}
+
+func TestMiddleware_CompressedReplay(t *testing.T) {
+	// Arrange/Given: compress fica fora do idempotency, como nas rotas
+	body := strings.Repeat("a", 2000)
+	rt := compress.Middleware(compress.Config{MinSize: 100})(Middleware(NewMemory(), time.Hour, nil)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain")
+		w.Header().Set("Vary", "Accept")
+		w.WriteHeader(http.StatusCreated)
+		w.Write([]byte(body))
+	})))
+
+	post := func(acceptEncoding string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("POST", "/products/bulk", strings.NewReader(`{"name":"a"}`))
+		req.Header.Set(Header, "key-1")
+		if acceptEncoding != "" {
+			req.Header.Set("Accept-Encoding", acceptEncoding)
+		}
+		res := httptest.NewRecorder()
+		rt.ServeHTTP(res, req)
+		return res
+	}
+	gunzip := func(res *httptest.ResponseRecorder) string {
+		zr, err := gzip.NewReader(res.Body)
+		require.NoError(t, err)
+		data, err := io.ReadAll(zr)
+		require.NoError(t, err)
+		return string(data)
+	}
+
+	// Act/When
+	first := post("gzip")
+	plain := post("")
+	gzipped := post("gzip")
+
+	// Assert/Then
+	require.Equal(t, "gzip", first.Header().Get("Content-Encoding"))
+	require.Equal(t, body, gunzip(first))
+
+	require.Equal(t, http.StatusCreated, plain.Code)
+	require.Equal(t, "true", plain.Header().Get("Idempotent-Replayed"))
+	require.Empty(t, plain.Header().Get("Content-Encoding"))
+	require.Equal(t, body, plain.Body.String())
+
+	require.Equal(t, "gzip", gzipped.Header().Get("Content-Encoding"))
+	require.Equal(t, body, gunzip(gzipped))
+	require.Equal(t, []string{"Accept-Encoding", "Accept"}, gzipped.Header().Values("Vary"))
+}
+
+func TestMemory(t *testing.T) {
+	// Arrange/Given
+	now := time.Now()
+	m := NewMemory()
+	m.now = func() time.Time { return now }
+
+	// Act/When
+	_, reserved := m.Reserve("k", "fp", time.Minute)
+	require.True(t, reserved)
+	rec, reserved := m.Reserve("k", "fp", time.Minute)
+
+	// Assert/Then
+	require.False(t, reserved)
+	require.False(t, rec.Done) // ainda em andamento
+
+	m.Release("k")
+	_, reserved = m.Reserve("k", "fp", time.Minute)
+	require.True(t, reserved)
+
+	m.Complete("k", Record{Fingerprint: "fp", Status: 201}, time.Minute)
+	now = now.Add(2 * time.Minute)
+	_, reserved = m.Reserve("k", "fp", time.Minute)
+	require.True(t, reserved) // expirou
+}
@@ -0,0 +1,264 @@
+// Package negotiate escolhe o formato da resposta pelo header Accept (ou ?format=)
+// e escreve listas de DTOs em CSV, XML ou NDJSON usando as tags json dos campos.
+package negotiate
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"mime"
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// Formatos suportados
+const (
+	JSON   = "json"
+	CSV    = "csv"
+	XML    = "xml"
+	NDJSON = "ndjson"
+)
+
+// ErrNotAcceptable indica que nenhum formato do Accept (ou do ?format=) é suportado
+var ErrNotAcceptable = errors.New("negotiate: formato não suportado")
+
+var mediaTypes = map[string]string{
+	"application/json":     JSON,
+	"application/*":        JSON,
+	"*/*":                  JSON,
+	"text/csv":             CSV,
+	"text/*":               CSV,
+	"application/xml":      XML,
+	"text/xml":             XML,
+	"application/x-ndjson": NDJSON,
+	"application/ndjson":   NDJSON,
+}
+
+var contentTypes = map[string]string{
+	JSON:   "application/json",
+	CSV:    "text/csv; charset=utf-8",
+	XML:    "application/xml; charset=utf-8",
+	NDJSON: "application/x-ndjson",
+}
+
+// Negotiate retorna o formato pedido. ?format= tem precedência sobre o Accept;
+// sem nenhum dos dois a resposta é JSON.
+func Negotiate(r *http.Request) (string, error) {
+	if format := strings.ToLower(r.URL.Query().Get("format")); format != "" {
+		if _, ok := contentTypes[format]; !ok {
+			return "", ErrNotAcceptable
+		}
+		return format, nil
+	}
+
+	accept := r.Header.Get("Accept")
+	if strings.TrimSpace(accept) == "" {
+		return JSON, nil
+	}
+
+	best, bestQ := "", 0.0
+	for _, part := range strings.Split(accept, ",") {
+		mediaType, params, err := mime.ParseMediaType(strings.TrimSpace(part))
+		if err != nil {
+			continue
+		}
+		format, ok := mediaTypes[mediaType]
+		if !ok {
+			continue
+		}
+
+		q := 1.0
+		if value, ok := params["q"]; ok {
+			if q, err = strconv.ParseFloat(value, 64); err != nil {
+				continue
+			}
+		}
+		if q > bestQ {
+			best, bestQ = format, q
+		}
+	}
+
+	if best == "" {
+		return "", ErrNotAcceptable
+	}
+	return best, nil
+}
+
+// Write escreve rows (um slice de structs) no formato informado. name é usado
+// como elemento raiz no XML. Para JSON use o envelope de resposta de cada serviço.
+func Write(w http.ResponseWriter, format string, status int, name string, rows any) error {
+	value := reflect.ValueOf(rows)
+	if value.Kind() != reflect.Slice {
+		return fmt.Errorf("negotiate: rows deve ser um slice, recebido %T", rows)
+	}
+	columns := columnsOf(value.Type().Elem())
+
+	w.Header().Set("Content-Type", contentTypes[format])
+	Vary(w)
+	w.WriteHeader(status)
+
+	switch format {
+	case CSV:
+		return writeCSV(w, value, columns)
+	case XML:
+		return writeXML(w, value, columns, name)
+	case NDJSON:
+		return writeNDJSON(w, value)
+	default:
+		return json.NewEncoder(w).Encode(rows)
+	}
+}
+
+// Vary acrescenta Accept ao header Vary sem apagar o que outros middlewares já
+// colocaram (Accept-Encoding do compress, Origin do cors). Chame também na resposta
+// JSON, para que caches não entreguem um formato a quem pediu outro.
+func Vary(w http.ResponseWriter) {
+	for _, value := range w.Header().Values("Vary") {
+		for _, token := range strings.Split(value, ",") {
+			if strings.EqualFold(strings.TrimSpace(token), "Accept") {
+				return
+			}
+		}
+	}
+	w.Header().Add("Vary", "Accept")
+}
+
+func writeCSV(w http.ResponseWriter, rows reflect.Value, columns []column) error {
+	cw := csv.NewWriter(w)
+
+	header := make([]string, len(columns))
+	for i, c := range columns {
+		header[i] = c.name
+	}
+	if err := cw.Write(header); err != nil {
+		return err
+	}
+
+	record := make([]string, len(columns))
+	for i := 0; i < rows.Len(); i++ {
+		row := indirect(rows.Index(i))
+		for j, c := range columns {
+			record[j] = cell(row.FieldByIndex(c.index))
+		}
+		if err := cw.Write(record); err != nil {
+			return err
+		}
+	}
+
+	cw.Flush()
+	return cw.Error()
+}
+
+func writeXML(w http.ResponseWriter, rows reflect.Value, columns []column, name string) error {
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+
+	if _, err := w.Write([]byte(xml.Header)); err != nil {
+		return err
+	}
+	root := xml.StartElement{Name: xml.Name{Local: name}}
+	if err := enc.EncodeToken(root); err != nil {
+		return err
+	}
+
+	item := xml.StartElement{Name: xml.Name{Local: "item"}}
+	for i := 0; i < rows.Len(); i++ {
+		row := indirect(rows.Index(i))
+		if err := enc.EncodeToken(item); err != nil {
+			return err
+		}
+		for _, c := range columns {
+			field := xml.StartElement{Name: xml.Name{Local: c.name}}
+			if err := enc.EncodeElement(cell(row.FieldByIndex(c.index)), field); err != nil {
+				return err
+			}
+		}
+		if err := enc.EncodeToken(item.End()); err != nil {
+			return err
+		}
+	}
+
+	if err := enc.EncodeToken(root.End()); err != nil {
+		return err
+	}
+	return enc.Flush()
+}
+
+// writeNDJSON envia uma linha por item, liberando o buffer a cada 100 linhas
+func writeNDJSON(w http.ResponseWriter, rows reflect.Value) error {
+	enc := json.NewEncoder(w)
+	flusher, _ := w.(http.Flusher)
+
+	for i := 0; i < rows.Len(); i++ {
+		if err := enc.Encode(rows.Index(i).Interface()); err != nil {
+			return err
+		}
+		if flusher != nil && (i+1)%100 == 0 {
+			flusher.Flush()
+		}
+	}
+	return nil
+}
+
+type column struct {
+	name  string
+	index []int
+}
+
+// columnsOf lista os campos exportados com o nome da tag json, achatando structs embutidas
+func columnsOf(t reflect.Type) []column {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if t.Kind() != reflect.Struct {
+		return nil
+	}
+
+	var columns []column
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+		if name == "-" {
+			continue
+		}
+		if field.Anonymous && name == "" && field.Type.Kind() == reflect.Struct {
+			for _, c := range columnsOf(field.Type) {
+				columns = append(columns, column{name: c.name, index: append([]int{i}, c.index...)})
+			}
+			continue
+		}
+		if !field.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+		columns = append(columns, column{name: name, index: []int{i}})
+	}
+	return columns
+}
+
+func indirect(v reflect.Value) reflect.Value {
+	for v.Kind() == reflect.Pointer || v.Kind() == reflect.Interface {
+		v = v.Elem()
+	}
+	return v
+}
+
+// cell formata um campo como no JSON, sem aspas em strings e vazio para null
+func cell(v reflect.Value) string {
+	data, err := json.Marshal(v.Interface())
+	if err != nil || string(data) == "null" {
+		return ""
+	}
+
+	var s string
+	if json.Unmarshal(data, &s) == nil {
+		return s
+	}
+	return string(data)
+}
@@ -0,0 +1,106 @@
+package negotiate
+
+import (
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+type attributes struct {
+	Name string `json:"name"`
+}
+
+type row struct {
+	ID int `json:"id"`
+	attributes
+	Price  float64  `json:"price"`
+	Tags   []string `json:"tags,omitempty"`
+	Secret string   `json:"-"`
+}
+
+func TestNegotiate(t *testing.T) {
+	cases := []struct {
+		name     string
+		url      string
+		accept   string
+		expected string
+		err      error
+	}{
+		{"default", "/", "", JSON, nil},
+		{"browser", "/", "text/html,application/xhtml+xml,*/*;q=0.8", JSON, nil},
+		{"csv", "/", "text/csv", CSV, nil},
+		{"q values", "/", "application/json;q=0.5, application/xml", XML, nil},
+		{"ndjson", "/", "application/x-ndjson", NDJSON, nil},
+		{"format overrides accept", "/?format=csv", "application/json", CSV, nil},
+		{"unknown format", "/?format=pdf", "", "", ErrNotAcceptable},
+		{"unsupported accept", "/", "image/png", "", ErrNotAcceptable},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			// Arrange/Given
+			req := httptest.NewRequest("GET", c.url, nil)
+			req.Header.Set("Accept", c.accept)
+
+			// Act/When
+			format, err := Negotiate(req)
+
+			// Assert/Then
+			require.ErrorIs(t, err, c.err)
+			require.Equal(t, c.expected, format)
+		})
+	}
+}
+
+func TestWrite(t *testing.T) {
+	// Arrange/Given
+	rows := []row{
+		{ID: 1, attributes: attributes{Name: "Caneta, azul"}, Price: 2.5, Tags: []string{"a"}, Secret: "x"},
+		{ID: 2, attributes: attributes{Name: "Lápis"}, Price: 1},
+	}
+
+	t.Run("csv", func(t *testing.T) {
+		// Act/When
+		res := httptest.NewRecorder()
+		require.NoError(t, Write(res, CSV, 200, "rows", rows))
+
+		// Assert/Then
+		require.Equal(t, "text/csv; charset=utf-8", res.Header().Get("Content-Type"))
+		require.Equal(t, "id,name,price,tags\n1,\"Caneta, azul\",2.5,\"[\"\"a\"\"]\"\n2,Lápis,1,\n", res.Body.String())
+	})
+
+	t.Run("xml", func(t *testing.T) {
+		// Act/When
+		res := httptest.NewRecorder()
+		require.NoError(t, Write(res, XML, 200, "rows", rows))
+
+		// Assert/Then
+		require.Contains(t, res.Body.String(), "<rows>")
+		require.Contains(t, res.Body.String(), "<item>\n    <id>1</id>\n    <name>Caneta, azul</name>")
+		require.NotContains(t, res.Body.String(), "Secret")
+	})
+
+	t.Run("ndjson", func(t *testing.T) {
+		// Act/When
+		res := httptest.NewRecorder()
+		require.NoError(t, Write(res, NDJSON, 200, "rows", rows))
+
+		// Assert/Then
+		require.Equal(t, "{\"id\":1,\"name\":\"Caneta, azul\",\"price\":2.5,\"tags\":[\"a\"]}\n{\"id\":2,\"name\":\"Lápis\",\"price\":1}\n", res.Body.String())
+	})
+
+	t.Run("vary keeps the values of other middlewares", func(t *testing.T) {
+		// Arrange/Given
+		res := httptest.NewRecorder()
+		res.Header().Add("Vary", "Accept-Encoding")
+		res.Header().Add("Vary", "Origin")
+
+		// Act/When
+		Vary(res)
+		require.NoError(t, Write(res, CSV, 200, "rows", rows))
+
+		// Assert/Then
+		require.Equal(t, []string{"Accept-Encoding", "Origin", "Accept"}, res.Header().Values("Vary"))
+	})
+}
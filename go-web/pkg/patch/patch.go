@@ -0,0 +1,339 @@
+// Package patch aplica documentos JSON Merge Patch (RFC 7386) e JSON Patch (RFC 6902)
+// sobre qualquer valor que possa ser serializado em JSON.
+package patch
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"mime"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+const (
+	ContentTypeMergePatch = "application/merge-patch+json"
+	ContentTypeJSONPatch  = "application/json-patch+json"
+)
+
+var (
+	// ErrInvalidPatch indica um documento de patch mal formado ou um path inexistente
+	ErrInvalidPatch = errors.New("patch inválido")
+	// ErrTestFailed indica que uma operação "test" do JSON Patch não confere
+	ErrTestFailed = errors.New("operação test falhou")
+	// ErrUnsupportedMediaType indica um Content-Type que não é de patch
+	ErrUnsupportedMediaType = errors.New("content-type não suportado para patch")
+)
+
+// Operation representa uma operação do JSON Patch (RFC 6902)
+type Operation struct {
+	Op    string          `json:"op"`
+	Path  string          `json:"path"`
+	From  string          `json:"from,omitempty"`
+	Value json.RawMessage `json:"value,omitempty"`
+}
+
+// Apply aplica o patch sobre value de acordo com o content type. O tipo
+// application/json é tratado como merge patch para manter os clientes antigos.
+func Apply[T any](value T, contentType string, patchDoc []byte) (T, error) {
+	var out T
+
+	mediaType := ContentTypeMergePatch
+	if contentType != "" {
+		parsed, _, err := mime.ParseMediaType(contentType)
+		if err != nil {
+			return out, fmt.Errorf("%w: %s", ErrUnsupportedMediaType, contentType)
+		}
+		mediaType = parsed
+	}
+
+	doc, err := json.Marshal(value)
+	if err != nil {
+		return out, err
+	}
+
+	var result []byte
+	switch mediaType {
+	case ContentTypeMergePatch, "application/json":
+		result, err = MergePatch(doc, patchDoc)
+	case ContentTypeJSONPatch:
+		result, err = JSONPatch(doc, patchDoc)
+	default:
+		return out, fmt.Errorf("%w: %s", ErrUnsupportedMediaType, mediaType)
+	}
+	if err != nil {
+		return out, err
+	}
+
+	if err := json.Unmarshal(result, &out); err != nil {
+		return out, fmt.Errorf("%w: %v", ErrInvalidPatch, err)
+	}
+	return out, nil
+}
+
+// MergePatch aplica um JSON Merge Patch (RFC 7386) sobre doc
+func MergePatch(doc, patchDoc []byte) ([]byte, error) {
+	var target, p any
+	if err := json.Unmarshal(doc, &target); err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(patchDoc, &p); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidPatch, err)
+	}
+
+	return json.Marshal(mergeValue(target, p))
+}
+
+func mergeValue(target, p any) any {
+	patchObj, ok := p.(map[string]any)
+	if !ok {
+		return p
+	}
+
+	targetObj, ok := target.(map[string]any)
+	if !ok {
+		targetObj = map[string]any{}
+	}
+
+	for key, value := range patchObj {
+		if value == nil {
+			delete(targetObj, key)
+			continue
+		}
+		targetObj[key] = mergeValue(targetObj[key], value)
+	}
+	return targetObj
+}
+
+// JSONPatch aplica uma lista de operações JSON Patch (RFC 6902) sobre doc.
+// As operações são aplicadas em ordem e qualquer erro cancela o patch inteiro.
+func JSONPatch(doc, patchDoc []byte) ([]byte, error) {
+	var target any
+	if err := json.Unmarshal(doc, &target); err != nil {
+		return nil, err
+	}
+
+	var operations []Operation
+	if err := json.Unmarshal(patchDoc, &operations); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidPatch, err)
+	}
+
+	for i, op := range operations {
+		var err error
+		target, err = applyOperation(target, op)
+		if err != nil {
+			return nil, fmt.Errorf("operação %d (%s %s): %w", i, op.Op, op.Path, err)
+		}
+	}
+
+	return json.Marshal(target)
+}
+
+func applyOperation(doc any, op Operation) (any, error) {
+	path, err := parsePointer(op.Path)
+	if err != nil {
+		return nil, err
+	}
+
+	switch op.Op {
+	case "add", "replace", "test":
+		if op.Value == nil {
+			return nil, fmt.Errorf("%w: value obrigatório", ErrInvalidPatch)
+		}
+		var value any
+		if err := json.Unmarshal(op.Value, &value); err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidPatch, err)
+		}
+
+		switch op.Op {
+		case "add":
+			return add(doc, path, value)
+		case "replace":
+			if _, err := get(doc, path); err != nil {
+				return nil, err
+			}
+			if len(path) == 0 {
+				return value, nil
+			}
+			doc, _, err = remove(doc, path)
+			if err != nil {
+				return nil, err
+			}
+			return add(doc, path, value)
+		default:
+			current, err := get(doc, path)
+			if err != nil {
+				return nil, err
+			}
+			if !reflect.DeepEqual(current, value) {
+				return nil, ErrTestFailed
+			}
+			return doc, nil
+		}
+
+	case "remove":
+		doc, _, err = remove(doc, path)
+		return doc, err
+
+	case "move", "copy":
+		from, err := parsePointer(op.From)
+		if err != nil {
+			return nil, err
+		}
+
+		var value any
+		if op.Op == "move" {
+			doc, value, err = remove(doc, from)
+		} else {
+			value, err = get(doc, from)
+			if err == nil {
+				value, err = deepCopy(value)
+			}
+		}
+		if err != nil {
+			return nil, err
+		}
+		return add(doc, path, value)
+	}
+
+	return nil, fmt.Errorf("%w: op desconhecida %q", ErrInvalidPatch, op.Op)
+}
+
+// parsePointer converte um JSON Pointer (RFC 6901) em tokens
+func parsePointer(pointer string) ([]string, error) {
+	if pointer == "" {
+		return nil, nil
+	}
+	if !strings.HasPrefix(pointer, "/") {
+		return nil, fmt.Errorf("%w: path %q deve começar com /", ErrInvalidPatch, pointer)
+	}
+
+	tokens := strings.Split(pointer[1:], "/")
+	for i, token := range tokens {
+		tokens[i] = strings.ReplaceAll(strings.ReplaceAll(token, "~1", "/"), "~0", "~")
+	}
+	return tokens, nil
+}
+
+func get(doc any, path []string) (any, error) {
+	for _, token := range path {
+		switch node := doc.(type) {
+		case map[string]any:
+			value, ok := node[token]
+			if !ok {
+				return nil, fmt.Errorf("%w: %q não existe", ErrInvalidPatch, token)
+			}
+			doc = value
+		case []any:
+			i, err := arrayIndex(token, len(node)-1)
+			if err != nil {
+				return nil, err
+			}
+			doc = node[i]
+		default:
+			return nil, fmt.Errorf("%w: %q não existe", ErrInvalidPatch, token)
+		}
+	}
+	return doc, nil
+}
+
+func add(doc any, path []string, value any) (any, error) {
+	if len(path) == 0 {
+		return value, nil
+	}
+
+	token, last := path[0], len(path) == 1
+	switch node := doc.(type) {
+	case map[string]any:
+		if last {
+			node[token] = value
+			return node, nil
+		}
+		child, ok := node[token]
+		if !ok {
+			return nil, fmt.Errorf("%w: %q não existe", ErrInvalidPatch, token)
+		}
+		child, err := add(child, path[1:], value)
+		node[token] = child
+		return node, err
+
+	case []any:
+		if last {
+			i := len(node)
+			if token != "-" {
+				var err error
+				if i, err = arrayIndex(token, len(node)); err != nil {
+					return nil, err
+				}
+			}
+			node = append(node, nil)
+			copy(node[i+1:], node[i:])
+			node[i] = value
+			return node, nil
+		}
+		i, err := arrayIndex(token, len(node)-1)
+		if err != nil {
+			return nil, err
+		}
+		node[i], err = add(node[i], path[1:], value)
+		return node, err
+	}
+
+	return nil, fmt.Errorf("%w: %q não existe", ErrInvalidPatch, token)
+}
+
+func remove(doc any, path []string) (any, any, error) {
+	if len(path) == 0 {
+		return nil, nil, fmt.Errorf("%w: não é possível remover a raiz", ErrInvalidPatch)
+	}
+
+	token, last := path[0], len(path) == 1
+	switch node := doc.(type) {
+	case map[string]any:
+		child, ok := node[token]
+		if !ok {
+			return nil, nil, fmt.Errorf("%w: %q não existe", ErrInvalidPatch, token)
+		}
+		if last {
+			delete(node, token)
+			return node, child, nil
+		}
+		child, removed, err := remove(child, path[1:])
+		node[token] = child
+		return node, removed, err
+
+	case []any:
+		i, err := arrayIndex(token, len(node)-1)
+		if err != nil {
+			return nil, nil, err
+		}
+		if last {
+			removed := node[i]
+			return append(node[:i], node[i+1:]...), removed, nil
+		}
+		child, removed, err := remove(node[i], path[1:])
+		node[i] = child
+		return node, removed, err
+	}
+
+	return nil, nil, fmt.Errorf("%w: %q não existe", ErrInvalidPatch, token)
+}
+
+func arrayIndex(token string, max int) (int, error) {
+	i, err := strconv.Atoi(token)
+	if err != nil || i < 0 || i > max || (len(token) > 1 && token[0] == '0') {
+		return 0, fmt.Errorf("%w: índice %q inválido", ErrInvalidPatch, token)
+	}
+	return i, nil
+}
+
+func deepCopy(value any) (any, error) {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return nil, err
+	}
+	var out any
+	err = json.Unmarshal(data, &out)
+	return out, err
+}
@@ -0,0 +1,194 @@
+package patch
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestJSONPatch(t *testing.T) {
+	doc := `{"name": "Oil", "tags": ["a", "b"], "stock": {"quantity": 10}}`
+
+	cases := []struct {
+		name        string
+		doc         string
+		patch       string
+		expected    string
+		expectedErr error
+	}{
+		{
+			name:     "add a field",
+			doc:      doc,
+			patch:    `[{"op": "add", "path": "/price", "value": 9.5}]`,
+			expected: `{"name": "Oil", "tags": ["a", "b"], "stock": {"quantity": 10}, "price": 9.5}`,
+		},
+		{
+			name:     "add in the middle and at the end of an array",
+			doc:      doc,
+			patch:    `[{"op": "add", "path": "/tags/1", "value": "x"}, {"op": "add", "path": "/tags/-", "value": "z"}]`,
+			expected: `{"name": "Oil", "tags": ["a", "x", "b", "z"], "stock": {"quantity": 10}}`,
+		},
+		{
+			name:     "remove a field and an array element",
+			doc:      doc,
+			patch:    `[{"op": "remove", "path": "/stock"}, {"op": "remove", "path": "/tags/0"}]`,
+			expected: `{"name": "Oil", "tags": ["b"]}`,
+		},
+		{
+			name:     "replace a nested field",
+			doc:      doc,
+			patch:    `[{"op": "replace", "path": "/stock/quantity", "value": 3}]`,
+			expected: `{"name": "Oil", "tags": ["a", "b"], "stock": {"quantity": 3}}`,
+		},
+		{
+			name:     "replace the whole document",
+			doc:      doc,
+			patch:    `[{"op": "replace", "path": "", "value": [1, 2]}]`,
+			expected: `[1, 2]`,
+		},
+		{
+			name:     "move a field",
+			doc:      doc,
+			patch:    `[{"op": "move", "from": "/stock/quantity", "path": "/quantity"}]`,
+			expected: `{"name": "Oil", "tags": ["a", "b"], "stock": {}, "quantity": 10}`,
+		},
+		{
+			name:     "copy does not share the value with the source",
+			doc:      doc,
+			patch:    `[{"op": "copy", "from": "/stock", "path": "/backup"}, {"op": "replace", "path": "/backup/quantity", "value": 0}]`,
+			expected: `{"name": "Oil", "tags": ["a", "b"], "stock": {"quantity": 10}, "backup": {"quantity": 0}}`,
+		},
+		{
+			name:     "test that passes",
+			doc:      doc,
+			patch:    `[{"op": "test", "path": "/tags", "value": ["a", "b"]}, {"op": "replace", "path": "/name", "value": "Olive oil"}]`,
+			expected: `{"name": "Olive oil", "tags": ["a", "b"], "stock": {"quantity": 10}}`,
+		},
+		{
+			name:        "test that fails cancels the whole patch",
+			doc:         doc,
+			patch:       `[{"op": "replace", "path": "/name", "value": "Olive oil"}, {"op": "test", "path": "/stock/quantity", "value": 11}]`,
+			expectedErr: ErrTestFailed,
+		},
+		{
+			name:     "pointer with ~1 and ~0",
+			doc:      `{"a/b": 1, "m~n": 2}`,
+			patch:    `[{"op": "replace", "path": "/a~1b", "value": 10}, {"op": "move", "from": "/m~0n", "path": "/~01"}]`,
+			expected: `{"a/b": 10, "~1": 2}`,
+		},
+		{
+			name:        "path that does not exist",
+			doc:         doc,
+			patch:       `[{"op": "replace", "path": "/price", "value": 1}]`,
+			expectedErr: ErrInvalidPatch,
+		},
+		{
+			name:        "path without leading slash",
+			doc:         doc,
+			patch:       `[{"op": "remove", "path": "name"}]`,
+			expectedErr: ErrInvalidPatch,
+		},
+		{
+			name:        "unknown operation",
+			doc:         doc,
+			patch:       `[{"op": "rename", "path": "/name", "value": "x"}]`,
+			expectedErr: ErrInvalidPatch,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			// Act/When
+			result, err := JSONPatch([]byte(c.doc), []byte(c.patch))
+
+			// Assert/Then
+			if c.expectedErr != nil {
+				require.ErrorIs(t, err, c.expectedErr)
+				return
+			}
+			require.NoError(t, err)
+			require.JSONEq(t, c.expected, string(result))
+		})
+	}
+}
+
+func TestMergePatch(t *testing.T) {
+	cases := []struct {
+		name     string
+		doc      string
+		patch    string
+		expected string
+	}{
+		{
+			name:     "null deletes the key",
+			doc:      `{"name": "Oil", "price": 9.5}`,
+			patch:    `{"price": null}`,
+			expected: `{"name": "Oil"}`,
+		},
+		{
+			name:     "nested objects are merged",
+			doc:      `{"name": "Oil", "stock": {"quantity": 10, "place": "A1"}}`,
+			patch:    `{"stock": {"quantity": 3, "place": null}, "price": 1}`,
+			expected: `{"name": "Oil", "stock": {"quantity": 3}, "price": 1}`,
+		},
+		{
+			name:     "arrays are replaced",
+			doc:      `{"tags": ["a", "b"]}`,
+			patch:    `{"tags": ["c"]}`,
+			expected: `{"tags": ["c"]}`,
+		},
+		{
+			name:     "a non-object patch replaces the whole document",
+			doc:      `{"name": "Oil"}`,
+			patch:    `["a", "b"]`,
+			expected: `["a", "b"]`,
+		},
+		{
+			name:     "an object patch over a non-object document",
+			doc:      `"Oil"`,
+			patch:    `{"name": "Oil"}`,
+			expected: `{"name": "Oil"}`,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			// Act/When
+			result, err := MergePatch([]byte(c.doc), []byte(c.patch))
+
+			// Assert/Then
+			require.NoError(t, err)
+			require.JSONEq(t, c.expected, string(result))
+		})
+	}
+
+	t.Run("invalid patch", func(t *testing.T) {
+		_, err := MergePatch([]byte(`{}`), []byte(`{`))
+		require.ErrorIs(t, err, ErrInvalidPatch)
+	})
+}
+
+func TestApply(t *testing.T) {
+	type product struct {
+		Name  string  `json:"name"`
+		Price float64 `json:"price"`
+	}
+	value := product{Name: "Oil", Price: 9.5}
+
+	t.Run("application/json is a merge patch", func(t *testing.T) {
+		result, err := Apply(value, "application/json; charset=utf-8", []byte(`{"price": 10}`))
+		require.NoError(t, err)
+		require.Equal(t, product{Name: "Oil", Price: 10}, result)
+	})
+
+	t.Run("json patch", func(t *testing.T) {
+		result, err := Apply(value, ContentTypeJSONPatch, []byte(`[{"op": "replace", "path": "/name", "value": "Olive oil"}]`))
+		require.NoError(t, err)
+		require.Equal(t, product{Name: "Olive oil", Price: 9.5}, result)
+	})
+
+	t.Run("unsupported content type", func(t *testing.T) {
+		_, err := Apply(value, "text/plain", []byte(`{}`))
+		require.ErrorIs(t, err, ErrUnsupportedMediaType)
+	})
+}
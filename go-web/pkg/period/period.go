@@ -0,0 +1,252 @@
+// Package period divide o dia em faixas nomeadas (madrugada, manhã, ...) e conta cada
+// horário na faixa do fuso configurado. O CLI e o serviço HTTP dos desafios usam este
+// pacote, então o mesmo arquivo dá as mesmas contagens por período nos dois.
+package period
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/izabelly/go-web/pkg/country"
+)
+
+const minutesPerDay = 24 * 60
+
+// Nomes das faixas de Default
+const (
+	Madrugada = "madrugada"
+	Manha     = "manha"
+	Tarde     = "tarde"
+	Noite     = "noite"
+)
+
+// Period é uma faixa do dia em minutos desde a meia-noite, de Start (incluído) até
+// End (excluído). Com Start maior que End a faixa passa da meia-noite (ex: 22:00-05:00).
+type Period struct {
+	Name  string `json:"name"`
+	Start int    `json:"start"`
+	End   int    `json:"end"`
+}
+
+// Contains indica se o minuto do dia pertence à faixa
+func (p Period) Contains(minute int) bool {
+	if p.Start < p.End {
+		return minute >= p.Start && minute < p.End
+	}
+	return minute >= p.Start || minute < p.End
+}
+
+func (p Period) String() string {
+	return fmt.Sprintf("%s=%s-%s", p.Name, formatMinute(p.Start), formatMinute(p.End))
+}
+
+// Periods é um conjunto de faixas que cobre o dia inteiro sem sobreposição
+type Periods struct {
+	list     []Period
+	byMinute [minutesPerDay]uint8
+}
+
+// Default são as faixas originais: madrugada até 06:59, manhã até 12:59,
+// tarde até 19:59 e noite até 23:59
+var Default = mustPeriods(
+	Period{Name: Madrugada, Start: 0, End: 7 * 60},
+	Period{Name: Manha, Start: 7 * 60, End: 13 * 60},
+	Period{Name: Tarde, Start: 13 * 60, End: 20 * 60},
+	Period{Name: Noite, Start: 20 * 60, End: minutesPerDay},
+)
+
+// New valida as faixas: nomes únicos, limites entre 00:00 e 24:00 e cada
+// minuto do dia em exatamente uma faixa
+func New(list ...Period) (*Periods, error) {
+	if len(list) == 0 {
+		return nil, errors.New("nenhum período informado")
+	}
+	if len(list) > 255 {
+		return nil, errors.New("no máximo 255 períodos")
+	}
+
+	p := &Periods{list: append([]Period(nil), list...)}
+	names := map[string]bool{}
+	for i, period := range p.list {
+		if period.Name == "" {
+			return nil, errors.New("período sem nome")
+		}
+		if names[period.Name] {
+			return nil, fmt.Errorf("período %q repetido", period.Name)
+		}
+		names[period.Name] = true
+		if period.Start < 0 || period.Start >= minutesPerDay || period.End < 0 || period.End > minutesPerDay || period.Start == period.End {
+			return nil, fmt.Errorf("período %s com limites inválidos", period)
+		}
+
+		for minute := 0; minute < minutesPerDay; minute++ {
+			if !period.Contains(minute) {
+				continue
+			}
+			if other := p.byMinute[minute]; other != 0 {
+				return nil, fmt.Errorf("período %q sobrepõe %q às %s", period.Name, p.list[other-1].Name, formatMinute(minute))
+			}
+			p.byMinute[minute] = uint8(i + 1)
+		}
+	}
+
+	for minute, index := range p.byMinute {
+		if index == 0 {
+			return nil, fmt.Errorf("o horário %s não pertence a nenhum período", formatMinute(minute))
+		}
+	}
+	return p, nil
+}
+
+// Parse lê faixas no formato "madrugada=00:00-06:30,manha=06:30-12:00,..."
+func Parse(spec string) (*Periods, error) {
+	var list []Period
+	for _, item := range strings.Split(spec, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+		name, bounds := split(item, "=")
+		start, end := split(bounds, "-")
+		period := Period{Name: strings.TrimSpace(name)}
+
+		var err error
+		if period.Start, err = parseMinute(start); err != nil {
+			return nil, fmt.Errorf("período %q: %w", item, err)
+		}
+		if period.End, err = parseMinute(end); err != nil {
+			return nil, fmt.Errorf("período %q: %w", item, err)
+		}
+		list = append(list, period)
+	}
+	return New(list...)
+}
+
+// Of retorna o nome da faixa do horário, pelo relógio da própria zona de t
+func (p *Periods) Of(t time.Time) string {
+	return p.list[p.byMinute[t.Hour()*60+t.Minute()]-1].Name
+}
+
+// Names retorna os nomes das faixas na ordem em que foram definidas
+func (p *Periods) Names() []string {
+	names := make([]string, len(p.list))
+	for i, period := range p.list {
+		names[i] = period.Name
+	}
+	return names
+}
+
+// List retorna uma cópia das faixas
+func (p *Periods) List() []Period {
+	return append([]Period(nil), p.list...)
+}
+
+// Has indica se existe uma faixa com o nome
+func (p *Periods) Has(name string) bool {
+	for _, period := range p.list {
+		if period.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// Options define em que faixa e em que fuso o horário de um ticket é contado.
+// O valor zero usa Default na zona em que o horário foi lido.
+type Options struct {
+	// Periods são as faixas do dia; nil usa Default
+	Periods *Periods
+	// Location é o fuso em que as faixas valem; nil usa a zona do próprio horário
+	Location *time.Location
+	// Destinations define o fuso de cada destino pelo código (ou pelo nome como está
+	// no arquivo) e tem precedência sobre Location
+	Destinations map[string]*time.Location
+}
+
+// Of retorna a faixa do horário de um ticket convertendo-o para o fuso do destino
+func (o Options) Of(destination string, t time.Time) string {
+	loc, ok := o.Destinations[destination]
+	if !ok {
+		loc = o.Destinations[country.Normalize(destination)]
+	}
+	if loc != nil {
+		t = t.In(loc)
+	} else if o.Location != nil {
+		t = t.In(o.Location)
+	}
+	return o.periods().Of(t)
+}
+
+// Names retorna os nomes das faixas configuradas
+func (o Options) Names() []string {
+	return o.periods().Names()
+}
+
+// Has indica se existe uma faixa configurada com o nome
+func (o Options) Has(name string) bool {
+	return o.periods().Has(name)
+}
+
+func (o Options) periods() *Periods {
+	if o.Periods == nil {
+		return Default
+	}
+	return o.Periods
+}
+
+// ParseLocations lê fusos por destino no formato "Brazil=America/Sao_Paulo,JP=Asia/Tokyo";
+// os destinos são guardados pelo código do país
+func ParseLocations(spec string) (map[string]*time.Location, error) {
+	locations := map[string]*time.Location{}
+	for _, item := range strings.Split(spec, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+		destination, zone := split(item, "=")
+		destination = strings.TrimSpace(destination)
+		if destination == "" {
+			return nil, fmt.Errorf("fuso %q sem destino", item)
+		}
+		loc, err := time.LoadLocation(strings.TrimSpace(zone))
+		if err != nil {
+			return nil, fmt.Errorf("fuso do destino %q: %w", destination, err)
+		}
+		locations[country.Normalize(destination)] = loc
+	}
+	return locations, nil
+}
+
+func mustPeriods(list ...Period) *Periods {
+	p, err := New(list...)
+	if err != nil {
+		panic(err)
+	}
+	return p
+}
+
+// parseMinute converte HH:MM (até 24:00) em minutos desde a meia-noite
+func parseMinute(value string) (int, error) {
+	value = strings.TrimSpace(value)
+	if value == "24:00" {
+		return minutesPerDay, nil
+	}
+	t, err := time.Parse("15:04", value)
+	if err != nil {
+		return 0, fmt.Errorf("horário inválido %q", value)
+	}
+	return t.Hour()*60 + t.Minute(), nil
+}
+
+func formatMinute(minute int) string {
+	return fmt.Sprintf("%02d:%02d", minute/60, minute%60)
+}
+
+func split(value, sep string) (string, string) {
+	if i := strings.Index(value, sep); i >= 0 {
+		return value[:i], value[i+len(sep):]
+	}
+	return value, ""
+}
@@ -0,0 +1,65 @@
+package period
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestPeriods(t *testing.T) {
+	at := func(hour, minute int) time.Time {
+		return time.Date(2024, 1, 15, hour, minute, 0, 0, time.UTC)
+	}
+
+	t.Run("default periods keep the original bounds", func(t *testing.T) {
+		require.Equal(t, Madrugada, Default.Of(at(6, 59)))
+		require.Equal(t, Manha, Default.Of(at(7, 0)))
+		require.Equal(t, Manha, Default.Of(at(12, 59)))
+		require.Equal(t, Tarde, Default.Of(at(19, 59)))
+		require.Equal(t, Noite, Default.Of(at(20, 0)))
+	})
+
+	t.Run("periods with minute bounds and past midnight", func(t *testing.T) {
+		// Act/When
+		periods, err := Parse("noite=18:30-06:30, dia=06:30-18:30")
+
+		// Assert/Then
+		require.NoError(t, err)
+		require.Equal(t, []string{"noite", "dia"}, periods.Names())
+		require.Equal(t, "noite", periods.Of(at(6, 29)))
+		require.Equal(t, "dia", periods.Of(at(6, 30)))
+		require.Equal(t, "noite", periods.Of(at(23, 0)))
+	})
+
+	t.Run("invalid periods", func(t *testing.T) {
+		_, err := Parse("a=00:00-12:00,b=11:00-24:00")
+		require.ErrorContains(t, err, "sobrepõe")
+
+		_, err = Parse("a=00:00-12:00,b=12:30-24:00")
+		require.ErrorContains(t, err, "12:00 não pertence")
+
+		_, err = Parse("a=00:00-25:00")
+		require.ErrorContains(t, err, "horário inválido")
+
+		_, err = Parse("a=00:00-12:00,a=12:00-24:00")
+		require.ErrorContains(t, err, "repetido")
+	})
+}
+
+func TestOptions_Of(t *testing.T) {
+	// Arrange/Given: 02:00 UTC é 23:00 em São Paulo, 11:00 em Tóquio e 21:00 em Lima
+	locations, err := ParseLocations("Brasil=America/Sao_Paulo, JP=Asia/Tokyo")
+	require.NoError(t, err)
+	opts := Options{Location: time.FixedZone("PET", -5*60*60), Destinations: locations}
+	at := time.Date(2024, 1, 15, 2, 0, 0, 0, time.UTC)
+
+	// Act/When e Assert/Then
+	require.Equal(t, Noite, opts.Of("Brazil", at))
+	require.Equal(t, Manha, opts.Of("Japan", at))
+	require.Equal(t, Noite, opts.Of("Peru", at))
+	require.Equal(t, Madrugada, Options{}.Of("Peru", at))
+
+	_, err = ParseLocations("Brazil=Mars/Olympus")
+	require.Error(t, err)
+}
@@ -0,0 +1,192 @@
+// Package ratelimit implementa um limitador token bucket e o middleware HTTP
+// que responde 429 com Retry-After e os headers RateLimit-*.
+package ratelimit
+
+import (
+	"fmt"
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/izabelly/go-web/pkg/auth"
+)
+
+// Limit define um bucket: Requests a cada Per, acumulando até Burst
+type Limit struct {
+	Requests int
+	Per      time.Duration
+	Burst    int
+}
+
+// ParseLimit lê limites no formato "100/m", "10/s" ou "1000/h"
+func ParseLimit(value string) (Limit, error) {
+	requests, unit, ok := strings.Cut(value, "/")
+	n, err := strconv.Atoi(requests)
+	if !ok || err != nil || n <= 0 {
+		return Limit{}, fmt.Errorf("ratelimit: limite inválido %q", value)
+	}
+
+	per := map[string]time.Duration{"s": time.Second, "m": time.Minute, "h": time.Hour}[unit]
+	if per == 0 {
+		return Limit{}, fmt.Errorf("ratelimit: unidade inválida %q", value)
+	}
+	return Limit{Requests: n, Per: per, Burst: n}, nil
+}
+
+// Validate rejeita limites que deixariam a taxa de reposição infinita ou NaN
+// (Requests ou Per não positivos) e Burst negativo
+func (l Limit) Validate() error {
+	if l.Requests <= 0 || l.Per <= 0 || l.Burst < 0 {
+		return fmt.Errorf("ratelimit: limite inválido %d/%s (burst %d)", l.Requests, l.Per, l.Burst)
+	}
+	return nil
+}
+
+func (l Limit) rate() float64 {
+	return float64(l.Requests) / l.Per.Seconds()
+}
+
+func (l Limit) burst() int {
+	if l.Burst > 0 {
+		return l.Burst
+	}
+	return l.Requests
+}
+
+// Result é o resultado de uma tentativa de consumir um token
+type Result struct {
+	Allowed    bool
+	Limit      int
+	Remaining  int
+	Reset      time.Duration // tempo até o bucket ficar cheio
+	RetryAfter time.Duration // tempo até o próximo token, quando negado
+}
+
+// Store guarda o estado dos buckets. A implementação em memória atende uma
+// instância; um store compartilhado (ex: redis) pode implementar a mesma interface.
+type Store interface {
+	Take(key string, limit Limit) Result
+}
+
+type bucket struct {
+	tokens float64
+	last   time.Time
+	limit  Limit
+}
+
+// Memory é um Store em memória, seguro para uso concorrente
+type Memory struct {
+	mu        sync.Mutex
+	buckets   map[string]*bucket
+	lastSweep time.Time
+	now       func() time.Time
+}
+
+func NewMemory() *Memory {
+	return &Memory{buckets: map[string]*bucket{}, now: time.Now}
+}
+
+// Take consome um token do bucket da chave
+func (m *Memory) Take(key string, limit Limit) Result {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := m.now()
+	m.sweep(now)
+
+	rate, burst := limit.rate(), float64(limit.burst())
+	b, ok := m.buckets[key]
+	if !ok {
+		b = &bucket{tokens: burst, last: now, limit: limit}
+		m.buckets[key] = b
+	}
+
+	b.tokens = math.Min(burst, b.tokens+now.Sub(b.last).Seconds()*rate)
+	b.last = now
+
+	result := Result{Limit: int(burst)}
+	if b.tokens >= 1 {
+		b.tokens--
+		result.Allowed = true
+	} else {
+		result.RetryAfter = seconds((1 - b.tokens) / rate)
+	}
+	result.Remaining = int(b.tokens)
+	result.Reset = seconds((burst - b.tokens) / rate)
+	return result
+}
+
+// sweep remove, no máximo uma vez por minuto, os buckets que já estariam cheios
+func (m *Memory) sweep(now time.Time) {
+	if now.Sub(m.lastSweep) < time.Minute {
+		return
+	}
+	m.lastSweep = now
+
+	for key, b := range m.buckets {
+		if b.tokens+now.Sub(b.last).Seconds()*b.limit.rate() >= float64(b.limit.burst()) {
+			delete(m.buckets, key)
+		}
+	}
+}
+
+func seconds(s float64) time.Duration {
+	return time.Duration(s * float64(time.Second))
+}
+
+// KeyFunc define de quem é o bucket de uma requisição
+type KeyFunc func(r *http.Request) string
+
+// ByIP usa o IP do cliente (combine com middleware.RealIP atrás de proxy)
+func ByIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
+
+// ByPrincipal usa o principal autenticado (API key ou sub do JWT) e cai para o IP
+func ByPrincipal(r *http.Request) string {
+	if principal, ok := auth.FromContext(r.Context()); ok && principal.ID != "" {
+		return "principal:" + principal.ID
+	}
+	return "ip:" + ByIP(r)
+}
+
+// Middleware limita as requisições do grupo name por chave. Com onError nil a
+// resposta de 429 segue o formato {"status", "message"}. Entra em pânico com um
+// limite inválido (veja Limit.Validate), como um erro de configuração na montagem.
+func Middleware(store Store, name string, limit Limit, key KeyFunc, onError auth.ErrorFunc) func(http.Handler) http.Handler {
+	if err := limit.Validate(); err != nil {
+		panic(err)
+	}
+	if onError == nil {
+		onError = auth.WriteError
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			result := store.Take(name+"|"+key(r), limit)
+
+			w.Header().Set("RateLimit-Limit", strconv.Itoa(result.Limit))
+			w.Header().Set("RateLimit-Remaining", strconv.Itoa(result.Remaining))
+			w.Header().Set("RateLimit-Reset", strconv.Itoa(ceilSeconds(result.Reset)))
+			if !result.Allowed {
+				w.Header().Set("Retry-After", strconv.Itoa(ceilSeconds(result.RetryAfter)))
+				onError(w, http.StatusTooManyRequests, "rate limit exceeded")
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+func ceilSeconds(d time.Duration) int {
+	return int(math.Ceil(d.Seconds()))
+}
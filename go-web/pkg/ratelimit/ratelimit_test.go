@@ -0,0 +1,69 @@
+package ratelimit
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestMemory_Take(t *testing.T) {
+	// Arrange/Given
+	now := time.Unix(0, 0)
+	store := NewMemory()
+	store.now = func() time.Time { return now }
+	limit := Limit{Requests: 2, Per: time.Second}
+
+	// Act/When + Assert/Then
+	require.True(t, store.Take("a", limit).Allowed)
+	require.True(t, store.Take("a", limit).Allowed)
+
+	denied := store.Take("a", limit)
+	require.False(t, denied.Allowed)
+	require.Equal(t, 500*time.Millisecond, denied.RetryAfter)
+	require.True(t, store.Take("b", limit).Allowed) // outra chave tem o próprio bucket
+
+	now = now.Add(500 * time.Millisecond)
+	require.True(t, store.Take("a", limit).Allowed)
+}
+
+func TestMiddleware(t *testing.T) {
+	// Arrange/Given
+	limit, err := ParseLimit("1/m")
+	require.NoError(t, err)
+	rt := Middleware(NewMemory(), "test", limit, ByIP, nil)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+	// Act/When
+	first := httptest.NewRecorder()
+	rt.ServeHTTP(first, httptest.NewRequest("GET", "/products", nil))
+	second := httptest.NewRecorder()
+	rt.ServeHTTP(second, httptest.NewRequest("GET", "/products", nil))
+
+	// Assert/Then
+	require.Equal(t, http.StatusOK, first.Code)
+	require.Equal(t, "1", first.Header().Get("RateLimit-Limit"))
+	require.Equal(t, "0", first.Header().Get("RateLimit-Remaining"))
+	require.Equal(t, http.StatusTooManyRequests, second.Code)
+	require.Equal(t, "60", second.Header().Get("Retry-After"))
+	require.JSONEq(t, `{"status": "Too Many Requests", "message": "rate limit exceeded"}`, second.Body.String())
+}
+
+func TestLimit_Validate(t *testing.T) {
+	// Arrange/Given
+	invalid := []Limit{
+		{Requests: 10},
+		{Requests: 0, Per: time.Second},
+		{Requests: -1, Per: time.Second},
+		{Requests: 10, Per: -time.Second},
+		{Requests: 10, Per: time.Second, Burst: -1},
+	}
+
+	// Act/When + Assert/Then
+	require.NoError(t, Limit{Requests: 10, Per: time.Second}.Validate())
+	for _, limit := range invalid {
+		require.Error(t, limit.Validate(), "%+v", limit)
+		require.Panics(t, func() { Middleware(NewMemory(), "test", limit, ByIP, nil) }, "%+v", limit)
+	}
+}
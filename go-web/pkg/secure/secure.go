@@ -0,0 +1,75 @@
+// Package secure adiciona os headers de segurança das respostas HTTP.
+package secure
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Config define os headers enviados. Campos vazios não geram header.
+type Config struct {
+	// HSTSMaxAge ativa o Strict-Transport-Security, enviado só em conexões HTTPS
+	HSTSMaxAge time.Duration
+	// HSTSIncludeSubdomains acrescenta includeSubDomains ao HSTS
+	HSTSIncludeSubdomains bool
+	// FrameOptions é o X-Frame-Options (ex: DENY)
+	FrameOptions string
+	// ReferrerPolicy é o Referrer-Policy (ex: no-referrer)
+	ReferrerPolicy string
+	// CSP é o Content-Security-Policy das respostas da API
+	CSP string
+	// DocsPrefix é o caminho da UI de documentação, que usa DocsCSP no lugar de CSP
+	DocsPrefix string
+	// DocsCSP libera scripts e estilos próprios para a UI de documentação
+	DocsCSP string
+}
+
+// Default é a configuração para APIs JSON: nada pode ser embutido ou executado
+var Default = Config{
+	HSTSMaxAge:            365 * 24 * time.Hour,
+	HSTSIncludeSubdomains: true,
+	FrameOptions:          "DENY",
+	ReferrerPolicy:        "no-referrer",
+	CSP:                   "default-src 'none'; frame-ancestors 'none'",
+	DocsPrefix:            "/docs",
+	DocsCSP:               "default-src 'self'; img-src 'self' data:; style-src 'self' 'unsafe-inline'; frame-ancestors 'none'",
+}
+
+// Headers aplica a Config em todas as respostas
+func Headers(cfg Config) func(http.Handler) http.Handler {
+	hsts := ""
+	if cfg.HSTSMaxAge > 0 {
+		hsts = "max-age=" + strconv.Itoa(int(cfg.HSTSMaxAge.Seconds()))
+		if cfg.HSTSIncludeSubdomains {
+			hsts += "; includeSubDomains"
+		}
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			h := w.Header()
+			h.Set("X-Content-Type-Options", "nosniff")
+			if hsts != "" && (r.TLS != nil || r.Header.Get("X-Forwarded-Proto") == "https") {
+				h.Set("Strict-Transport-Security", hsts)
+			}
+			if cfg.FrameOptions != "" {
+				h.Set("X-Frame-Options", cfg.FrameOptions)
+			}
+			if cfg.ReferrerPolicy != "" {
+				h.Set("Referrer-Policy", cfg.ReferrerPolicy)
+			}
+
+			csp := cfg.CSP
+			if cfg.DocsPrefix != "" && cfg.DocsCSP != "" && strings.HasPrefix(r.URL.Path, cfg.DocsPrefix) {
+				csp = cfg.DocsCSP
+			}
+			if csp != "" {
+				h.Set("Content-Security-Policy", csp)
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
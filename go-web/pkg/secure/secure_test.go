@@ -0,0 +1,41 @@
+package secure
+
+import (
+	"crypto/tls"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestHeaders(t *testing.T) {
+	// Arrange/Given
+	rt := Headers(Default)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+	t.Run("api response over http", func(t *testing.T) {
+		// Act/When
+		res := httptest.NewRecorder()
+		rt.ServeHTTP(res, httptest.NewRequest("GET", "/products", nil))
+
+		// Assert/Then
+		require.Equal(t, "nosniff", res.Header().Get("X-Content-Type-Options"))
+		require.Equal(t, "DENY", res.Header().Get("X-Frame-Options"))
+		require.Equal(t, Default.CSP, res.Header().Get("Content-Security-Policy"))
+		require.Empty(t, res.Header().Get("Strict-Transport-Security"))
+	})
+
+	t.Run("docs over https", func(t *testing.T) {
+		// Arrange/Given
+		req := httptest.NewRequest("GET", "/docs/index.html", nil)
+		req.TLS = &tls.ConnectionState{}
+
+		// Act/When
+		res := httptest.NewRecorder()
+		rt.ServeHTTP(res, req)
+
+		// Assert/Then
+		require.Equal(t, Default.DocsCSP, res.Header().Get("Content-Security-Policy"))
+		require.Equal(t, "max-age=31536000; includeSubDomains", res.Header().Get("Strict-Transport-Security"))
+	})
+}
@@ -0,0 +1,108 @@
+// Package share calcula a participação de uma parte no total, como razão (0 a 1) e
+// porcentagem (0 a 100), com precisão e arredondamento configuráveis. O CLI e o
+// serviço HTTP dos desafios usam este pacote, então o mesmo arquivo dá o mesmo valor nos dois.
+package share
+
+import (
+	"errors"
+	"fmt"
+	"math"
+)
+
+// Modos de arredondamento de Options.Rounding
+const (
+	// RoundHalfUp arredonda o meio para longe do zero (4,5 -> 5)
+	RoundHalfUp = "half-up"
+	// RoundHalfEven arredonda o meio para o número par (4,5 -> 4, 5,5 -> 6)
+	RoundHalfEven = "half-even"
+	// RoundDown descarta as casas a mais (4,59 -> 4,5)
+	RoundDown = "down"
+	// RoundUp arredonda para cima qualquer casa a mais (4,51 -> 4,6)
+	RoundUp = "up"
+)
+
+// FullPrecision em Options.Precision não arredonda os valores
+const FullPrecision = -1
+
+// MaxPrecision é o maior número de casas decimais aceito
+const MaxPrecision = 10
+
+// ErrInvalidOptions indica precisão ou modo de arredondamento inválidos
+var ErrInvalidOptions = errors.New("opções de participação inválidas")
+
+// DefaultOptions são as opções padrão do CLI e do serviço HTTP: a porcentagem com
+// duas casas, arredondada com RoundHalfUp
+var DefaultOptions = Options{Precision: 2, Rounding: RoundHalfUp}
+
+// Options configura o arredondamento da participação
+type Options struct {
+	// Precision é o número de casas decimais da porcentagem; a razão tem duas casas
+	// a mais, então Ratio*100 é sempre igual a Percentage. FullPrecision não arredonda.
+	Precision int
+	// Rounding é o modo de arredondamento; vazio usa RoundHalfUp
+	Rounding string
+}
+
+// Validate verifica a precisão e o modo de arredondamento
+func (o Options) Validate() error {
+	if o.Precision < FullPrecision || o.Precision > MaxPrecision {
+		return fmt.Errorf("%w: precisão %d (use de 0 a %d, ou %d para não arredondar)", ErrInvalidOptions, o.Precision, MaxPrecision, FullPrecision)
+	}
+	switch o.Rounding {
+	case "", RoundHalfUp, RoundHalfEven, RoundDown, RoundUp:
+		return nil
+	}
+	return fmt.Errorf("%w: arredondamento %q (use %s, %s, %s ou %s)", ErrInvalidOptions, o.Rounding, RoundHalfUp, RoundHalfEven, RoundDown, RoundUp)
+}
+
+// Share é a participação dos tickets de um destino no total
+type Share struct {
+	// Destination é o código do país; New deixa vazio para quem chama preencher
+	Destination string `json:"destination"`
+	Count       int    `json:"count"`
+	Total       int    `json:"total"`
+	// Ratio é a participação de 0 a 1
+	Ratio float64 `json:"ratio"`
+	// Percentage é a participação de 0 a 100
+	Percentage float64 `json:"percentage"`
+}
+
+// New calcula a participação de count em total; com total zero a participação é zero
+func New(count, total int, opts Options) (Share, error) {
+	if err := opts.Validate(); err != nil {
+		return Share{}, err
+	}
+	if count < 0 || total < 0 || count > total {
+		return Share{}, fmt.Errorf("participação inválida %d de %d", count, total)
+	}
+
+	share := Share{Count: count, Total: total}
+	if total == 0 {
+		return share, nil
+	}
+	if opts.Precision == FullPrecision {
+		share.Ratio = float64(count) / float64(total)
+		share.Percentage = float64(count) * 100 / float64(total)
+		return share, nil
+	}
+
+	// units é a porcentagem em unidades da última casa; dividir um inteiro por uma
+	// potência de 10 exata dá o decimal mais próximo, sem erro acumulado
+	scale := math.Pow10(opts.Precision)
+	units := round(float64(count)*100*scale/float64(total), opts.Rounding)
+	share.Percentage = units / scale
+	share.Ratio = units / (scale * 100)
+	return share, nil
+}
+
+func round(value float64, mode string) float64 {
+	switch mode {
+	case RoundHalfEven:
+		return math.RoundToEven(value)
+	case RoundDown:
+		return math.Floor(value)
+	case RoundUp:
+		return math.Ceil(value)
+	}
+	return math.Round(value)
+}
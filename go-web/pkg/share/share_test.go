@@ -0,0 +1,56 @@
+package share
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestNew(t *testing.T) {
+	t.Run("rounding modes", func(t *testing.T) {
+		cases := []struct {
+			rounding   string
+			percentage float64
+			ratio      float64
+		}{
+			{RoundHalfUp, 12.5, 0.125},
+			{RoundHalfEven, 12.4, 0.124},
+			{RoundDown, 12.4, 0.124},
+			{RoundUp, 12.5, 0.125},
+		}
+		for _, c := range cases {
+			// Act/When: 249 de 2000 = 12,45%
+			share, err := New(249, 2000, Options{Precision: 1, Rounding: c.rounding})
+
+			// Assert/Then
+			require.NoError(t, err, c.rounding)
+			require.Equal(t, c.percentage, share.Percentage, c.rounding)
+			require.Equal(t, c.ratio, share.Ratio, c.rounding)
+		}
+	})
+
+	t.Run("precision", func(t *testing.T) {
+		share, err := New(1, 3, DefaultOptions)
+		require.NoError(t, err)
+		require.Equal(t, 33.33, share.Percentage)
+		require.Equal(t, 0.3333, share.Ratio)
+
+		share, err = New(1, 3, Options{Precision: FullPrecision})
+		require.NoError(t, err)
+		require.Equal(t, 100.0/3, share.Percentage)
+		require.Equal(t, 1.0/3, share.Ratio)
+	})
+
+	t.Run("zero total and invalid options", func(t *testing.T) {
+		share, err := New(0, 0, DefaultOptions)
+		require.NoError(t, err)
+		require.Equal(t, Share{}, share)
+
+		_, err = New(1, 2, Options{Precision: 11})
+		require.ErrorIs(t, err, ErrInvalidOptions)
+		_, err = New(1, 2, Options{Rounding: "bancário"})
+		require.ErrorIs(t, err, ErrInvalidOptions)
+		_, err = New(3, 2, Options{})
+		require.Error(t, err)
+	})
+}
@@ -0,0 +1,34 @@
+// Package sharetest guarda um arquivo de tickets e as participações esperadas nele.
+// Os testes do CLI e do serviço HTTP conferem os mesmos casos, então os dois só
+// passam enquanto derem o mesmo valor para o mesmo arquivo.
+package sharetest
+
+import (
+	_ "embed"
+
+	"github.com/izabelly/go-web/pkg/share"
+)
+
+// TicketsCSV são 7 tickets no layout id,name,email,destination,hour,price:
+// 3 para o Brasil, 2 para o Chile, 1 para o Peru e 1 para o Japão, com os destinos
+// escritos de formas diferentes
+//
+//go:embed tickets.csv
+var TicketsCSV string
+
+// Case é uma consulta de participação e o resultado esperado
+type Case struct {
+	// Destination é o destino como o usuário digita (nome, apelido ou código)
+	Destination string
+	Options     share.Options
+	Expected    share.Share
+}
+
+// Cases cobrem os modos de arredondamento e a precisão total sobre TicketsCSV
+var Cases = []Case{
+	{"Brasil", share.Options{Precision: 0, Rounding: share.RoundHalfEven}, share.Share{Destination: "BR", Count: 3, Total: 7, Ratio: 0.43, Percentage: 43}},
+	{"PE", share.Options{Precision: 2, Rounding: share.RoundHalfUp}, share.Share{Destination: "PE", Count: 1, Total: 7, Ratio: 0.1429, Percentage: 14.29}},
+	{"peru", share.Options{Precision: 2, Rounding: share.RoundDown}, share.Share{Destination: "PE", Count: 1, Total: 7, Ratio: 0.1428, Percentage: 14.28}},
+	{"Chile", share.Options{Precision: 1, Rounding: share.RoundUp}, share.Share{Destination: "CL", Count: 2, Total: 7, Ratio: 0.286, Percentage: 28.6}},
+	{"JPN", share.Options{Precision: share.FullPrecision}, share.Share{Destination: "JP", Count: 1, Total: 7, Ratio: 1.0 / 7, Percentage: 100.0 / 7}},
+}
@@ -0,0 +1,194 @@
+// Package ticketcsv reúne a leitura tolerante dos arquivos de tickets: colunas pelo
+// cabeçalho (com apelidos em português, inglês e espanhol), detecção do cabeçalho,
+// encoding utf-8 ou latin1 e horários em vários formatos. O CLI e o serviço HTTP dos
+// desafios usam este pacote, então os dois aceitam exatamente os mesmos arquivos.
+package ticketcsv
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// Colunas usadas no mapeamento
+const (
+	ColumnID          = "id"
+	ColumnName        = "name"
+	ColumnEmail       = "email"
+	ColumnDestination = "destination"
+	ColumnTime        = "time"
+	ColumnPrice       = "price"
+)
+
+// Modos de cabeçalho
+const (
+	HeaderAuto    = "auto"    // detecta pelo conteúdo da primeira linha
+	HeaderPresent = "present" // a primeira linha sempre é cabeçalho
+	HeaderAbsent  = "absent"  // o arquivo não tem cabeçalho
+)
+
+// Encodings aceitos
+const (
+	EncodingUTF8   = "utf-8"
+	EncodingLatin1 = "latin1"
+)
+
+// DefaultTimeFormats são os formatos de horário aceitos quando nenhum é configurado
+var DefaultTimeFormats = []string{"15:04", "15:04:05", "3:04PM", "3:04 PM", "1504"}
+
+// defaultColumns é o layout original: id,nome,email,destino,hora,preço
+var defaultColumns = map[string]int{
+	ColumnID:          0,
+	ColumnName:        1,
+	ColumnEmail:       2,
+	ColumnDestination: 3,
+	ColumnTime:        4,
+	ColumnPrice:       5,
+}
+
+// headerAliases são os nomes reconhecidos no cabeçalho de cada coluna
+var headerAliases = map[string]string{
+	"id": ColumnID, "ticket": ColumnID, "ticket_id": ColumnID,
+	"name": ColumnName, "nome": ColumnName, "nombre": ColumnName, "passenger": ColumnName,
+	"email": ColumnEmail, "e-mail": ColumnEmail, "mail": ColumnEmail, "correo": ColumnEmail,
+	"destination": ColumnDestination, "destino": ColumnDestination, "country": ColumnDestination, "pais": ColumnDestination, "país": ColumnDestination,
+	"time": ColumnTime, "hour": ColumnTime, "hora": ColumnTime, "horario": ColumnTime, "horário": ColumnTime,
+	"price": ColumnPrice, "preco": ColumnPrice, "preço": ColumnPrice, "precio": ColumnPrice, "valor": ColumnPrice,
+}
+
+// IsHeader indica se a primeira linha é o cabeçalho no modo informado; vazio é HeaderAuto
+func IsHeader(mode string, record []string) bool {
+	return mode == HeaderPresent || ((mode == "" || mode == HeaderAuto) && LooksLikeHeader(record))
+}
+
+// LooksLikeHeader considera cabeçalho a linha com ao menos dois nomes conhecidos
+// e nenhum valor numérico (ids e preços são números)
+func LooksLikeHeader(record []string) bool {
+	known := 0
+	for _, field := range record {
+		field = strings.TrimSpace(field)
+		if _, err := strconv.ParseFloat(field, 64); err == nil {
+			return false
+		}
+		if _, ok := headerAliases[normalizeHeader(field)]; ok {
+			known++
+		}
+	}
+	return known >= 2
+}
+
+// Mapping retorna o índice de cada coluna: o layout original quando header é nil,
+// senão as colunas reconhecidas no cabeçalho; columns tem precedência sobre os dois
+func Mapping(header []string, columns map[string]int) map[string]int {
+	mapping := map[string]int{}
+	if header == nil {
+		for name, index := range defaultColumns {
+			mapping[name] = index
+		}
+	}
+	for index, name := range header {
+		if column, ok := headerAliases[normalizeHeader(name)]; ok {
+			if _, seen := mapping[column]; !seen {
+				mapping[column] = index
+			}
+		}
+	}
+	for name, index := range columns {
+		mapping[name] = index
+	}
+	return mapping
+}
+
+// Width é o número mínimo de campos que uma linha precisa ter para as colunas
+func Width(columns map[string]int) int {
+	n := 0
+	for _, index := range columns {
+		n = max(n, index+1)
+	}
+	return n
+}
+
+func normalizeHeader(name string) string {
+	name = strings.TrimPrefix(name, "\ufeff")
+	return strings.ToLower(strings.TrimSpace(name))
+}
+
+// ParseTime converte o horário tentando cada layout (vazio usa DefaultTimeFormats);
+// am/pm minúsculos são aceitos
+func ParseTime(value string, formats []string) (time.Time, error) {
+	if len(formats) == 0 {
+		formats = DefaultTimeFormats
+	}
+	value = strings.ToUpper(strings.TrimSpace(value))
+	for _, format := range formats {
+		if t, err := time.Parse(format, value); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("erro ao converter tempo: %q não está em nenhum dos formatos %v", value, formats)
+}
+
+// Decode converte o conteúdo para UTF-8, removendo o BOM
+func Decode(r io.Reader, encoding string) (io.Reader, error) {
+	switch strings.ToLower(encoding) {
+	case "", EncodingUTF8, "utf8":
+		br := bufio.NewReader(r)
+		if bom, err := br.Peek(3); err == nil && bytes.Equal(bom, []byte("\ufeff")) {
+			br.Discard(3)
+		}
+		return br, nil
+	case EncodingLatin1, "iso-8859-1":
+		return &latin1Reader{r: bufio.NewReader(r)}, nil
+	}
+	return nil, unsupported(encoding)
+}
+
+// Encode converte o conteúdo UTF-8 para o encoding lido por Decode
+func Encode(data []byte, encoding string) ([]byte, error) {
+	switch strings.ToLower(encoding) {
+	case "", EncodingUTF8, "utf8":
+		return data, nil
+	case EncodingLatin1, "iso-8859-1":
+		encoded := make([]byte, 0, len(data))
+		for _, r := range string(data) {
+			if r > 0xff {
+				return nil, fmt.Errorf("o caractere %q não existe em latin1", r)
+			}
+			encoded = append(encoded, byte(r))
+		}
+		return encoded, nil
+	}
+	return nil, unsupported(encoding)
+}
+
+func unsupported(encoding string) error {
+	return fmt.Errorf("encoding não suportado %q (use utf-8 ou latin1)", encoding)
+}
+
+// latin1Reader converte ISO-8859-1 para UTF-8: cada byte é o próprio code point
+type latin1Reader struct {
+	r   *bufio.Reader
+	buf []byte
+}
+
+func (l *latin1Reader) Read(p []byte) (int, error) {
+	for len(l.buf) < len(p) {
+		b, err := l.r.ReadByte()
+		if err != nil {
+			if len(l.buf) == 0 {
+				return 0, err
+			}
+			break
+		}
+		l.buf = utf8.AppendRune(l.buf, rune(b))
+	}
+
+	n := copy(p, l.buf)
+	l.buf = l.buf[n:]
+	return n, nil
+}
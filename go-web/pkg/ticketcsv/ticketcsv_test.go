@@ -0,0 +1,93 @@
+package ticketcsv
+
+import (
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestMapping(t *testing.T) {
+	t.Run("header with aliases in other order", func(t *testing.T) {
+		// Arrange/Given
+		header := []string{"\ufeffPreço", "Destino", "Hora", "Nombre", "E-mail", "Ticket"}
+
+		// Act/When
+		columns := Mapping(header, nil)
+
+		// Assert/Then
+		require.True(t, LooksLikeHeader(header))
+		require.True(t, IsHeader("", header))
+		expected := map[string]int{ColumnPrice: 0, ColumnDestination: 1, ColumnTime: 2, ColumnName: 3, ColumnEmail: 4, ColumnID: 5}
+		require.Equal(t, expected, columns)
+		require.Equal(t, 6, Width(columns))
+	})
+
+	t.Run("default layout with configured columns", func(t *testing.T) {
+		record := []string{"1", "Tait", "tmc0@scribd.com", "Finland", "17:11", "785"}
+		require.False(t, LooksLikeHeader(record))
+		require.True(t, IsHeader(HeaderPresent, record))
+		require.False(t, IsHeader(HeaderAbsent, []string{"name", "email"}))
+
+		columns := Mapping(nil, map[string]int{ColumnPrice: 7})
+		require.Equal(t, 7, columns[ColumnPrice])
+		require.Equal(t, 3, columns[ColumnDestination])
+		require.Equal(t, 8, Width(columns))
+	})
+}
+
+func TestParseTime(t *testing.T) {
+	cases := []struct {
+		value    string
+		expected string
+	}{
+		{"9:05", "09:05"},
+		{"17:11:30", "17:11"},
+		{"5:11 pm", "17:11"},
+		{"5:11PM", "17:11"},
+		{"0930", "09:30"},
+	}
+	for _, c := range cases {
+		parsed, err := ParseTime(c.value, nil)
+		require.NoError(t, err, c.value)
+		require.Equal(t, c.expected, parsed.Format("15:04"), c.value)
+	}
+
+	_, err := ParseTime("25:00", nil)
+	require.Error(t, err)
+	_, err = ParseTime("5:11 pm", []string{"15:04"})
+	require.Error(t, err)
+}
+
+func TestDecodeEncode(t *testing.T) {
+	t.Run("utf-8 drops the BOM", func(t *testing.T) {
+		r, err := Decode(strings.NewReader("\ufeffid,país\n"), "")
+		require.NoError(t, err)
+		data, _ := io.ReadAll(r)
+		require.Equal(t, "id,país\n", string(data))
+	})
+
+	t.Run("latin1 round trip", func(t *testing.T) {
+		// Arrange/Given
+		latin1 := "Jos\xe9,Per\xfa\n"
+
+		// Act/When
+		r, err := Decode(strings.NewReader(latin1), EncodingLatin1)
+		require.NoError(t, err)
+		decoded, _ := io.ReadAll(r)
+		encoded, err := Encode(decoded, "ISO-8859-1")
+
+		// Assert/Then
+		require.NoError(t, err)
+		require.Equal(t, "José,Perú\n", string(decoded))
+		require.Equal(t, latin1, string(encoded))
+	})
+
+	t.Run("unsupported encoding and characters", func(t *testing.T) {
+		_, err := Decode(strings.NewReader(""), "utf-16")
+		require.ErrorContains(t, err, "utf-16")
+		_, err = Encode([]byte("東京"), EncodingLatin1)
+		require.Error(t, err)
+	})
+}
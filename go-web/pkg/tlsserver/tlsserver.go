@@ -0,0 +1,241 @@
+// Package tlsserver serve HTTP com TLS configurável: certificado e chave em
+// arquivos recarregados quando mudam, versão mínima do TLS e mTLS opcional
+// validando o certificado do cliente contra um bundle de CAs.
+package tlsserver
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Modos de autenticação do cliente
+const (
+	ClientAuthNone     = "none"
+	ClientAuthOptional = "optional" // valida o certificado quando o cliente envia
+	ClientAuthRequire  = "require"  // mTLS: sem certificado válido não há handshake
+)
+
+// Config define o TLS do servidor. Sem CertFile o servidor sobe em HTTP puro.
+type Config struct {
+	CertFile string
+	KeyFile  string
+	// ClientCAFile é o bundle PEM das CAs aceitas nos certificados de cliente
+	ClientCAFile string
+	// ClientAuth é none, optional ou require; vazio vale require quando há ClientCAFile
+	ClientAuth string
+	// MinVersion é "1.2" ou "1.3"; vazio vale 1.2
+	MinVersion string
+	// ReloadInterval é o intervalo de verificação dos arquivos; 0 usa 30s
+	ReloadInterval time.Duration
+}
+
+// Enabled informa se o TLS está configurado
+func (c Config) Enabled() bool {
+	return c.CertFile != ""
+}
+
+// FromEnv lê a Config das variáveis TLS_CERT_FILE, TLS_KEY_FILE, TLS_CLIENT_CA_FILE,
+// TLS_CLIENT_AUTH, TLS_MIN_VERSION e TLS_RELOAD_INTERVAL
+func FromEnv() Config {
+	interval, _ := time.ParseDuration(os.Getenv("TLS_RELOAD_INTERVAL"))
+	return Config{
+		CertFile:       os.Getenv("TLS_CERT_FILE"),
+		KeyFile:        os.Getenv("TLS_KEY_FILE"),
+		ClientCAFile:   os.Getenv("TLS_CLIENT_CA_FILE"),
+		ClientAuth:     os.Getenv("TLS_CLIENT_AUTH"),
+		MinVersion:     os.Getenv("TLS_MIN_VERSION"),
+		ReloadInterval: interval,
+	}
+}
+
+// ParseVersion converte "1.2"/"1.3" para a constante do crypto/tls
+func ParseVersion(value string) (uint16, error) {
+	switch strings.TrimPrefix(strings.ToLower(value), "tls") {
+	case "", "1.2":
+		return tls.VersionTLS12, nil
+	case "1.3":
+		return tls.VersionTLS13, nil
+	}
+	return 0, fmt.Errorf("tlsserver: versão mínima inválida %q (use 1.2 ou 1.3)", value)
+}
+
+// cipherSuites são as suítes aceitas no TLS 1.2 (o 1.3 não é configurável): só ECDHE
+// com AEAD, fixas para não depender dos padrões da versão do go de quem importa o pacote
+var cipherSuites = []uint16{
+	tls.TLS_ECDHE_ECDSA_WITH_AES_128_GCM_SHA256,
+	tls.TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256,
+	tls.TLS_ECDHE_ECDSA_WITH_AES_256_GCM_SHA384,
+	tls.TLS_ECDHE_RSA_WITH_AES_256_GCM_SHA384,
+	tls.TLS_ECDHE_ECDSA_WITH_CHACHA20_POLY1305_SHA256,
+	tls.TLS_ECDHE_RSA_WITH_CHACHA20_POLY1305_SHA256,
+}
+
+func (c Config) clientAuth() (tls.ClientAuthType, error) {
+	mode := c.ClientAuth
+	if mode == "" && c.ClientCAFile != "" {
+		mode = ClientAuthRequire
+	}
+
+	switch mode {
+	case "", ClientAuthNone:
+		return tls.NoClientCert, nil
+	case ClientAuthOptional:
+		return tls.VerifyClientCertIfGiven, nil
+	case ClientAuthRequire:
+		return tls.RequireAndVerifyClientCert, nil
+	}
+	return 0, fmt.Errorf("tlsserver: client auth inválido %q", c.ClientAuth)
+}
+
+// Reloader guarda o certificado e as CAs atuais e os recarrega quando os arquivos mudam.
+// Um arquivo novo inválido é ignorado e o material anterior continua em uso.
+type Reloader struct {
+	cfg      Config
+	base     *tls.Config
+	mu       sync.RWMutex
+	cert     *tls.Certificate
+	clientCA *x509.CertPool
+	modTimes map[string]time.Time
+}
+
+// NewReloader carrega os arquivos da Config e monta o tls.Config base
+func NewReloader(cfg Config) (*Reloader, error) {
+	minVersion, err := ParseVersion(cfg.MinVersion)
+	if err != nil {
+		return nil, err
+	}
+	clientAuth, err := cfg.clientAuth()
+	if err != nil {
+		return nil, err
+	}
+	if clientAuth != tls.NoClientCert && cfg.ClientCAFile == "" {
+		return nil, errors.New("tlsserver: client auth exige TLS_CLIENT_CA_FILE")
+	}
+
+	r := &Reloader{
+		cfg:  cfg,
+		base: &tls.Config{MinVersion: minVersion, ClientAuth: clientAuth, CipherSuites: cipherSuites},
+	}
+	if _, err := r.Reload(); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// TLSConfig retorna o tls.Config do servidor; cada handshake usa o material atual
+func (r *Reloader) TLSConfig() *tls.Config {
+	cfg := r.base.Clone()
+	cfg.GetConfigForClient = func(*tls.ClientHelloInfo) (*tls.Config, error) {
+		r.mu.RLock()
+		defer r.mu.RUnlock()
+
+		current := r.base.Clone()
+		current.Certificates = []tls.Certificate{*r.cert}
+		current.ClientCAs = r.clientCA
+		return current, nil
+	}
+	return cfg
+}
+
+// Reload relê os arquivos que mudaram desde a última leitura e informa se algo mudou
+func (r *Reloader) Reload() (bool, error) {
+	files := []string{r.cfg.CertFile, r.cfg.KeyFile}
+	if r.cfg.ClientCAFile != "" {
+		files = append(files, r.cfg.ClientCAFile)
+	}
+
+	modTimes := map[string]time.Time{}
+	changed := r.modTimes == nil
+	for _, file := range files {
+		info, err := os.Stat(file)
+		if err != nil {
+			return false, err
+		}
+		modTimes[file] = info.ModTime()
+		if !info.ModTime().Equal(r.modTimes[file]) {
+			changed = true
+		}
+	}
+	if !changed {
+		return false, nil
+	}
+
+	cert, err := tls.LoadX509KeyPair(r.cfg.CertFile, r.cfg.KeyFile)
+	if err != nil {
+		return false, fmt.Errorf("tlsserver: certificado inválido: %w", err)
+	}
+
+	var pool *x509.CertPool
+	if r.cfg.ClientCAFile != "" {
+		data, err := os.ReadFile(r.cfg.ClientCAFile)
+		if err != nil {
+			return false, err
+		}
+		pool = x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(data) {
+			return false, fmt.Errorf("tlsserver: nenhuma CA válida em %s", r.cfg.ClientCAFile)
+		}
+	}
+
+	r.mu.Lock()
+	r.cert, r.clientCA, r.modTimes = &cert, pool, modTimes
+	r.mu.Unlock()
+	return true, nil
+}
+
+// Watch verifica os arquivos a cada intervalo até o context ser cancelado
+func (r *Reloader) Watch(ctx context.Context) {
+	interval := r.cfg.ReloadInterval
+	if interval <= 0 {
+		interval = 30 * time.Second
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			changed, err := r.Reload()
+			if err != nil {
+				log.Println("tlsserver: mantendo o certificado anterior:", err)
+				continue
+			}
+			if changed {
+				log.Println("tlsserver: certificado recarregado")
+			}
+		}
+	}
+}
+
+// ListenAndServe sobe o handler em addr, com TLS quando a Config está habilitada
+func ListenAndServe(ctx context.Context, addr string, handler http.Handler, cfg Config) error {
+	if !cfg.Enabled() {
+		return http.ListenAndServe(addr, handler)
+	}
+
+	reloader, err := NewReloader(cfg)
+	if err != nil {
+		return err
+	}
+	go reloader.Watch(ctx)
+
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		return err
+	}
+
+	server := &http.Server{Handler: handler, TLSConfig: reloader.TLSConfig()}
+	return server.Serve(tls.NewListener(listener, server.TLSConfig))
+}
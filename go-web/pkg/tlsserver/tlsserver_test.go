@@ -0,0 +1,203 @@
+package tlsserver
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"io"
+	"math/big"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/izabelly/go-web/pkg/auth"
+	"github.com/stretchr/testify/require"
+)
+
+type certificate struct {
+	cert *x509.Certificate
+	key  *ecdsa.PrivateKey
+	pem  []byte
+	kpem []byte
+}
+
+func issue(t *testing.T, cn string, serial int64, parent *certificate, usage x509.ExtKeyUsage) *certificate {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(serial),
+		Subject:      pkix.Name{CommonName: cn, Organization: []string{"GoLang"}},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		IPAddresses:  []net.IP{net.ParseIP("127.0.0.1")},
+		ExtKeyUsage:  []x509.ExtKeyUsage{usage},
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+	}
+	signer, signerKey := template, key
+	if parent == nil {
+		template.IsCA = true
+		template.BasicConstraintsValid = true
+		template.KeyUsage |= x509.KeyUsageCertSign
+	} else {
+		signer, signerKey = parent.cert, parent.key
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, signer, &key.PublicKey, signerKey)
+	require.NoError(t, err)
+	cert, err := x509.ParseCertificate(der)
+	require.NoError(t, err)
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	require.NoError(t, err)
+
+	return &certificate{
+		cert: cert,
+		key:  key,
+		pem:  pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}),
+		kpem: pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}),
+	}
+}
+
+func TestReloader_MutualTLS(t *testing.T) {
+	// Arrange/Given
+	dir := t.TempDir()
+	ca := issue(t, "ca", 1, nil, x509.ExtKeyUsageAny)
+	server := issue(t, "server", 2, ca, x509.ExtKeyUsageServerAuth)
+	client := issue(t, "billing", 3, ca, x509.ExtKeyUsageClientAuth)
+
+	cfg := Config{
+		CertFile:     filepath.Join(dir, "server.pem"),
+		KeyFile:      filepath.Join(dir, "server-key.pem"),
+		ClientCAFile: filepath.Join(dir, "ca.pem"),
+		MinVersion:   "1.3",
+	}
+	require.NoError(t, os.WriteFile(cfg.CertFile, server.pem, 0600))
+	require.NoError(t, os.WriteFile(cfg.KeyFile, server.kpem, 0600))
+	require.NoError(t, os.WriteFile(cfg.ClientCAFile, ca.pem, 0600))
+
+	reloader, err := NewReloader(cfg)
+	require.NoError(t, err)
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	handler := auth.ClientCert(map[string][]string{"billing": {"products:read"}})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		principal, _ := auth.FromContext(r.Context())
+		w.Write([]byte(principal.ID))
+	}))
+	srv := &http.Server{Handler: handler, TLSConfig: reloader.TLSConfig()}
+	go srv.Serve(tls.NewListener(listener, srv.TLSConfig))
+	defer srv.Close()
+
+	roots := x509.NewCertPool()
+	roots.AddCert(ca.cert)
+	get := func(withCert bool) (*http.Response, error) {
+		tlsCfg := &tls.Config{RootCAs: roots}
+		if withCert {
+			pair, err := tls.X509KeyPair(client.pem, client.kpem)
+			require.NoError(t, err)
+			tlsCfg.Certificates = []tls.Certificate{pair}
+		}
+		c := &http.Client{Transport: &http.Transport{TLSClientConfig: tlsCfg, DisableKeepAlives: true}}
+		return c.Get("https://" + listener.Addr().String())
+	}
+
+	t.Run("client certificate becomes the principal", func(t *testing.T) {
+		// Act/When
+		res, err := get(true)
+
+		// Assert/Then
+		require.NoError(t, err)
+		defer res.Body.Close()
+		body, _ := io.ReadAll(res.Body)
+		require.Equal(t, "billing", string(body))
+		require.Equal(t, uint16(tls.VersionTLS13), res.TLS.Version)
+	})
+
+	t.Run("without client certificate", func(t *testing.T) {
+		// Act/When
+		res, err := get(false)
+
+		// Assert/Then
+		if err == nil {
+			res.Body.Close()
+		}
+		require.Error(t, err)
+	})
+
+	t.Run("reload the server certificate", func(t *testing.T) {
+		// Arrange/Given
+		renewed := issue(t, "server", 4, ca, x509.ExtKeyUsageServerAuth)
+		require.NoError(t, os.WriteFile(cfg.CertFile, renewed.pem, 0600))
+		require.NoError(t, os.WriteFile(cfg.KeyFile, renewed.kpem, 0600))
+		future := time.Now().Add(time.Minute)
+		require.NoError(t, os.Chtimes(cfg.CertFile, future, future))
+
+		// Act/When
+		changed, err := reloader.Reload()
+		require.NoError(t, err)
+		res, err := get(true)
+
+		// Assert/Then
+		require.True(t, changed)
+		require.NoError(t, err)
+		defer res.Body.Close()
+		require.Equal(t, int64(4), res.TLS.PeerCertificates[0].SerialNumber.Int64())
+	})
+
+	t.Run("invalid file keeps the previous certificate", func(t *testing.T) {
+		// Arrange/Given
+		require.NoError(t, os.WriteFile(cfg.CertFile, []byte("lixo"), 0600))
+		future := time.Now().Add(2 * time.Minute)
+		require.NoError(t, os.Chtimes(cfg.CertFile, future, future))
+
+		// Act/When
+		_, err := reloader.Reload()
+		res, getErr := get(true)
+
+		// Assert/Then
+		require.Error(t, err)
+		require.NoError(t, getErr)
+		defer res.Body.Close()
+		require.Equal(t, int64(4), res.TLS.PeerCertificates[0].SerialNumber.Int64())
+	})
+}
+
+func TestParseVersion(t *testing.T) {
+	v, err := ParseVersion("")
+	require.NoError(t, err)
+	require.Equal(t, uint16(tls.VersionTLS12), v)
+
+	_, err = ParseVersion("1.0")
+	require.Error(t, err)
+}
+
+func TestReloader_CipherSuites(t *testing.T) {
+	// Arrange/Given
+	dir := t.TempDir()
+	server := issue(t, "server", 1, nil, x509.ExtKeyUsageServerAuth)
+	cfg := Config{CertFile: filepath.Join(dir, "server.pem"), KeyFile: filepath.Join(dir, "server-key.pem")}
+	require.NoError(t, os.WriteFile(cfg.CertFile, server.pem, 0600))
+	require.NoError(t, os.WriteFile(cfg.KeyFile, server.kpem, 0600))
+
+	// Act/When
+	reloader, err := NewReloader(cfg)
+
+	// Assert/Then: no suite without forward secrecy (RSA key exchange) or with 3DES/CBC
+	require.NoError(t, err)
+	suites := reloader.TLSConfig().CipherSuites
+	require.NotEmpty(t, suites)
+	for _, id := range suites {
+		name := tls.CipherSuiteName(id)
+		require.True(t, strings.HasPrefix(name, "TLS_ECDHE_"), name)
+		require.NotContains(t, name, "CBC", name)
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"log"
 	"time"
 
+	"github.com/izabelly/go-web/internal/model"
 	"github.com/izabelly/go-web/internal/repository"
 )
 
@@ -14,8 +15,14 @@ func ValidCodeValue(codeValue string, repository *repository.RepositoryProduct)
 		return false
 	}
 
-	for _, product := range listProd {
-		if codeValue == product.CodeValue {
+	return ValidCodeValueList(codeValue, listProd, 0)
+}
+
+// ValidCodeValueList valida o code_value contra uma lista já carregada,
+// ignorando o produto com o id informado (usado em updates)
+func ValidCodeValueList(codeValue string, products []model.Product, ignoreID int) bool {
+	for _, product := range products {
+		if product.ID != ignoreID && codeValue == product.CodeValue {
 			return false
 		}
 	}